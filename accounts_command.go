@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"txn-service/internal/repository"
+	"txn-service/internal/service"
+	"txn-service/models"
+)
+
+const accountsUsage = `usage: txn-service accounts <command>
+
+commands:
+  create --balance <amount> [--id <id>] [--product <code>] [--customer <id>]
+                              create an account
+  show <account>              show an account
+  freeze <account>            stop the account from sending or receiving transfers
+  unfreeze <account>          let a frozen account transfer again
+
+<account> is an account ID or account number. Every command takes
+--tenant <tenant> and -o/--output table|json.
+`
+
+// runAccountsCommand manages accounts through the account service and
+// returns the process exit code
+func runAccountsCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, accountsUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("accounts "+args[0], flag.ContinueOnError)
+	source := configFlags(flags)
+	tenantID := tenantFlag(flags)
+	output := outputFlag(flags)
+	req := &models.CreateAccountRequest{}
+	wantArgs := 1
+	switch args[0] {
+	case "create":
+		flags.Int64Var(&req.AccountID, "id", 0, "account ID, allocated when omitted")
+		flags.StringVar(&req.InitialBalance, "balance", "", "initial balance")
+		flags.StringVar(&req.ProductCode, "product", "", "product code of the account")
+		flags.Int64Var(&req.CustomerID, "customer", 0, "customer owning the account")
+		wantArgs = 0
+	case "show", "freeze", "unfreeze":
+	default:
+		fmt.Fprint(os.Stderr, accountsUsage)
+		return 2
+	}
+	positional, err := parseFlags(flags, args[1:])
+	if err != nil || len(positional) != wantArgs {
+		fmt.Fprint(os.Stderr, accountsUsage)
+		return 2
+	}
+	if err := checkOutput(*output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	var accountID int64
+	if wantArgs == 1 {
+		if accountID, err = parseAccountReference(positional[0]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	ctx, err := tenantContext(*tenantID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	env, err := openAdminEnv(source, true)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer env.Close()
+
+	accountService := service.NewAccountService(
+		repository.NewAccountRepository(env.db, env.log),
+		repository.NewProductRepository(env.db, env.log),
+		repository.NewCustomerRepository(env.db, env.log),
+		env.log,
+	)
+
+	var account *models.Account
+	switch args[0] {
+	case "create":
+		account, err = accountService.CreateAccount(ctx, req)
+	case "show":
+		account, err = accountService.GetAccount(ctx, accountID)
+	case "freeze":
+		account, err = accountService.FreezeAccount(ctx, accountID)
+	case "unfreeze":
+		account, err = accountService.UnfreezeAccount(ctx, accountID)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to %s account: %v\n", args[0], err)
+		return 1
+	}
+
+	if err := printOutput(*output, account, func(w io.Writer) { printAccounts(w, account) }); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print account: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printAccounts writes accounts as table rows
+func printAccounts(w io.Writer, accounts ...*models.Account) {
+	fmt.Fprintln(w, "ACCOUNT ID\tACCOUNT NUMBER\tBALANCE\tPRODUCT\tCUSTOMER\tFROZEN")
+	for _, account := range accounts {
+		product, customer := "-", "-"
+		if account.ProductCode != nil {
+			product = *account.ProductCode
+		}
+		if account.CustomerID != nil {
+			customer = fmt.Sprint(*account.CustomerID)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", account.AccountID, account.AccountNumber, account.Balance, product, customer, formatTime(account.FrozenAt))
+	}
+}
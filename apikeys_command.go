@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/repository"
+	"txn-service/internal/service"
+	"txn-service/internal/tenant"
+)
+
+const apiKeysUsage = `usage: txn-service apikeys <command>
+
+commands:
+  create --name <name> --scopes <scope,scope,...> [--tenant <tenant>]
+                                                    create a key and print its token
+  revoke <key id>                                   revoke a key
+  list                                              list all keys
+
+create and list take -o/--output table|json.
+
+scopes: %s
+`
+
+// apiKeyCreatedOutput is what apikeys create prints, the only time the token
+// is ever shown
+type apiKeyCreatedOutput struct {
+	KeyID    string   `json:"key_id"`
+	TenantID string   `json:"tenant_id"`
+	Scopes   []string `json:"scopes"`
+	Token    string   `json:"token"`
+}
+
+// runAPIKeysCommand manages API keys against the configured database and
+// returns the process exit code
+func runAPIKeysCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, apiKeysUsage, strings.Join(auth.AllScopes, ", "))
+		return 2
+	}
+
+	flags := flag.NewFlagSet("apikeys "+args[0], flag.ContinueOnError)
+	source := configFlags(flags)
+	output := outputFlag(flags)
+	var name, scopes, tenantID *string
+	wantArgs := 0
+	switch args[0] {
+	case "create":
+		name = flags.String("name", "", "name describing who uses the key")
+		scopes = flags.String("scopes", "", "comma separated scopes to grant")
+		tenantID = flags.String("tenant", tenant.Default, "tenant the key acts in")
+	case "revoke":
+		wantArgs = 1
+	case "list":
+	default:
+		fmt.Fprintf(os.Stderr, apiKeysUsage, strings.Join(auth.AllScopes, ", "))
+		return 2
+	}
+	positional, err := parseFlags(flags, args[1:])
+	if err != nil || len(positional) != wantArgs {
+		fmt.Fprintf(os.Stderr, apiKeysUsage, strings.Join(auth.AllScopes, ", "))
+		return 2
+	}
+	if err := checkOutput(*output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	env, err := openAdminEnv(source, true)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer env.Close()
+
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(env.db, env.log), env.log)
+	ctx := context.Background()
+
+	switch args[0] {
+	case "create":
+		key, token, err := apiKeyService.CreateAPIKey(ctx, *name, *tenantID, splitScopes(*scopes))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create API key: %v\n", err)
+			return 1
+		}
+
+		created := apiKeyCreatedOutput{KeyID: key.KeyID, TenantID: key.TenantID, Scopes: key.Scopes, Token: token}
+		err = printOutput(*output, created, func(w io.Writer) {
+			fmt.Fprintf(w, "key_id:\t%s\ntenant:\t%s\nscopes:\t%s\ntoken:\t%s\n", created.KeyID, created.TenantID, strings.Join(created.Scopes, ","), created.Token)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to print API key: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(os.Stderr, "Store the token now, it cannot be shown again.")
+
+	case "revoke":
+		if err := apiKeyService.RevokeAPIKey(ctx, positional[0]); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to revoke API key: %v\n", err)
+			return 1
+		}
+
+		fmt.Printf("revoked %s\n", positional[0])
+
+	case "list":
+		keys, err := apiKeyService.ListAPIKeys(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to list API keys: %v\n", err)
+			return 1
+		}
+
+		err = printOutput(*output, keys, func(w io.Writer) {
+			fmt.Fprintln(w, "KEY ID\tNAME\tTENANT\tSCOPES\tCREATED\tREVOKED")
+			for _, key := range keys {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", key.KeyID, key.Name, key.TenantID, strings.Join(key.Scopes, ","), key.CreatedAt.Format(time.RFC3339), formatTime(key.RevokedAt))
+			}
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to print API keys: %v\n", err)
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func splitScopes(value string) []string {
+	var scopes []string
+	for _, scope := range strings.Split(value, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"txn-service/internal/accountnumber"
+	"txn-service/internal/config"
+	"txn-service/internal/database"
+	"txn-service/internal/logger"
+	"txn-service/internal/tenant"
+)
+
+// adminEnv is what the admin commands share: the server's configuration, a
+// logger on stderr so log lines never mix with the output, and the database
+type adminEnv struct {
+	cfg *config.Config
+	log *logger.Logger
+	db  *sql.DB
+}
+
+// configSource is where a command reads its configuration from besides the
+// environment: the --config file and the --set overrides
+type configSource struct {
+	file      string
+	overrides stringList
+}
+
+// stringList is a flag that may be repeated
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// configFlags adds the --config and --set flags to flags
+func configFlags(flags *flag.FlagSet) *configSource {
+	source := &configSource{}
+	flags.StringVar(&source.file, "config", "", "YAML config file, $"+config.FileEnv+" by default")
+	flags.Var(&source.overrides, "set", "key=value overriding a setting of the config file and environment, may be repeated")
+	return source
+}
+
+func (s *configSource) load() (*config.Config, error) {
+	return config.Load(s.file, s.overrides)
+}
+
+// openAdminEnv connects to the configured database. Only the migrate command
+// changes the schema; the others require it to be up to date.
+func openAdminEnv(source *configSource, requireMigrated bool) (*adminEnv, error) {
+	cfg, err := source.load()
+	if err != nil {
+		return nil, err
+	}
+
+	log := logger.NewFromConfig(cfg.LoggerConfig())
+	log.SetOutput(os.Stderr)
+
+	db, err := database.Open(cfg.DatabaseURL, cfg.DatabaseOptions())
+	if err != nil {
+		log.Close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if requireMigrated {
+		if err := database.CheckSchemaVersion(context.Background(), db); err != nil {
+			db.Close()
+			log.Close()
+			return nil, fmt.Errorf("database schema is not up to date, run \"txn-service migrate up\" first: %w", err)
+		}
+	}
+
+	return &adminEnv{cfg: cfg, log: log, db: db}, nil
+}
+
+func (e *adminEnv) Close() {
+	e.db.Close()
+	e.log.Close()
+}
+
+// Output formats of the admin commands
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// outputFlag adds the -o/--output flag to flags
+func outputFlag(flags *flag.FlagSet) *string {
+	output := outputTable
+	flags.StringVar(&output, "output", outputTable, "output format: table or json")
+	flags.StringVar(&output, "o", outputTable, "shorthand for --output")
+	return &output
+}
+
+// checkOutput fails for an unknown output format, before the command has
+// done anything
+func checkOutput(format string) error {
+	if format != outputTable && format != outputJSON {
+		return fmt.Errorf("unknown output format %q, must be table or json", format)
+	}
+	return nil
+}
+
+// tenantFlag adds the --tenant flag to flags
+func tenantFlag(flags *flag.FlagSet) *string {
+	return flags.String("tenant", tenant.Default, "tenant to act in")
+}
+
+// tenantContext is the context of a command acting in tenantID
+func tenantContext(tenantID string) (context.Context, error) {
+	if err := tenant.Validate(tenantID); err != nil {
+		return nil, fmt.Errorf("invalid tenant: %w", err)
+	}
+	return tenant.WithID(context.Background(), tenantID), nil
+}
+
+// parseFlags parses flags given before, between or after the positional
+// arguments, which it returns. The commands print their own usage on error.
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	flags.Usage = func() {}
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, err
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// printOutput writes value to stdout as indented JSON, or as the table that
+// table writes
+func printOutput(format string, value interface{}, table func(w io.Writer)) error {
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case outputTable:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		table(w)
+		return w.Flush()
+	default:
+		return checkOutput(format)
+	}
+}
+
+// parseAccountReference accepts an account ID or an account number
+func parseAccountReference(value string) (int64, error) {
+	if accountnumber.IsAccountNumber(value) {
+		return accountnumber.Parse(value)
+	}
+	accountID, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || accountID <= 0 {
+		return 0, fmt.Errorf("%q is neither an account ID nor an account number", value)
+	}
+	return accountID, nil
+}
+
+// formatTime prints t in RFC 3339, or "-" for nil
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"testing"
+
+	"txn-service/internal/accountnumber"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFlagsAcceptsInterspersedFlags(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	reason := flags.String("reason", "", "")
+	output := outputFlag(flags)
+
+	positional, err := parseFlags(flags, []string{"-o", "json", "first", "--reason", "duplicate", "second"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, positional)
+	assert.Equal(t, "duplicate", *reason)
+	assert.Equal(t, outputJSON, *output)
+
+	_, err = parseFlags(flag.NewFlagSet("test", flag.ContinueOnError), []string{"first", "--unknown"})
+	assert.Error(t, err)
+}
+
+func TestParseAccountReference(t *testing.T) {
+	accountID, err := parseAccountReference("42")
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), accountID)
+
+	accountID, err = parseAccountReference(accountnumber.Format(42))
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), accountID)
+
+	for _, reference := range []string{"", "0", "-1", "abc"} {
+		_, err := parseAccountReference(reference)
+		assert.Error(t, err, reference)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+const configUsage = `usage: txn-service config print [--config <file>] [--set <key>=<value> ...]
+
+Validates the configuration the other commands would run with, taken from
+the defaults, the config file, the environment and the --set flags, and
+prints it with the secrets redacted. The YAML printed is a valid config file.
+`
+
+// runConfigCommand prints the effective configuration and returns the process
+// exit code
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("config print", flag.ContinueOnError)
+	source := configFlags(flags)
+	if positional, err := parseFlags(flags, args[1:]); err != nil || len(positional) > 0 {
+		fmt.Fprint(os.Stderr, configUsage)
+		return 2
+	}
+
+	cfg, err := source.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(cfg.Redacted()); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print configuration: %v\n", err)
+		return 1
+	}
+	return 0
+}
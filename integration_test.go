@@ -1,19 +1,46 @@
 package main
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
 	"math/big"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"txn-service/internal/auth"
+	"txn-service/internal/config"
+	"txn-service/internal/database"
+	"txn-service/internal/handlers"
+	"txn-service/internal/logger"
+	"txn-service/internal/ratelimit"
+	"txn-service/internal/repository"
+	"txn-service/internal/service"
+	"txn-service/internal/settings"
 	"txn-service/internal/testutil"
+	"txn-service/models"
+	txnv1 "txn-service/proto/txn/v1"
 
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestBasicTransactionFlow(t *testing.T) {
@@ -92,6 +119,36 @@ func TestInsufficientBalance(t *testing.T) {
 	assert.Equal(t, expectedBalanceFloat, balance2Float, "Account 2 balance should remain unchanged")
 }
 
+func TestTransferAtMaxPrecisionAndAmount(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ts.CreateTestAccount(t, 111, "999999999999")
+	ts.CreateTestAccount(t, 112, "123456789012.12345678")
+	ts.CreateTestAccount(t, 113, "0")
+
+	// amounts and balances with more digits than a float64 holds move exactly
+	require.NotEmpty(t, ts.CreateTransaction(t, 112, 111, "0.00000001"))
+	assert.Equal(t, "123456789012.12345677", ts.GetAccountBalance(t, 112))
+	assert.Equal(t, "999999999999.00000001", ts.GetAccountBalance(t, 111))
+
+	require.NotEmpty(t, ts.CreateTransaction(t, 111, 113, "999999999999"))
+	assert.Equal(t, "0.00000001", ts.GetAccountBalance(t, 111))
+	assert.Equal(t, "999999999999.00000000", ts.GetAccountBalance(t, 113))
+
+	require.NotEmpty(t, ts.CreateTransaction(t, 112, 113, "0.99999999"))
+	assert.Equal(t, "999999999999.99999999", ts.GetAccountBalance(t, 113))
+
+	// one more unit does not fit the balance column and moves nothing
+	resp, err := http.Post(ts.Server.URL+"/transactions", "application/json",
+		strings.NewReader(`{"source_account_id": 112, "destination_account_id": 113, "amount": "0.00000001"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, "999999999999.99999999", ts.GetAccountBalance(t, 113))
+	assert.Equal(t, "123456789011.12345678", ts.GetAccountBalance(t, 112))
+}
+
 func TestConcurrencyHandling(t *testing.T) {
 	ts := testutil.SetupTestServer(t)
 	defer ts.Cleanup()
@@ -417,3 +474,1166 @@ func TestMultiAccountTransactionFlow(t *testing.T) {
 	fmt.Printf("Total money in system: %.2f (should be 3000.00)\n", actualTotal)
 	fmt.Printf("Multi-account transaction flow test completed successfully!\n")
 }
+
+func TestInterestAccrualAndPosting(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ctx := context.Background()
+	savingsAccountID := int64(7001)
+
+	ts.CreateTestAccount(t, testutil.InterestExpenseAccountID, "0")
+	// 3.65% ACT/365 on 1000.00 accrues exactly 0.10 per day
+	ts.CreateTestProduct(t, "SAVINGS", "0.0365", "ACT/365")
+	ts.CreateTestAccountWithProduct(t, savingsAccountID, "1000.00", "SAVINGS")
+
+	today := time.Now().UTC()
+	nextMonth := time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+	daysAccrued := nextMonth.Sub(time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, time.UTC)).Hours() / 24
+
+	// running on the first of next month accrues the rest of this month and posts it
+	require.NoError(t, ts.InterestService.Run(ctx, nextMonth))
+
+	var accruals int
+	require.NoError(t, ts.DB.QueryRow("SELECT COUNT(*) FROM interest_accruals WHERE account_id = $1", savingsAccountID).Scan(&accruals))
+	assert.Equal(t, int(daysAccrued), accruals, "One accrual per day expected")
+
+	expectedInterest := 0.10 * daysAccrued
+	savingsBalance, _ := strconv.ParseFloat(ts.GetAccountBalance(t, savingsAccountID), 64)
+	expenseBalance, _ := strconv.ParseFloat(ts.GetAccountBalance(t, testutil.InterestExpenseAccountID), 64)
+	assert.InDelta(t, 1000.0+expectedInterest, savingsBalance, 0.000001, "Savings account should receive the month's interest")
+	assert.InDelta(t, -expectedInterest, expenseBalance, 0.000001, "Interest should be paid from the expense account")
+
+	// re-running the job must neither accrue nor post again
+	require.NoError(t, ts.InterestService.Run(ctx, nextMonth))
+
+	require.NoError(t, ts.DB.QueryRow("SELECT COUNT(*) FROM interest_accruals WHERE account_id = $1", savingsAccountID).Scan(&accruals))
+	assert.Equal(t, int(daysAccrued), accruals, "Re-running the job must not accrue twice")
+
+	rerunBalance, _ := strconv.ParseFloat(ts.GetAccountBalance(t, savingsAccountID), 64)
+	assert.Equal(t, savingsBalance, rerunBalance, "Re-running the job must not post twice")
+}
+
+func TestInterestAccrualAfterReversal(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ctx := context.Background()
+	log := logger.New("ERROR")
+	accountRepo := repository.NewAccountRepository(ts.DB, log)
+	transactionService := service.NewTransactionService(repository.NewTransactionRepository(ts.DB, sql.LevelReadCommitted, log), accountRepo, settings.NewStore(&settings.Settings{}), log)
+
+	ts.CreateTestAccount(t, testutil.InterestExpenseAccountID, "0")
+	ts.CreateTestProduct(t, "SAVINGS", "0.0365", "ACT/365")
+	ts.CreateTestAccountWithProduct(t, 7101, "1000.00", "SAVINGS")
+	ts.CreateTestAccount(t, 7102, "0")
+
+	// the account was opened two days ago; today it sends 200.00, which is reversed
+	_, err := ts.DB.Exec("UPDATE accounts SET created_at = created_at - INTERVAL '2 days' WHERE account_id = 7101")
+	require.NoError(t, err)
+	transactionID, err := uuid.Parse(ts.CreateTransaction(t, 7101, 7102, "200.00"))
+	require.NoError(t, err)
+	_, err = transactionService.ReverseTransaction(ctx, transactionID, "")
+	require.NoError(t, err)
+
+	yesterday := time.Now().UTC().AddDate(0, 0, -1)
+	require.NoError(t, ts.InterestService.AccrueThrough(ctx, yesterday))
+
+	rows, err := ts.DB.Query("SELECT end_of_day_balance FROM interest_accruals WHERE account_id = $1", 7101)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	var balances []float64
+	for rows.Next() {
+		var balance float64
+		require.NoError(t, rows.Scan(&balance))
+		balances = append(balances, balance)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []float64{1000, 1000}, balances, "The transfer and its reversal both happened after the accrued days")
+}
+
+func TestCustomerAccountsAndKYCRestriction(t *testing.T) {
+	t.Setenv("REQUIRE_VERIFIED_KYC", "true")
+
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	verifiedID := ts.CreateTestCustomer(t, "cust-verified", "verified")
+	pendingID := ts.CreateTestCustomer(t, "cust-pending", "pending")
+
+	ts.CreateTestAccountForCustomer(t, 8001, "100.50", verifiedID)
+	ts.CreateTestAccountForCustomer(t, 8002, "200.25", verifiedID)
+	ts.CreateTestAccountForCustomer(t, 8003, "50.00", pendingID)
+
+	resp, err := http.Get(fmt.Sprintf("%s/customers/%d/accounts", ts.Server.URL, verifiedID))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var accounts struct {
+		AccountCount int    `json:"account_count"`
+		TotalBalance string `json:"total_balance"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&accounts))
+	resp.Body.Close()
+
+	totalBalance, _ := strconv.ParseFloat(accounts.TotalBalance, 64)
+	assert.Equal(t, 2, accounts.AccountCount)
+	assert.Equal(t, 300.75, totalBalance)
+
+	// both owners verified
+	assert.NotEmpty(t, ts.CreateTransaction(t, 8001, 8002, "10.00"))
+
+	// destination owner still pending
+	payload := `{"source_account_id": 8001, "destination_account_id": 8003, "amount": "10.00"}`
+	resp, err = http.Post(fmt.Sprintf("%s/transactions", ts.Server.URL), "application/json", strings.NewReader(payload))
+	require.NoError(t, err)
+	assert.NotEqual(t, http.StatusOK, resp.StatusCode, "Transfer to an unverified owner should fail")
+	resp.Body.Close()
+
+	req, err := http.NewRequest("PUT", fmt.Sprintf("%s/customers/%d/kyc_status", ts.Server.URL, pendingID), strings.NewReader(`{"kyc_status": "verified"}`))
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp.Body.Close()
+
+	assert.NotEmpty(t, ts.CreateTransaction(t, 8001, 8003, "10.00"))
+}
+
+func TestTransactionMetadataAndSearch(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ts.CreateTestAccount(t, 9101, "100.00")
+	ts.CreateTestAccount(t, 9102, "100.00")
+
+	payload := `{
+		"source_account_id": 9101,
+		"destination_account_id": 9102,
+		"amount": "5.00",
+		"reference": "order-1234",
+		"description": "Order payment",
+		"metadata": {"channel": "web", "invoice": "inv-77"}
+	}`
+	resp, err := http.Post(fmt.Sprintf("%s/transactions", ts.Server.URL), "application/json", strings.NewReader(payload))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var created struct {
+		TransactionID string `json:"transaction_id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	ts.CreateTransaction(t, 9101, 9102, "1.00")
+
+	resp, err = http.Get(fmt.Sprintf("%s/transactions/%s", ts.Server.URL, created.TransactionID))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var transaction struct {
+		Reference   string            `json:"reference"`
+		Description string            `json:"description"`
+		Metadata    map[string]string `json:"metadata"`
+		Status      string            `json:"status"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&transaction))
+	resp.Body.Close()
+
+	assert.Equal(t, "order-1234", transaction.Reference)
+	assert.Equal(t, "Order payment", transaction.Description)
+	assert.Equal(t, map[string]string{"channel": "web", "invoice": "inv-77"}, transaction.Metadata)
+	assert.Equal(t, "completed", transaction.Status)
+
+	search := func(query string) int {
+		resp, err := http.Get(fmt.Sprintf("%s/transactions?%s", ts.Server.URL, query))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result struct {
+			Transactions []map[string]interface{} `json:"transactions"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+		return len(result.Transactions)
+	}
+
+	assert.Equal(t, 1, search("reference=order-1234"))
+	assert.Equal(t, 1, search("metadata.invoice=inv-77"))
+	assert.Equal(t, 1, search("reference=order-1234&metadata.channel=web"))
+	assert.Equal(t, 0, search("reference=order-1234&metadata.channel=pos"))
+
+	tooLong := strings.Repeat("x", 300)
+	payload = fmt.Sprintf(`{"source_account_id": 9101, "destination_account_id": 9102, "amount": "1.00", "reference": "%s"}`, tooLong)
+	resp, err = http.Post(fmt.Sprintf("%s/transactions", ts.Server.URL), "application/json", strings.NewReader(payload))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "Oversized reference should be rejected")
+	resp.Body.Close()
+}
+
+func TestServerAssignedAccountIDsAndAccountNumbers(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	sourceID, sourceNumber := ts.CreateTestAccountWithServerID(t, "100.00")
+	destinationID, destinationNumber := ts.CreateTestAccountWithServerID(t, "0.00")
+
+	assert.Positive(t, sourceID)
+	assert.NotEqual(t, sourceID, destinationID, "Allocated IDs must be unique")
+
+	// client supplied IDs keep working next to allocated ones
+	ts.CreateTestAccount(t, 42, "10.00")
+
+	resp, err := http.Get(fmt.Sprintf("%s/accounts/%s", ts.Server.URL, sourceNumber))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var account struct {
+		AccountID     int64  `json:"account_id"`
+		AccountNumber string `json:"account_number"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&account))
+	resp.Body.Close()
+	assert.Equal(t, sourceID, account.AccountID)
+	assert.Equal(t, sourceNumber, account.AccountNumber)
+
+	payload := fmt.Sprintf(`{"source_account_number": "%s", "destination_account_number": "%s", "amount": "25.00"}`, sourceNumber, destinationNumber)
+	resp, err = http.Post(fmt.Sprintf("%s/transactions", ts.Server.URL), "application/json", strings.NewReader(payload))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	destinationBalance, _ := strconv.ParseFloat(ts.GetAccountBalance(t, destinationID), 64)
+	assert.Equal(t, 25.0, destinationBalance)
+
+	// a typo in the account number must be caught by the check digits
+	corrupted := sourceNumber[:len(sourceNumber)-1] + string("0123456789"[(int(sourceNumber[len(sourceNumber)-1]-'0')+1)%10])
+	resp, err = http.Get(fmt.Sprintf("%s/accounts/%s", ts.Server.URL, corrupted))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func TestErrorCodes(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ts.CreateTestAccount(t, 9201, "10.00")
+	ts.CreateTestAccount(t, 9202, "10.00")
+
+	expectError := func(resp *http.Response, statusCode int, errorCode string) {
+		t.Helper()
+		defer resp.Body.Close()
+
+		var problem struct {
+			Status   int    `json:"status"`
+			Code     string `json:"code"`
+			Instance string `json:"instance"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+		assert.Equal(t, statusCode, resp.StatusCode)
+		assert.Equal(t, statusCode, problem.Status)
+		assert.Equal(t, errorCode, problem.Code)
+		assert.Equal(t, resp.Header.Get("X-Request-ID"), problem.Instance)
+	}
+
+	resp, err := http.Post(fmt.Sprintf("%s/accounts", ts.Server.URL), "application/json", strings.NewReader(`{"account_id": 9201, "initial_balance": "1.00"}`))
+	require.NoError(t, err)
+	expectError(resp, http.StatusConflict, "ACCOUNT_ALREADY_EXISTS")
+
+	resp, err = http.Get(fmt.Sprintf("%s/accounts/%d", ts.Server.URL, 9299))
+	require.NoError(t, err)
+	expectError(resp, http.StatusNotFound, "ACCOUNT_NOT_FOUND")
+
+	resp, err = http.Post(fmt.Sprintf("%s/transactions", ts.Server.URL), "application/json", strings.NewReader(`{"source_account_id": 9299, "destination_account_id": 9201, "amount": "1.00"}`))
+	require.NoError(t, err)
+	expectError(resp, http.StatusNotFound, "ACCOUNT_NOT_FOUND")
+
+	resp, err = http.Post(fmt.Sprintf("%s/transactions", ts.Server.URL), "application/json", strings.NewReader(`{"source_account_id": 9201, "destination_account_id": 9202, "amount": "50.00"}`))
+	require.NoError(t, err)
+	expectError(resp, http.StatusUnprocessableEntity, "INSUFFICIENT_FUNDS")
+
+	resp, err = http.Post(fmt.Sprintf("%s/transactions", ts.Server.URL), "application/json", strings.NewReader(`{"source_account_id": 9201, "destination_account_id": 9201, "amount": "1.00"}`))
+	require.NoError(t, err)
+	expectError(resp, http.StatusBadRequest, "VALIDATION_FAILED")
+}
+
+func TestRequestValidation(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ts.CreateTestAccount(t, 9301, "100.00")
+	ts.CreateTestAccount(t, 9302, "100.00")
+
+	post := func(path, body string) (*http.Response, map[string]string) {
+		t.Helper()
+
+		resp, err := http.Post(ts.Server.URL+path, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var problem struct {
+			Code   string `json:"code"`
+			Errors []struct {
+				Field   string `json:"field"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+
+		fields := map[string]string{"code": problem.Code}
+		for _, fieldErr := range problem.Errors {
+			fields[fieldErr.Field] = fieldErr.Message
+		}
+		return resp, fields
+	}
+
+	// every violation is reported at once
+	resp, fields := post("/transactions", `{"destination_account_id": -1, "amount": "1.123456789"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "VALIDATION_FAILED", fields["code"])
+	assert.Contains(t, fields, "source_account_id")
+	assert.Contains(t, fields, "destination_account_id")
+	assert.Contains(t, fields, "amount")
+
+	resp, fields = post("/transactions", `{"source_account_id": 9301, "destination_account_id": 9302, "amount": "1e3"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "must be a decimal number", fields["amount"])
+
+	resp, fields = post("/accounts", `{"account_id": 9303, "initial_balance": "1.00", "balanse": "2.00"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "is not a known field", fields["balanse"])
+
+	resp, fields = post("/accounts", fmt.Sprintf(`{"initial_balance": "1.00", "product_code": "%s"}`, strings.Repeat("x", 70<<10)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+	assert.Equal(t, "PAYLOAD_TOO_LARGE", fields["code"])
+}
+
+func TestGRPCAPI(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	accounts := txnv1.NewAccountServiceClient(ts.GRPCConn)
+	transactions := txnv1.NewTransactionServiceClient(ts.GRPCConn)
+
+	source, err := accounts.CreateAccount(ctx, &txnv1.CreateAccountRequest{AccountId: 9401, InitialBalance: "100.00"})
+	require.NoError(t, err)
+	destination, err := accounts.CreateAccount(ctx, &txnv1.CreateAccountRequest{InitialBalance: "0"})
+	require.NoError(t, err)
+	assert.Positive(t, destination.Account.AccountId)
+
+	created, err := transactions.CreateTransaction(ctx, &txnv1.CreateTransactionRequest{
+		SourceAccountNumber:  source.Account.AccountNumber,
+		DestinationAccountId: destination.Account.AccountId,
+		Amount:               "40.00",
+		Reference:            "grpc-1",
+	})
+	require.NoError(t, err)
+
+	transaction, err := transactions.GetTransaction(ctx, &txnv1.GetTransactionRequest{TransactionId: created.TransactionId})
+	require.NoError(t, err)
+	assert.Equal(t, "completed", transaction.Transaction.Status)
+	assert.Equal(t, "grpc-1", transaction.Transaction.Reference)
+
+	// the HTTP API sees the same data
+	destinationBalance, _ := strconv.ParseFloat(ts.GetAccountBalance(t, destination.Account.AccountId), 64)
+	assert.Equal(t, 40.0, destinationBalance)
+
+	_, err = transactions.CreateTransaction(ctx, &txnv1.CreateTransactionRequest{SourceAccountId: 9401, DestinationAccountId: destination.Account.AccountId, Amount: "1000.00"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	_, err = accounts.GetAccount(ctx, &txnv1.GetAccountRequest{Account: &txnv1.GetAccountRequest_AccountId{AccountId: 9499}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = accounts.CreateAccount(ctx, &txnv1.CreateAccountRequest{AccountId: 9401, InitialBalance: "1.00"})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+}
+
+func TestAPIKeyAuthentication(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ts.CreateTestAccount(t, 9601, "100.00")
+	ts.CreateTestAccount(t, 9602, "100.00")
+
+	do := func(method, path, token, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.Server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	expectCode := func(resp *http.Response, statusCode int, errorCode string) {
+		t.Helper()
+		defer resp.Body.Close()
+
+		var problem struct {
+			Code string `json:"code"`
+		}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		assert.Equal(t, statusCode, resp.StatusCode)
+		assert.Equal(t, errorCode, problem.Code)
+	}
+
+	transfer := `{"source_account_id": 9601, "destination_account_id": 9602, "amount": "1.00"}`
+
+	// public routes need no key
+	resp := do("GET", "/livez", "", "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = do("GET", "/accounts/9601", "", "")
+	assert.Equal(t, "Bearer realm=\"txn-service\"", resp.Header.Get("WWW-Authenticate"))
+	expectCode(resp, http.StatusUnauthorized, "UNAUTHENTICATED")
+
+	expectCode(do("GET", "/accounts/9601", "txn_0123456789abcdef_forged", ""), http.StatusUnauthorized, "UNAUTHENTICATED")
+
+	reader := ts.CreateTestAPIKey(t, "accounts:read")
+	resp = do("GET", "/accounts/9601", reader, "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	expectCode(do("POST", "/transactions", reader, transfer), http.StatusForbidden, "FORBIDDEN")
+
+	// the key that created a transaction is recorded on it
+	writer := ts.CreateTestAPIKey(t, "transactions:read", "transactions:write")
+	resp = do("POST", "/transactions", writer, transfer)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var created struct {
+		TransactionID string `json:"transaction_id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	resp = do("GET", "/transactions/"+created.TransactionID, writer, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var transaction struct {
+		APIKeyID string `json:"api_key_id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&transaction))
+	resp.Body.Close()
+	assert.True(t, strings.HasPrefix(writer, "txn_"+transaction.APIKeyID+"_"), "transaction records key %q", transaction.APIKeyID)
+
+	// revoked keys are rejected
+	require.NoError(t, ts.APIKeyService.RevokeAPIKey(context.Background(), transaction.APIKeyID))
+	expectCode(do("GET", "/transactions/"+created.TransactionID, writer, ""), http.StatusUnauthorized, "UNAUTHENTICATED")
+
+	// gRPC checks the same keys and scopes
+	client := txnv1.NewAccountServiceClient(ts.GRPCConn)
+	_, err := client.GetAccount(context.Background(), &txnv1.GetAccountRequest{Account: &txnv1.GetAccountRequest_AccountId{AccountId: 9601}})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+reader)
+	_, err = client.GetAccount(ctx, &txnv1.GetAccountRequest{Account: &txnv1.GetAccountRequest_AccountId{AccountId: 9601}})
+	assert.NoError(t, err)
+}
+
+func TestJWTAuthentication(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	jwks := fmt.Sprintf(`{"keys": [{"kty": "EC", "kid": "test", "crv": "P-256", "x": %q, "y": %q}]}`,
+		base64.RawURLEncoding.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+		base64.RawURLEncoding.EncodeToString(key.Y.FillBytes(make([]byte, 32))))
+	require.NoError(t, os.WriteFile(jwksPath, []byte(jwks), 0o600))
+
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("JWT_JWKS_URL", jwksPath)
+	t.Setenv("JWT_ISSUER", "https://gateway.example")
+
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	alice := ts.CreateTestCustomer(t, "jwt-alice", "verified")
+	bob := ts.CreateTestCustomer(t, "jwt-bob", "verified")
+	ts.CreateTestAccountForCustomer(t, 9701, "100.00", alice)
+	ts.CreateTestAccountForCustomer(t, 9702, "100.00", bob)
+	ts.CreateTestAccountForCustomer(t, 9703, "100.00", bob)
+
+	sign := func(claims jwt.MapClaims) string {
+		t.Helper()
+		claims["iss"] = "https://gateway.example"
+		claims["exp"] = time.Now().Add(time.Hour).Unix()
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+		token.Header["kid"] = "test"
+		signed, err := token.SignedString(key)
+		require.NoError(t, err)
+		return signed
+	}
+
+	aliceToken := sign(jwt.MapClaims{"sub": "alice", "customer_id": alice, "roles": []string{"customer"}, "scope": "accounts:read transactions:read transactions:write"})
+	adminToken := sign(jwt.MapClaims{"sub": "ops", "roles": []string{"admin"}, "scope": "accounts:read transactions:write"})
+
+	do := func(method, path, token, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.Server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	expectStatus := func(resp *http.Response, statusCode int) {
+		t.Helper()
+		resp.Body.Close()
+		assert.Equal(t, statusCode, resp.StatusCode)
+	}
+
+	// customers only see their own accounts, admins see all of them
+	expectStatus(do("GET", "/accounts/9701", aliceToken, ""), http.StatusOK)
+	expectStatus(do("GET", "/accounts/9702", aliceToken, ""), http.StatusForbidden)
+	expectStatus(do("GET", "/accounts/9702", adminToken, ""), http.StatusOK)
+
+	// customers can pay anyone, but only out of their own accounts
+	expectStatus(do("POST", "/transactions", aliceToken, `{"source_account_id": 9701, "destination_account_id": 9702, "amount": "1.00", "reference": "jwt-ref"}`), http.StatusOK)
+	expectStatus(do("POST", "/transactions", aliceToken, `{"source_account_id": 9702, "destination_account_id": 9701, "amount": "1.00"}`), http.StatusForbidden)
+
+	expectStatus(do("POST", "/transactions", adminToken, `{"source_account_id": 9702, "destination_account_id": 9703, "amount": "1.00", "reference": "jwt-ref"}`), http.StatusOK)
+
+	// search is limited to transfers touching the customer's accounts
+	resp := do("GET", "/transactions?reference=jwt-ref", aliceToken, "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var found models.TransactionListResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&found))
+	resp.Body.Close()
+	assert.Len(t, found.Transactions, 1)
+
+	// tokens from other issuers or without the scope are rejected
+	expectStatus(do("GET", "/accounts/9701", sign(jwt.MapClaims{"sub": "alice", "customer_id": alice}), ""), http.StatusForbidden)
+	otherIssuer := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": "https://evil.example", "sub": "alice", "customer_id": alice, "scope": "accounts:read", "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	otherIssuer.Header["kid"] = "test"
+	otherIssuerToken, err := otherIssuer.SignedString(key)
+	require.NoError(t, err)
+	expectStatus(do("GET", "/accounts/9701", otherIssuerToken, ""), http.StatusUnauthorized)
+}
+
+func TestSignedRequests(t *testing.T) {
+	secret := "0123456789abcdef0123456789abcdef"
+	clientsFile := filepath.Join(t.TempDir(), "clients.json")
+	require.NoError(t, os.WriteFile(clientsFile, []byte(`{"clients": [{"id": "partner", "secret": "`+secret+`", "scopes": ["transactions:write"]}]}`), 0o600))
+
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("HMAC_CLIENTS_FILE", clientsFile)
+
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ts.CreateTestAccount(t, 9801, "100.00")
+	ts.CreateTestAccount(t, 9802, "100.00")
+
+	newRequest := func(body string) *http.Request {
+		t.Helper()
+		req, err := http.NewRequest("POST", ts.Server.URL+"/transactions", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+
+	send := func(req *http.Request) int {
+		t.Helper()
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	body := `{"source_account_id": 9801, "destination_account_id": 9802, "amount": "10.00"}`
+
+	req := newRequest(body)
+	require.NoError(t, auth.SignRequest(req, "partner", secret, time.Now()))
+	signature := req.Header.Get("Authorization")
+	assert.Equal(t, http.StatusOK, send(req))
+
+	// replaying the exact request is rejected
+	replay := newRequest(body)
+	replay.Header.Set("Authorization", signature)
+	assert.Equal(t, http.StatusUnauthorized, send(replay))
+
+	// so is a request whose body was changed after signing
+	tampered := newRequest(body)
+	require.NoError(t, auth.SignRequest(tampered, "partner", secret, time.Now()))
+	tampered.Body = io.NopCloser(strings.NewReader(strings.Replace(body, "10.00", "90.00", 1)))
+	tampered.ContentLength = -1
+	assert.Equal(t, http.StatusUnauthorized, send(tampered))
+
+	// and one signed outside the clock skew window
+	stale := newRequest(body)
+	require.NoError(t, auth.SignRequest(stale, "partner", secret, time.Now().Add(-10*time.Minute)))
+	assert.Equal(t, http.StatusUnauthorized, send(stale))
+
+	// only the first request moved money
+	sourceBalance, _ := strconv.ParseFloat(ts.GetAccountBalance(t, 9801), 64)
+	destinationBalance, _ := strconv.ParseFloat(ts.GetAccountBalance(t, 9802), 64)
+	assert.Equal(t, 90.0, sourceBalance)
+	assert.Equal(t, 110.0, destinationBalance)
+}
+
+func TestTenantIsolation(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	acme := ts.CreateTestTenantAPIKey(t, "acme", auth.AllScopes...)
+	globex := ts.CreateTestTenantAPIKey(t, "globex", auth.AllScopes...)
+
+	do := func(method, path, token, body string) (*http.Response, map[string]interface{}) {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.Server.URL+path, strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var fields map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&fields)
+		return resp, fields
+	}
+
+	// account IDs and customer references are unique per tenant only
+	for _, token := range []string{acme, globex} {
+		resp, _ := do("POST", "/accounts", token, `{"account_id": 9901, "initial_balance": "100.00"}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		resp, _ = do("POST", "/customers", token, `{"name": "Shared", "external_reference": "shared-ref", "kyc_status": "verified"}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	resp, _ := do("POST", "/accounts", acme, `{"account_id": 9902, "initial_balance": "0.00"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// another tenant's accounts look like they do not exist
+	resp, fields := do("GET", "/accounts/9902", globex, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "ACCOUNT_NOT_FOUND", fields["code"])
+
+	resp, fields = do("POST", "/transactions", globex, `{"source_account_id": 9901, "destination_account_id": 9902, "amount": "10.00"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "ACCOUNT_NOT_FOUND", fields["code"])
+
+	resp, fields = do("POST", "/transactions", acme, `{"source_account_id": 9901, "destination_account_id": 9902, "amount": "10.00", "reference": "acme-1"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	transactionID := fields["transaction_id"].(string)
+
+	resp, fields = do("GET", "/transactions/"+transactionID, globex, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "TRANSACTION_NOT_FOUND", fields["code"])
+
+	_, fields = do("GET", "/transactions?reference=acme-1", globex, "")
+	assert.Empty(t, fields["transactions"])
+	_, fields = do("GET", "/transactions?reference=acme-1", acme, "")
+	assert.Len(t, fields["transactions"], 1)
+
+	// the transfer only moved acme's money
+	balance := func(token string) float64 {
+		t.Helper()
+		_, fields := do("GET", "/accounts/9901", token, "")
+		value, err := strconv.ParseFloat(fields["balance"].(string), 64)
+		require.NoError(t, err)
+		return value
+	}
+	assert.Equal(t, 90.0, balance(acme))
+	assert.Equal(t, 100.0, balance(globex))
+
+	// product codes are per tenant too, and an account can only use its own tenant's products
+	for _, token := range []string{acme, globex} {
+		resp, _ := do("POST", "/products", token, `{"product_code": "SAVINGS", "name": "Savings", "annual_interest_rate": "0.02", "day_count_convention": "ACT/365"}`)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+	resp, _ = do("POST", "/products", acme, `{"product_code": "GOLD", "name": "Gold", "annual_interest_rate": "0.05", "day_count_convention": "ACT/365"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, fields = do("GET", "/products/GOLD", globex, "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "PRODUCT_NOT_FOUND", fields["code"])
+
+	resp, _ = do("POST", "/accounts", globex, `{"account_id": 9903, "initial_balance": "0.00", "product_code": "GOLD"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, _ = do("POST", "/accounts", globex, `{"account_id": 9903, "initial_balance": "0.00", "product_code": "SAVINGS"}`)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	// keys without a tenant act in the default tenant, which has neither account
+	resp, _ = do("GET", "/accounts/9901", ts.CreateTestAPIKey(t, auth.ScopeAccountsRead), "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestRateLimiting(t *testing.T) {
+	t.Setenv("RATE_LIMIT_ENABLED", "true")
+	t.Setenv("RATE_LIMIT_STORE", "postgres")
+	t.Setenv("RATE_LIMIT_WRITE_RPS", "0.01")
+	t.Setenv("RATE_LIMIT_WRITE_BURST", "4")
+
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ts.CreateTestAccount(t, 9951, "100.00")
+	ts.CreateTestAccount(t, 9952, "100.00")
+
+	transfer := func() *http.Response {
+		t.Helper()
+		resp, err := http.Post(ts.Server.URL+"/transactions", "application/json",
+			strings.NewReader(`{"source_account_id": 9951, "destination_account_id": 9952, "amount": "1.00"}`))
+		require.NoError(t, err)
+		resp.Body.Close()
+		return resp
+	}
+
+	// the two account creations took half of the write burst
+	resp := transfer()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "4", resp.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "1", resp.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, http.StatusOK, transfer().StatusCode)
+
+	resp = transfer()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, 100, retryAfter, 1)
+
+	// reads have their own bucket
+	balance, _ := strconv.ParseFloat(ts.GetAccountBalance(t, 9951), 64)
+	assert.Equal(t, 98.0, balance)
+
+	// the bucket is in Postgres, so another replica sees it exhausted too
+	cfg, err := config.Load("", nil)
+	require.NoError(t, err)
+	replica := ratelimit.NewLimiter(ratelimit.NewPostgresStore(ts.DB), cfg.RateLimits(), logger.New("ERROR"))
+	assert.False(t, replica.Allow(context.Background(), "ip:127.0.0.1", ratelimit.Write).Allowed)
+	assert.True(t, replica.Allow(context.Background(), "ip:127.0.0.2", ratelimit.Write).Allowed)
+}
+
+func TestRequestIDCorrelation(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "txn-service.log")
+	t.Setenv("LOG_FILE", logFile)
+	t.Setenv("LOG_FORMAT", "json")
+	t.Setenv("LOG_LEVEL", "DEBUG")
+
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ts.CreateTestAccount(t, 9961, "100.00")
+	ts.CreateTestAccount(t, 9962, "0")
+
+	req, err := http.NewRequest(http.MethodPost, ts.Server.URL+"/transactions",
+		strings.NewReader(`{"source_account_id": 9961, "destination_account_id": 9962, "amount": "1.00"}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-ID", "corr-9961")
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "corr-9961", resp.Header.Get("X-Request-ID"))
+
+	var created struct {
+		TransactionID string `json:"transaction_id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	// an invalid ID is replaced, and error bodies carry the one in the header
+	req, err = http.NewRequest(http.MethodGet, ts.Server.URL+"/accounts/9969", nil)
+	require.NoError(t, err)
+	req.Header.Set("X-Request-ID", "not valid")
+	resp, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var problem struct {
+		Instance string `json:"instance"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.NotEqual(t, "not valid", resp.Header.Get("X-Request-ID"))
+	assert.NotEmpty(t, problem.Instance)
+	assert.Equal(t, resp.Header.Get("X-Request-ID"), problem.Instance)
+
+	// gRPC calls take and return the ID in metadata
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "corr-grpc")
+	_, err = txnv1.NewAccountServiceClient(ts.GRPCConn).GetAccount(ctx,
+		&txnv1.GetAccountRequest{Account: &txnv1.GetAccountRequest_AccountId{AccountId: 9961}}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"corr-grpc"}, header.Get("x-request-id"))
+
+	// the repository lines of the transfer carry the request, route and transaction
+	content, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+
+	var transferLine map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(content)), "\n") {
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &decoded), line)
+		if decoded["msg"] == "Transfer completed successfully" && decoded["request_id"] == "corr-9961" {
+			transferLine = decoded
+		}
+	}
+	require.NotNil(t, transferLine, "no transfer log line with the request ID")
+	assert.Equal(t, "/transactions", transferLine["route"])
+	assert.Equal(t, "POST", transferLine["method"])
+	assert.Equal(t, created.TransactionID, transferLine["transaction_id"])
+}
+
+func TestMetrics(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ts.CreateTestAccount(t, 9971, "100.00")
+	ts.CreateTestAccount(t, 9972, "0")
+	ts.CreateTransaction(t, 9971, 9972, "10.00")
+
+	resp, err := http.Post(ts.Server.URL+"/transactions", "application/json",
+		strings.NewReader(`{"source_account_id": 9971, "destination_account_id": 9972, "amount": "500.00"}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+	resp, err = http.Get(ts.Server.URL + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	metrics := string(body)
+
+	assert.Contains(t, metrics, `txn_http_requests_total{method="POST",route="/transactions",status="422"}`)
+	assert.Contains(t, metrics, `txn_transfers_total{outcome="completed"}`)
+	assert.Contains(t, metrics, `txn_transfers_total{outcome="insufficient_funds"}`)
+	assert.Contains(t, metrics, `txn_transfer_amount_bucket{le="10"}`)
+	assert.Contains(t, metrics, `txn_transfer_lock_wait_seconds_count`)
+	assert.Contains(t, metrics, `go_sql_in_use_connections{db_name="txn_service"}`)
+	assert.Contains(t, metrics, `go_sql_wait_duration_seconds_total{db_name="txn_service"}`)
+	assert.Contains(t, metrics, `txn_build_info{`)
+}
+
+func TestHealthProbes(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	readyz := func() (int, handlers.HealthResponse) {
+		resp, err := http.Get(ts.Server.URL + "/readyz")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var health handlers.HealthResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&health))
+		return resp.StatusCode, health
+	}
+
+	resp, err := http.Get(ts.Server.URL + "/livez")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	status, health := readyz()
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, handlers.HealthStatusOK, health.Status)
+	for _, check := range []string{"database", "migrations", "shutdown"} {
+		assert.Equal(t, handlers.HealthStatusOK, health.Checks[check].Status, check)
+	}
+
+	// a database migrated by an older build is not ready for this one
+	migrator, err := database.NewMigrator(ts.DB)
+	require.NoError(t, err)
+	_, err = migrator.Down(context.Background(), 1)
+	require.NoError(t, err)
+	status, health = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, handlers.HealthCheckResult{Status: handlers.HealthStatusUnavailable, Detail: "schema is not at the expected version"}, health.Checks["migrations"])
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	// shutting down fails readiness while liveness holds
+	ts.Health.ShutDown()
+	status, health = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, handlers.HealthStatusUnavailable, health.Checks["shutdown"].Status)
+	assert.Equal(t, handlers.HealthStatusOK, health.Checks["database"].Status)
+
+	resp, err = http.Get(ts.Server.URL + "/livez")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestMigrations(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	ctx := context.Background()
+	migrator, err := database.NewMigrator(ts.DB)
+	require.NoError(t, err)
+	latest := migrator.LatestVersion()
+
+	statuses, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, statuses, int(latest))
+	for _, status := range statuses {
+		assert.NotNil(t, status.AppliedAt, "%d_%s", status.Version, status.Name)
+	}
+
+	// every migration reverts cleanly, down to an empty schema
+	reverted, err := migrator.Down(ctx, int(latest))
+	require.NoError(t, err)
+	assert.Equal(t, int(latest), reverted)
+
+	var tables int
+	require.NoError(t, ts.DB.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.tables
+		WHERE table_schema = 'public' AND table_name <> 'schema_migrations'`).Scan(&tables))
+	assert.Equal(t, 0, tables)
+
+	// replicas starting together apply each migration exactly once
+	var wg sync.WaitGroup
+	applied := make([]int, 4)
+	errs := make([]error, 4)
+	for i := range applied {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			applied[i], errs[i] = migrator.Up(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for i := range applied {
+		require.NoError(t, errs[i])
+		total += applied[i]
+	}
+	assert.Equal(t, int(latest), total)
+	require.NoError(t, migrator.Check(ctx))
+
+	// the schema works again after the round trip
+	ts.CreateTestAccount(t, 9981, "10.00")
+	balance, err := strconv.ParseFloat(ts.GetAccountBalance(t, 9981), 64)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, balance)
+
+	// an applied migration that was edited afterwards stops the service
+	_, err = ts.DB.Exec("UPDATE schema_migrations SET checksum = $1 WHERE version = 1", strings.Repeat("0", 64))
+	require.NoError(t, err)
+	_, err = migrator.Up(ctx)
+	assert.ErrorIs(t, err, database.ErrChecksumMismatch)
+}
+
+func TestAccountFreezeAndTransactionReversal(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	log := logger.New("ERROR")
+	accountRepo := repository.NewAccountRepository(ts.DB, log)
+	customerRepo := repository.NewCustomerRepository(ts.DB, log)
+	accountService := service.NewAccountService(accountRepo, repository.NewProductRepository(ts.DB, log), customerRepo, log)
+	transactionService := service.NewTransactionService(repository.NewTransactionRepository(ts.DB, sql.LevelReadCommitted, log), accountRepo, settings.NewStore(&settings.Settings{}), log)
+	ctx := context.Background()
+
+	ts.CreateTestAccount(t, 9401, "100.00")
+	ts.CreateTestAccount(t, 9402, "100.00")
+
+	account, err := accountService.FreezeAccount(ctx, 9402)
+	require.NoError(t, err)
+	assert.NotNil(t, account.FrozenAt)
+
+	transfer := fmt.Sprintf(`{"source_account_id": %d, "destination_account_id": %d, "amount": "10.00"}`, 9401, 9402)
+	resp, err := http.Post(fmt.Sprintf("%s/transactions", ts.Server.URL), "application/json", strings.NewReader(transfer))
+	require.NoError(t, err)
+	var problem struct {
+		Code string `json:"code"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, "ACCOUNT_FROZEN", problem.Code)
+
+	account, err = accountService.UnfreezeAccount(ctx, 9402)
+	require.NoError(t, err)
+	assert.Nil(t, account.FrozenAt)
+
+	transactionID := ts.CreateTransaction(t, 9401, 9402, "10.00")
+	require.NotEmpty(t, transactionID)
+	original, err := uuid.Parse(transactionID)
+	require.NoError(t, err)
+
+	// a frozen account can still be reversed into and out of
+	_, err = accountService.FreezeAccount(ctx, 9402)
+	require.NoError(t, err)
+
+	reversal, err := transactionService.ReverseTransaction(ctx, original, "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(9402), reversal.SourceAccountID)
+	assert.Equal(t, int64(9401), reversal.DestinationAccountID)
+	assert.Equal(t, models.TransactionStatusCompleted, reversal.Status)
+	require.NotNil(t, reversal.ReversalOf)
+	assert.Equal(t, original, *reversal.ReversalOf)
+
+	for _, accountID := range []int64{9401, 9402} {
+		balance, _ := strconv.ParseFloat(ts.GetAccountBalance(t, accountID), 64)
+		assert.Equal(t, 100.0, balance, "account %d", accountID)
+	}
+
+	reversed, err := transactionService.GetTransaction(ctx, original)
+	require.NoError(t, err)
+	assert.Equal(t, models.TransactionStatusReversed, reversed.Status)
+
+	_, err = transactionService.ReverseTransaction(ctx, original, "")
+	assert.ErrorIs(t, err, service.ErrNotReversible)
+	_, err = transactionService.ReverseTransaction(ctx, reversal.TransactionID, "")
+	require.NoError(t, err, "a reversal is itself a completed transaction")
+}
+
+func TestReconciliation(t *testing.T) {
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	log := logger.New("ERROR")
+	reconciliationService := service.NewReconciliationService(repository.NewTransactionRepository(ts.DB, sql.LevelReadCommitted, log), repository.NewAccountRepository(ts.DB, log), testutil.InterestExpenseAccountID, log)
+	ctx := context.Background()
+
+	ts.CreateTestAccount(t, 9501, "100.00")
+	ts.CreateTestAccount(t, 9502, "100.00")
+	ts.CreateTestAccount(t, testutil.InterestExpenseAccountID, "0")
+	ts.CreateTransaction(t, 9501, 9502, "10.00")
+
+	stale, recent := uuid.New(), uuid.New()
+	_, err := ts.DB.Exec(`
+		INSERT INTO transactions (transaction_id, source_account_id, destination_account_id, amount, status, created_at)
+		VALUES ($1, 9501, 9502, 5, 'pending', NOW() - INTERVAL '2 hours'), ($2, 9501, 9502, 5, 'pending', NOW())`, stale, recent)
+	require.NoError(t, err)
+	_, err = ts.DB.Exec("UPDATE accounts SET balance = -5 WHERE account_id IN (9502, $1)", testutil.InterestExpenseAccountID)
+	require.NoError(t, err)
+
+	report, err := reconciliationService.Reconcile(ctx, time.Hour, false)
+	require.NoError(t, err)
+	require.Len(t, report.StalePendingTransactions, 1)
+	assert.Equal(t, stale, report.StalePendingTransactions[0].TransactionID)
+	require.Len(t, report.NegativeBalanceAccounts, 1, "the interest expense account may go negative")
+	assert.Equal(t, int64(9502), report.NegativeBalanceAccounts[0].AccountID)
+
+	report, err = reconciliationService.Reconcile(ctx, time.Hour, true)
+	require.NoError(t, err)
+	require.Len(t, report.StalePendingTransactions, 1)
+	assert.Equal(t, models.TransactionStatusFailed, report.StalePendingTransactions[0].Status)
+
+	var status string
+	require.NoError(t, ts.DB.QueryRow("SELECT status FROM transactions WHERE transaction_id = $1", recent).Scan(&status))
+	assert.Equal(t, models.TransactionStatusPending, status)
+
+	report, err = reconciliationService.Reconcile(ctx, time.Hour, false)
+	require.NoError(t, err)
+	assert.Empty(t, report.StalePendingTransactions)
+}
+
+func TestConfigReload(t *testing.T) {
+	t.Setenv("AUTH_ENABLED", "true")
+
+	ts := testutil.SetupTestServer(t)
+	defer ts.Cleanup()
+
+	reload := func(token string) (*http.Response, map[string]interface{}) {
+		t.Helper()
+		req, err := http.NewRequest("POST", ts.Server.URL+"/admin/config/reload", nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var fields map[string]interface{}
+		json.NewDecoder(resp.Body).Decode(&fields)
+		return resp, fields
+	}
+
+	operator := ts.CreateTestAPIKey(t, auth.ScopeConfigWrite)
+
+	// only operators may reload: the scope alone is not enough for another tenant
+	resp, _ := reload(ts.CreateTestAPIKey(t, auth.ScopeAccountsRead))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	resp, _ = reload(ts.CreateTestTenantAPIKey(t, "acme", auth.ScopeConfigWrite))
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	t.Setenv("REQUIRE_VERIFIED_KYC", "true")
+	t.Setenv("GRPC_ADDRESS", ":50099")
+	resp, fields := reload(operator)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []interface{}{map[string]interface{}{"key": "require_verified_kyc", "old": "false", "new": "true"}}, fields["applied"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"key": "grpc_address", "old": ":9090", "new": ":50099"}}, fields["restart_required"])
+
+	// an invalid configuration is rejected as a whole
+	t.Setenv("LOG_LEVEL", "LOUD")
+	t.Setenv("REQUIRE_VERIFIED_KYC", "false")
+	resp, fields = reload(operator)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, "INVALID_CONFIGURATION", fields["code"])
+	assert.Contains(t, fields["detail"], "log_level")
+
+	t.Setenv("LOG_LEVEL", "ERROR")
+	_, fields = reload(operator)
+	assert.Equal(t, []interface{}{map[string]interface{}{"key": "require_verified_kyc", "old": "true", "new": "false"}}, fields["applied"])
+
+	// the transfer limits apply from the next transfer on
+	t.Setenv("TRANSFER_MAX_AMOUNT", "50")
+	t.Setenv("TRANSFER_DAILY_LIMIT", "80")
+	_, fields = reload(operator)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "transfer_max_amount", "old": "", "new": "50"},
+		map[string]interface{}{"key": "transfer_daily_limit", "old": "", "new": "80"},
+	}, fields["applied"])
+
+	ts.CreateTestAccount(t, 9801, "500.00")
+	ts.CreateTestAccount(t, 9802, "0")
+	client := ts.CreateTestAPIKey(t, auth.AllScopes...)
+	transfer := func(amount string) (int, string) {
+		t.Helper()
+		body := fmt.Sprintf(`{"source_account_id": 9801, "destination_account_id": 9802, "amount": "%s"}`, amount)
+		req, err := http.NewRequest("POST", ts.Server.URL+"/transactions", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+client)
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var problem struct {
+			Code string `json:"code"`
+		}
+		json.NewDecoder(resp.Body).Decode(&problem)
+		return resp.StatusCode, problem.Code
+	}
+
+	status, code := transfer("60.00")
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "TRANSFER_LIMIT_EXCEEDED", code)
+
+	status, _ = transfer("50.00")
+	assert.Equal(t, http.StatusOK, status)
+	status, code = transfer("40.00")
+	assert.Equal(t, http.StatusUnprocessableEntity, status, "50 + 40 is above the daily limit")
+	assert.Equal(t, "TRANSFER_LIMIT_EXCEEDED", code)
+	status, _ = transfer("30.00")
+	assert.Equal(t, http.StatusOK, status)
+	balance, _ := strconv.ParseFloat(ts.GetAccountBalance(t, 9801), 64)
+	assert.Equal(t, 420.0, balance)
+}
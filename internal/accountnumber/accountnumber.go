@@ -0,0 +1,85 @@
+// Package accountnumber converts numeric account IDs to and from the
+// human-facing account number. The format follows the IBAN check digit
+// scheme (ISO 7064 mod 97-10): a country-style prefix, two check digits and
+// the zero padded account ID, e.g. TX06000000000123.
+package accountnumber
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Prefix identifies account numbers issued by this service
+	Prefix = "TX"
+
+	idDigits = 12
+)
+
+// Format returns the account number for a positive account ID
+func Format(accountID int64) string {
+	id := fmt.Sprintf("%0*d", idDigits, accountID)
+	return fmt.Sprintf("%s%02d%s", Prefix, checkDigits(id), id)
+}
+
+// Parse validates an account number and returns the account ID it encodes.
+// Spaces are ignored and letters are case-insensitive so grouped or lower
+// case input typed by people is accepted.
+func Parse(accountNumber string) (int64, error) {
+	normalized := Normalize(accountNumber)
+
+	if len(normalized) < len(Prefix)+2+idDigits || !strings.HasPrefix(normalized, Prefix) {
+		return 0, fmt.Errorf("invalid account number format: %s", accountNumber)
+	}
+
+	check := normalized[len(Prefix) : len(Prefix)+2]
+	id := normalized[len(Prefix)+2:]
+
+	for _, c := range check + id {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid account number format: %s", accountNumber)
+		}
+	}
+
+	if mod97(id+Prefix+check) != 1 {
+		return 0, fmt.Errorf("invalid account number check digits: %s", accountNumber)
+	}
+
+	accountID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || accountID <= 0 {
+		return 0, fmt.Errorf("invalid account number: %s", accountNumber)
+	}
+
+	return accountID, nil
+}
+
+// IsAccountNumber reports whether s looks like an account number rather than
+// a plain numeric account ID. It does not validate the check digits.
+func IsAccountNumber(s string) bool {
+	return strings.HasPrefix(Normalize(s), Prefix)
+}
+
+func Normalize(accountNumber string) string {
+	return strings.ToUpper(strings.ReplaceAll(accountNumber, " ", ""))
+}
+
+func checkDigits(id string) int64 {
+	return 98 - mod97(id+Prefix+"00")
+}
+
+// mod97 computes the ISO 7064 remainder, with letters expanded to A=10 ... Z=35
+func mod97(s string) int64 {
+	var digits strings.Builder
+	for _, c := range s {
+		if c >= 'A' && c <= 'Z' {
+			digits.WriteString(strconv.Itoa(int(c-'A') + 10))
+			continue
+		}
+		digits.WriteRune(c)
+	}
+
+	n, _ := new(big.Int).SetString(digits.String(), 10)
+	return new(big.Int).Mod(n, big.NewInt(97)).Int64()
+}
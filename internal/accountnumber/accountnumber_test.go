@@ -0,0 +1,38 @@
+package accountnumber
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatAndParseRoundTrip(t *testing.T) {
+	for _, accountID := range []int64{1, 123, 999999999999, 9223372036854775807} {
+		accountNumber := Format(accountID)
+		assert.True(t, IsAccountNumber(accountNumber))
+
+		parsed, err := Parse(accountNumber)
+		require.NoError(t, err, accountNumber)
+		assert.Equal(t, accountID, parsed)
+	}
+}
+
+func TestParseAcceptsHumanInput(t *testing.T) {
+	accountNumber := Format(123)
+
+	parsed, err := Parse("tx" + accountNumber[2:4] + " 0000 0000 0123")
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), parsed)
+}
+
+func TestParseRejectsInvalidNumbers(t *testing.T) {
+	accountNumber := Format(123)
+	// swapping two adjacent digits must be caught by the check digits
+	swapped := accountNumber[:len(accountNumber)-2] + "32"
+
+	for _, input := range []string{"", "123", "XX00000000000123", swapped, Format(0), accountNumber[:10]} {
+		_, err := Parse(input)
+		assert.Error(t, err, input)
+	}
+}
@@ -0,0 +1,170 @@
+// Package app wires the components of the service together. The server and
+// the integration tests build the service through New, so they run the same
+// wiring and apply a configuration reload the same way.
+package app
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/config"
+	"txn-service/internal/grpcapi"
+	"txn-service/internal/handlers"
+	"txn-service/internal/jobs"
+	"txn-service/internal/logger"
+	"txn-service/internal/metrics"
+	"txn-service/internal/ratelimit"
+	"txn-service/internal/repository"
+	"txn-service/internal/service"
+	"txn-service/internal/settings"
+
+	"google.golang.org/grpc"
+)
+
+// App is the wired service. Nothing runs until the caller serves Router and
+// GRPCServer and starts Jobs.
+type App struct {
+	Router     http.Handler
+	GRPCServer *grpc.Server
+	Jobs       *jobs.Runner
+	Health     *handlers.HealthHandler
+	Reloader   *config.Reloader
+
+	AccountService     service.AccountService
+	TransactionService service.TransactionService
+	InterestService    service.InterestService
+	APIKeyService      service.APIKeyService
+}
+
+// New builds the service from cfg on db. load reads the configuration again
+// from the sources cfg came from when the configuration is reloaded.
+func New(cfg *config.Config, load func() (*config.Config, error), db *sql.DB, log *logger.Logger) (*App, error) {
+	metrics.RegisterDB(db)
+
+	// The settings tagged reload in config.Config are read from this store by
+	// the running components; a reload swaps in a new snapshot of all of them
+	runtime := settings.NewStore(cfg.Settings())
+	log.FollowLevel(func() logger.Level { return runtime.Current().LogLevel })
+
+	accountRepo := repository.NewAccountRepository(db, log)
+	transactionRepo := repository.NewTransactionRepository(db, cfg.TransferIsolationLevel(), log)
+	productRepo := repository.NewProductRepository(db, log)
+	interestRepo := repository.NewInterestRepository(db, log)
+	customerRepo := repository.NewCustomerRepository(db, log)
+	apiKeyRepo := repository.NewAPIKeyRepository(db, log)
+
+	accountService := service.NewAccountService(accountRepo, productRepo, customerRepo, log)
+	transactionService := service.NewTransactionService(transactionRepo, accountRepo, runtime, log)
+	productService := service.NewProductService(productRepo, log)
+	customerService := service.NewCustomerService(customerRepo, accountRepo, log)
+	interestService := service.NewInterestService(interestRepo, cfg.InterestExpenseAccountID, log)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, log)
+
+	accountHandler := handlers.NewAccountHandler(accountService, runtime, log)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, runtime, log)
+	productHandler := handlers.NewProductHandler(productService, runtime, log)
+	customerHandler := handlers.NewCustomerHandler(customerService, runtime, log)
+
+	var authMiddleware *handlers.AuthMiddleware
+	var authenticator auth.Authenticator
+	if cfg.AuthEnabled {
+		// JWTs are only accepted when a key set is configured
+		var jwtAuthenticator auth.Authenticator
+		if cfg.JWKSURL != "" {
+			keySet, err := auth.NewKeySet(context.Background(), cfg.JWKSURL, cfg.JWKSRefreshInterval, log)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load JWKS: %w", err)
+			}
+			jwtAuthenticator = auth.NewJWTAuthenticator(keySet, cfg.JWTConfig())
+			log.Info("JWT authentication enabled - jwks: %s", cfg.JWKSURL)
+		}
+
+		// signed requests are only accepted when signing clients are configured
+		var signatures *auth.SignatureVerifier
+		if cfg.SigningClientsFile != "" {
+			clients, err := auth.LoadSigningClients(cfg.SigningClientsFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load signing clients: %w", err)
+			}
+			signatures = auth.NewSignatureVerifier(clients, cfg.SignatureMaxSkew)
+			log.Info("HMAC request signing enabled - clients: %d", len(clients))
+		}
+
+		authenticator = auth.NewTokenAuthenticator(apiKeyService, jwtAuthenticator)
+		authMiddleware = handlers.NewAuthMiddleware(authenticator, signatures, runtime, log)
+	} else {
+		log.Warn("Authentication disabled by AUTH_ENABLED=false, API requests are not authenticated")
+	}
+
+	// Background jobs run alongside the HTTP server and are stopped before it shuts down
+	jobRunner := jobs.NewRunner(log)
+
+	var rateLimitMiddleware *handlers.RateLimitMiddleware
+	var limiter *ratelimit.Limiter
+	if cfg.RateLimitEnabled {
+		limits := cfg.RateLimits()
+		for _, limit := range []ratelimit.Limit{limits.Read, limits.Write, limits.IP} {
+			if err := limit.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid rate limit configuration: %w", err)
+			}
+		}
+
+		store, err := ratelimit.NewStore(cfg.RateLimitStore, db)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limit configuration: %w", err)
+		}
+		jobRunner.Register("rate-limit-cleanup", time.Minute, store.Cleanup)
+
+		limiter = ratelimit.NewLimiter(store, limits, log)
+		limiter.FollowLimits(func() ratelimit.Limits {
+			return runtime.Current().RateLimits
+		})
+		rateLimitMiddleware = handlers.NewRateLimitMiddleware(limiter)
+		log.Info("Rate limiting enabled - store: %s, read: %g/s burst %d, write: %g/s burst %d, ip: %g/s burst %d",
+			cfg.RateLimitStore, limits.Read.Rate, limits.Read.Burst, limits.Write.Rate, limits.Write.Burst, limits.IP.Rate, limits.IP.Burst)
+	}
+
+	var accessLogMiddleware *handlers.AccessLogMiddleware
+	if cfg.AccessLogEnabled {
+		accessLogMiddleware = handlers.NewAccessLogMiddleware(log, runtime)
+	}
+
+	healthHandler, err := handlers.NewHealthHandler(db, cfg.ReadinessCheckTimeout, log)
+	if err != nil {
+		return nil, err
+	}
+
+	// SIGHUP and POST /admin/config/reload put the reloaded settings in force
+	// all at once
+	reloader := config.NewReloader(cfg, load, log)
+	reloader.OnReload(func(cfg *config.Config) {
+		runtime.Replace(cfg.Settings())
+	})
+	adminHandler := handlers.NewAdminHandler(reloader, runtime, log)
+
+	router := handlers.SetupRoutes(accountHandler, transactionHandler, productHandler, customerHandler, healthHandler, adminHandler, authMiddleware, rateLimitMiddleware, accessLogMiddleware, log)
+
+	if cfg.InterestExpenseAccountID > 0 {
+		jobRunner.Register("interest", cfg.InterestJobInterval, func(ctx context.Context) error {
+			return interestService.Run(ctx, time.Now())
+		})
+	} else {
+		log.Warn("INTEREST_EXPENSE_ACCOUNT_ID not set, interest job disabled")
+	}
+
+	return &App{
+		Router:             router,
+		GRPCServer:         grpcapi.NewServer(accountService, transactionService, authenticator, limiter, cfg.GRPCRequestTimeout, log),
+		Jobs:               jobRunner,
+		Health:             healthHandler,
+		Reloader:           reloader,
+		AccountService:     accountService,
+		TransactionService: transactionService,
+		InterestService:    interestService,
+		APIKeyService:      apiKeyService,
+	}, nil
+}
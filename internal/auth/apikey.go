@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// API keys have the form txn_<key id>_<secret>. The key ID is public and is the
+// lookup key in the database; only a SHA-256 hash of the secret is stored. The
+// secret carries 256 bits of entropy, so a plain hash is enough.
+const (
+	apiKeyPrefix    = "txn"
+	apiKeyIDBytes   = 8
+	apiKeySecretLen = 32
+)
+
+// GenerateAPIKey returns a new key ID and the full token to hand to the client
+func GenerateAPIKey() (keyID, token string, err error) {
+	id := make([]byte, apiKeyIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", fmt.Errorf("failed to generate key ID: %w", err)
+	}
+
+	secret := make([]byte, apiKeySecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate key secret: %w", err)
+	}
+
+	keyID = hex.EncodeToString(id)
+	token = fmt.Sprintf("%s_%s_%s", apiKeyPrefix, keyID, base64.RawURLEncoding.EncodeToString(secret))
+	return keyID, token, nil
+}
+
+// ParseAPIKeyID returns the key ID of a token without checking its secret
+func ParseAPIKeyID(token string) (string, error) {
+	parts := strings.SplitN(token, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || len(parts[1]) != 2*apiKeyIDBytes || parts[2] == "" {
+		return "", fmt.Errorf("%w: malformed API key", ErrUnauthenticated)
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", fmt.Errorf("%w: malformed API key", ErrUnauthenticated)
+	}
+	return parts[1], nil
+}
+
+// IsAPIKey reports whether token looks like an API key rather than another kind
+// of bearer token
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, apiKeyPrefix+"_")
+}
+
+// HashAPIKey returns the hex encoded SHA-256 hash stored for a token
+func HashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// MatchAPIKey compares a token with a stored hash in constant time
+func MatchAPIKey(token, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashAPIKey(token)), []byte(hash)) == 1
+}
@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	keyID, token, err := GenerateAPIKey()
+	require.NoError(t, err)
+
+	assert.True(t, IsAPIKey(token))
+
+	parsed, err := ParseAPIKeyID(token)
+	require.NoError(t, err)
+	assert.Equal(t, keyID, parsed)
+
+	hash := HashAPIKey(token)
+	assert.Len(t, hash, 64)
+	assert.True(t, MatchAPIKey(token, hash))
+	assert.False(t, MatchAPIKey(token+"x", hash))
+
+	_, other, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestParseAPIKeyIDRejectsMalformedTokens(t *testing.T) {
+	for _, token := range []string{
+		"",
+		"txn_",
+		"txn_0123456789abcdef",
+		"txn_0123456789abcdef_",
+		"txn_0123456789abcdeg_secret",
+		"txn_0123_secret",
+		"key_0123456789abcdef_secret",
+	} {
+		_, err := ParseAPIKeyID(token)
+		assert.True(t, errors.Is(err, ErrUnauthenticated), "token %q", token)
+	}
+}
+
+func TestPrincipalHasScope(t *testing.T) {
+	principal := &Principal{Scopes: []string{ScopeAccountsRead}}
+
+	assert.True(t, principal.HasScope(ScopeAccountsRead))
+	assert.False(t, principal.HasScope(ScopeAccountsWrite))
+}
@@ -0,0 +1,205 @@
+// Package auth holds the authenticated caller of a request, the scopes that
+// gate each API operation and the account ownership rules for callers acting
+// on behalf of a single customer.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/tenant"
+)
+
+// Scopes granted to API keys. Each route requires exactly one of them.
+const (
+	ScopeAccountsRead      = "accounts:read"
+	ScopeAccountsWrite     = "accounts:write"
+	ScopeTransactionsRead  = "transactions:read"
+	ScopeTransactionsWrite = "transactions:write"
+	ScopeProductsRead      = "products:read"
+	ScopeProductsWrite     = "products:write"
+	ScopeCustomersRead     = "customers:read"
+	ScopeCustomersWrite    = "customers:write"
+	ScopeConfigWrite       = "config:write"
+)
+
+// AllScopes lists every scope a key can be granted
+var AllScopes = []string{
+	ScopeAccountsRead,
+	ScopeAccountsWrite,
+	ScopeTransactionsRead,
+	ScopeTransactionsWrite,
+	ScopeProductsRead,
+	ScopeProductsWrite,
+	ScopeCustomersRead,
+	ScopeCustomersWrite,
+	ScopeConfigWrite,
+}
+
+var (
+	// ErrUnauthenticated means the credentials are missing, malformed, unknown or revoked
+	ErrUnauthenticated = errors.New("invalid or missing credentials")
+	// ErrForbidden means the caller is authenticated but lacks the scope, role or
+	// ownership the operation requires
+	ErrForbidden = errors.New("access denied")
+)
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// APIKeyID identifies the API key the request was made with
+	APIKeyID string
+	// SigningClientID identifies the client that signed the request
+	SigningClientID string
+	// TenantID is the tenant the principal acts in
+	TenantID string
+	// Subject is the sub claim of the JWT the request was made with
+	Subject string
+	// CustomerID is the customer a JWT principal acts for, 0 when it names none
+	CustomerID int64
+	Roles      []string
+	Scopes     []string
+	// Restricted principals may only access CustomerID and the accounts it
+	// owns. JWT principals without the admin role are restricted; API keys are
+	// service credentials and are not.
+	Restricted bool
+}
+
+// ID names the principal in logs: the API key, signing client or JWT subject
+func (p *Principal) ID() string {
+	switch {
+	case p.APIKeyID != "":
+		return "key:" + p.APIKeyID
+	case p.SigningClientID != "":
+		return "hmac:" + p.SigningClientID
+	default:
+		return "jwt:" + p.Subject
+	}
+}
+
+// HasScope reports whether the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, granted := range p.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// HasRole reports whether the principal was granted role
+func (p *Principal) HasRole(role string) bool {
+	for _, granted := range p.Roles {
+		if granted == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessCustomer reports whether the principal may access the customer and
+// the resources it owns. A nil customerID stands for an account without owner,
+// which only unrestricted principals may access.
+func (p *Principal) CanAccessCustomer(customerID *int64) bool {
+	if !p.Restricted {
+		return true
+	}
+	return customerID != nil && p.CustomerID != 0 && *customerID == p.CustomerID
+}
+
+// CheckCustomerAccess returns an error matching ErrForbidden when the caller
+// in ctx may not access resources owned by customerID. Unauthenticated calls
+// (authentication disabled, background jobs) are allowed.
+func CheckCustomerAccess(ctx context.Context, customerID *int64) error {
+	if principal := FromContext(ctx); principal != nil && !principal.CanAccessCustomer(customerID) {
+		return fmt.Errorf("%w: not owned by the caller", ErrForbidden)
+	}
+	return nil
+}
+
+// CheckUnrestricted returns an error matching ErrForbidden when the caller in
+// ctx is restricted to a single customer, for operations reserved to admins
+// and service credentials
+func CheckUnrestricted(ctx context.Context) error {
+	if principal := FromContext(ctx); principal != nil && principal.Restricted {
+		return fmt.Errorf("%w: requires an admin role", ErrForbidden)
+	}
+	return nil
+}
+
+// CheckOperator returns an error matching ErrForbidden unless the caller in
+// ctx is an authenticated, unrestricted caller of the default tenant, for
+// operations on the whole service rather than on one tenant's data
+func CheckOperator(ctx context.Context) error {
+	principal := FromContext(ctx)
+	switch {
+	case principal == nil:
+		return fmt.Errorf("%w: requires authentication", ErrForbidden)
+	case principal.Restricted:
+		return fmt.Errorf("%w: requires an admin role", ErrForbidden)
+	case principal.TenantID != tenant.Default:
+		return fmt.Errorf("%w: requires a credential of the %s tenant", ErrForbidden, tenant.Default)
+	}
+	return nil
+}
+
+// Authenticator resolves a bearer token to the principal it belongs to. It
+// returns an error matching ErrUnauthenticated for credentials it rejects.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (*Principal, error)
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal
+// and acting in its tenant; lines logged with it name both
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	ctx = context.WithValue(ctx, principalKey{}, principal)
+	ctx = logger.ContextWithFields(ctx, map[string]interface{}{
+		"principal": principal.ID(),
+		"tenant_id": principal.TenantID,
+	})
+	return tenant.WithID(ctx, principal.TenantID)
+}
+
+// FromContext returns the authenticated principal, or nil when the request was
+// not authenticated (authentication disabled, or a background job)
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
+
+// IsValidScope reports whether scope is one of AllScopes
+func IsValidScope(scope string) bool {
+	for _, known := range AllScopes {
+		if known == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// NewTokenAuthenticator dispatches API keys to apiKeys and any other bearer
+// token to jwt. Either may be nil to reject that kind of token.
+func NewTokenAuthenticator(apiKeys, jwt Authenticator) Authenticator {
+	return &tokenAuthenticator{apiKeys: apiKeys, jwt: jwt}
+}
+
+type tokenAuthenticator struct {
+	apiKeys Authenticator
+	jwt     Authenticator
+}
+
+func (a *tokenAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	authenticator := a.jwt
+	if IsAPIKey(token) {
+		authenticator = a.apiKeys
+	}
+
+	if authenticator == nil {
+		return nil, fmt.Errorf("%w: unsupported token type", ErrUnauthenticated)
+	}
+
+	return authenticator.Authenticate(ctx, token)
+}
@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"txn-service/internal/logger"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// minKeySetRefresh bounds how often an unknown key ID can trigger a reload, so
+// tokens with made up key IDs cannot hammer the JWKS endpoint
+const minKeySetRefresh = time.Minute
+
+// KeySet is a JSON Web Key Set loaded from a file or an http(s) URL. Keys are
+// cached and reloaded every refreshInterval, and also when a token names a key
+// ID the cache does not know, so signing keys can be rotated without a
+// restart. A failed reload keeps the previous keys. Concurrent reloads share
+// one fetch, and the lock is only held to read or swap the cache, so lookups
+// of cached keys never wait for the endpoint.
+type KeySet struct {
+	source          string
+	refreshInterval time.Duration
+	minRefresh      time.Duration
+	client          *http.Client
+	logger          *logger.Logger
+	reloads         singleflight.Group
+
+	mu        sync.Mutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+}
+
+// NewKeySet loads the key set from source, a file path, file:// URL or
+// http(s) URL, and fails when it cannot be loaded
+func NewKeySet(ctx context.Context, source string, refreshInterval time.Duration, log *logger.Logger) (*KeySet, error) {
+	keySet := &KeySet{
+		source:          source,
+		refreshInterval: refreshInterval,
+		minRefresh:      minKeySetRefresh,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		logger:          log,
+	}
+
+	if err := keySet.refresh(ctx); err != nil {
+		return nil, err
+	}
+
+	return keySet, nil
+}
+
+// Key returns the public key with the given key ID. An empty kid is accepted
+// when the set holds a single key.
+func (k *KeySet) Key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	k.mu.Lock()
+	key, ok := k.lookup(kid)
+	fetchedAt := k.fetchedAt
+	k.mu.Unlock()
+
+	sinceFetch := time.Since(fetchedAt)
+	if sinceFetch >= k.refreshInterval || (!ok && sinceFetch >= k.minRefresh) {
+		if err := k.reload(ctx, fetchedAt); err != nil {
+			return nil, err
+		}
+
+		k.mu.Lock()
+		key, ok = k.lookup(kid)
+		k.mu.Unlock()
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrUnauthenticated, kid)
+	}
+
+	return key, nil
+}
+
+func (k *KeySet) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(k.keys) == 1 {
+		for _, key := range k.keys {
+			return key, true
+		}
+	}
+	key, ok := k.keys[kid]
+	return key, ok
+}
+
+func (k *KeySet) refresh(ctx context.Context) error {
+	keys, err := k.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	return nil
+}
+
+// reload reloads the keys unless that happened since the caller saw the cache
+// fetched at fetchedAt, and waits for it as long as ctx allows. Callers that
+// arrive during a reload join it instead of fetching again. A failed reload
+// keeps the cached keys; the fetch time is updated either way so a broken
+// endpoint is retried at the refresh interval rather than on every request.
+func (k *KeySet) reload(ctx context.Context, fetchedAt time.Time) error {
+	// the fetch outlives a caller that gives up, since others may be waiting
+	fetchCtx := context.WithoutCancel(ctx)
+	done := k.reloads.DoChan("", func() (interface{}, error) {
+		k.mu.Lock()
+		reloaded := k.fetchedAt.After(fetchedAt)
+		k.mu.Unlock()
+		if reloaded {
+			return nil, nil
+		}
+
+		keys, err := k.load(fetchCtx)
+
+		k.mu.Lock()
+		defer k.mu.Unlock()
+
+		k.fetchedAt = time.Now()
+		if err != nil {
+			k.logger.FromContext(fetchCtx).Warn("Failed to reload JWKS, keeping %d cached keys - source: %s, error: %v", len(k.keys), k.source, err)
+			return nil, nil
+		}
+		k.keys = keys
+		return nil, nil
+	})
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to reload JWKS: %w", ctx.Err())
+	}
+}
+
+func (k *KeySet) load(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	data, err := k.fetch(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load JWKS from %s: %w", k.source, err)
+	}
+
+	keys, err := k.parseJWKS(ctx, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS from %s: %w", k.source, err)
+	}
+
+	return keys, nil
+}
+
+func (k *KeySet) fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(k.source, "http://") && !strings.HasPrefix(k.source, "https://") {
+		return os.ReadFile(strings.TrimPrefix(k.source, "file://"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS returns the RSA and P-256 signing keys of a key set by key ID.
+// Encryption keys and other key types are skipped, and so are malformed keys,
+// which are logged, so one bad key does not take the others down with it.
+func (k *KeySet) parseJWKS(ctx context.Context, data []byte) (map[string]crypto.PublicKey, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		var key crypto.PublicKey
+		var err error
+		switch jwk.Kty {
+		case "RSA":
+			key, err = jwk.rsaPublicKey()
+		case "EC":
+			key, err = jwk.ecdsaPublicKey()
+		default:
+			continue
+		}
+		if err != nil {
+			k.logger.FromContext(ctx).Warn("Skipping unusable JWKS key - source: %s, kid: %s, error: %v", k.source, jwk.Kid, err)
+			continue
+		}
+
+		keys[jwk.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no usable RSA or EC signing keys")
+	}
+
+	return keys, nil
+}
+
+func (jwk *jsonWebKey) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := decodeBigInt(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+
+	e, err := decodeBigInt(jwk.E)
+	if err != nil || !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, fmt.Errorf("invalid exponent")
+	}
+
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (jwk *jsonWebKey) ecdsaPublicKey() (*ecdsa.PublicKey, error) {
+	if jwk.Crv != "P-256" {
+		return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+	if err != nil || len(x) != 32 {
+		return nil, fmt.Errorf("invalid x coordinate")
+	}
+
+	y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+	if err != nil || len(y) != 32 {
+		return nil, fmt.Errorf("invalid y coordinate")
+	}
+
+	// ecdh rejects points that are not on the curve
+	point := append(append([]byte{4}, x...), y...)
+	if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty value")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"txn-service/internal/tenant"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig describes the tokens issued by the gateway
+type JWTConfig struct {
+	// Issuer and Audience must match the iss and aud claims when set
+	Issuer   string
+	Audience string
+	// RolesClaim names the claim holding the roles, a dotted path such as
+	// realm_access.roles reaches into nested objects
+	RolesClaim string
+	// CustomerClaim names the claim holding the customer the caller acts for
+	CustomerClaim string
+	// TenantClaim names the claim holding the tenant; tokens without it act in
+	// the default tenant
+	TenantClaim string
+	// AdminRole lifts the restriction to the caller's own customer
+	AdminRole string
+}
+
+// JWTAuthenticator validates RS256 and ES256 signed JWTs against a key set.
+// The scope (space separated) or scp (array) claim grants scopes, and callers
+// without the admin role are restricted to the customer in CustomerClaim.
+type JWTAuthenticator struct {
+	keys   *KeySet
+	config JWTConfig
+	parser *jwt.Parser
+}
+
+func NewJWTAuthenticator(keys *KeySet, config JWTConfig) *JWTAuthenticator {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(30 * time.Second),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+
+	return &JWTAuthenticator{
+		keys:   keys,
+		config: config,
+		parser: jwt.NewParser(options...),
+	}
+}
+
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return a.keys.Key(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrUnauthenticated)
+	}
+
+	principal := &Principal{
+		Subject: subject,
+		Roles:   stringsClaim(claimPath(claims, a.config.RolesClaim)),
+		Scopes:  scopesClaim(claims),
+	}
+
+	if customer := claimPath(claims, a.config.CustomerClaim); customer != nil {
+		customerID, ok := int64Claim(customer)
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid %s claim", ErrUnauthenticated, a.config.CustomerClaim)
+		}
+		principal.CustomerID = customerID
+	}
+
+	principal.TenantID = tenant.Default
+	if value := claimPath(claims, a.config.TenantClaim); value != nil {
+		tenantID, ok := value.(string)
+		if !ok || tenant.Validate(tenantID) != nil {
+			return nil, fmt.Errorf("%w: invalid %s claim", ErrUnauthenticated, a.config.TenantClaim)
+		}
+		principal.TenantID = tenantID
+	}
+
+	principal.Restricted = a.config.AdminRole == "" || !principal.HasRole(a.config.AdminRole)
+
+	return principal, nil
+}
+
+// claimPath looks up a claim by dotted path, returning nil when it is missing
+func claimPath(claims jwt.MapClaims, path string) interface{} {
+	if path == "" {
+		return nil
+	}
+
+	var value interface{} = map[string]interface{}(claims)
+	for _, name := range strings.Split(path, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[name]
+	}
+	return value
+}
+
+func scopesClaim(claims jwt.MapClaims) []string {
+	if scope, ok := claims["scope"].(string); ok {
+		return strings.Fields(scope)
+	}
+	return stringsClaim(claims["scp"])
+}
+
+// stringsClaim accepts an array of strings or a single string
+func stringsClaim(value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// int64Claim accepts a JSON number or a numeric string
+func int64Claim(value interface{}) (int64, bool) {
+	switch value := value.(type) {
+	case float64:
+		if value != float64(int64(value)) || value <= 0 {
+			return 0, false
+		}
+		return int64(value), true
+	case string:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		return parsed, err == nil && parsed > 0
+	default:
+		return 0, false
+	}
+}
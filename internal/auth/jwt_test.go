@@ -0,0 +1,355 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"txn-service/internal/logger"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type signingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.Signer
+}
+
+func newRSAKey(t *testing.T, kid string) *signingKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &signingKey{kid: kid, method: jwt.SigningMethodRS256, key: key}
+}
+
+func newECKey(t *testing.T, kid string) *signingKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &signingKey{kid: kid, method: jwt.SigningMethodES256, key: key}
+}
+
+func (k *signingKey) jwk() map[string]string {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+	switch public := k.key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": k.kid, "use": "sig", "n": encode(public.N.Bytes()), "e": encode(big.NewInt(int64(public.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": k.kid, "crv": "P-256", "x": encode(public.X.FillBytes(make([]byte, 32))), "y": encode(public.Y.FillBytes(make([]byte, 32)))}
+	}
+	panic("unsupported key")
+}
+
+func (k *signingKey) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(k.method, claims)
+	token.Header["kid"] = k.kid
+	signed, err := token.SignedString(k.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func jwks(keys ...*signingKey) []byte {
+	set := struct {
+		Keys []map[string]string `json:"keys"`
+	}{}
+	for _, key := range keys {
+		set.Keys = append(set.Keys, key.jwk())
+	}
+	data, _ := json.Marshal(set)
+	return data
+}
+
+// jwksServer serves a key set that tests can swap to simulate rotation. While
+// hold is set, requests wait for it to be closed.
+type jwksServer struct {
+	mu       sync.Mutex
+	body     []byte
+	hold     chan struct{}
+	requests int
+}
+
+func (s *jwksServer) set(body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.body = body
+}
+
+func (s *jwksServer) requestCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+func (s *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests++
+	body, hold := s.body, s.hold
+	s.mu.Unlock()
+
+	if hold != nil {
+		<-hold
+	}
+	if body == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.Write(body)
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":         "https://gateway.example",
+		"aud":         "txn-service",
+		"sub":         "user-1",
+		"exp":         time.Now().Add(time.Hour).Unix(),
+		"scope":       "accounts:read transactions:write",
+		"roles":       []string{"customer"},
+		"customer_id": 42,
+	}
+}
+
+var testJWTConfig = JWTConfig{
+	Issuer:        "https://gateway.example",
+	Audience:      "txn-service",
+	RolesClaim:    "roles",
+	CustomerClaim: "customer_id",
+	TenantClaim:   "tenant_id",
+	AdminRole:     "admin",
+}
+
+func TestJWTAuthenticatorMapsClaims(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa-1")
+	ecKey := newECKey(t, "ec-1")
+
+	server := &jwksServer{body: jwks(rsaKey, ecKey)}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	keySet, err := NewKeySet(context.Background(), httpServer.URL, time.Hour, logger.New("ERROR"))
+	require.NoError(t, err)
+	authenticator := NewJWTAuthenticator(keySet, testJWTConfig)
+
+	for _, key := range []*signingKey{rsaKey, ecKey} {
+		t.Run(key.method.Alg(), func(t *testing.T) {
+			principal, err := authenticator.Authenticate(context.Background(), key.sign(t, validClaims()))
+			require.NoError(t, err)
+
+			assert.Equal(t, "user-1", principal.Subject)
+			assert.Equal(t, int64(42), principal.CustomerID)
+			assert.Equal(t, []string{"customer"}, principal.Roles)
+			assert.Equal(t, []string{ScopeAccountsRead, ScopeTransactionsWrite}, principal.Scopes)
+			assert.True(t, principal.Restricted)
+			assert.Equal(t, "default", principal.TenantID)
+		})
+	}
+
+	// the key set is cached
+	assert.Equal(t, 1, server.requests)
+
+	claims := validClaims()
+	claims["roles"] = []string{"customer", "admin"}
+	claims["tenant_id"] = "acme"
+	principal, err := authenticator.Authenticate(context.Background(), rsaKey.sign(t, claims))
+	require.NoError(t, err)
+	assert.False(t, principal.Restricted)
+	assert.Equal(t, "acme", principal.TenantID)
+}
+
+func TestJWTAuthenticatorRejectsInvalidTokens(t *testing.T) {
+	key := newRSAKey(t, "rsa-1")
+	unknown := newRSAKey(t, "rsa-1")
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwks(key), 0o600))
+
+	keySet, err := NewKeySet(context.Background(), path, time.Hour, logger.New("ERROR"))
+	require.NoError(t, err)
+	authenticator := NewJWTAuthenticator(keySet, testJWTConfig)
+
+	with := func(name string, value interface{}) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	hs256 := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	hs256.Header["kid"] = key.kid
+	hmacToken, err := hs256.SignedString([]byte("secret"))
+	require.NoError(t, err)
+
+	tests := map[string]string{
+		"expired":          key.sign(t, with("exp", time.Now().Add(-time.Hour).Unix())),
+		"no expiry":        key.sign(t, with("exp", nil)),
+		"wrong issuer":     key.sign(t, with("iss", "https://evil.example")),
+		"wrong audience":   key.sign(t, with("aud", "other-service")),
+		"no subject":       key.sign(t, with("sub", nil)),
+		"invalid customer": key.sign(t, with("customer_id", "abc")),
+		"invalid tenant":   key.sign(t, with("tenant_id", "Acme Corp")),
+		"wrong key":        unknown.sign(t, validClaims()),
+		"hmac":             hmacToken,
+		"garbage":          "not.a.jwt",
+	}
+
+	for name, token := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := authenticator.Authenticate(context.Background(), token)
+			assert.True(t, errors.Is(err, ErrUnauthenticated), "got %v", err)
+		})
+	}
+}
+
+func TestKeySetRotation(t *testing.T) {
+	oldKey := newRSAKey(t, "2024-01")
+	newKey := newECKey(t, "2024-02")
+
+	server := &jwksServer{body: jwks(oldKey)}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	keySet, err := NewKeySet(context.Background(), httpServer.URL, time.Hour, logger.New("ERROR"))
+	require.NoError(t, err)
+	keySet.minRefresh = 0
+	authenticator := NewJWTAuthenticator(keySet, testJWTConfig)
+
+	_, err = authenticator.Authenticate(context.Background(), oldKey.sign(t, validClaims()))
+	require.NoError(t, err)
+
+	// an unknown key ID triggers a reload
+	server.set(jwks(oldKey, newKey))
+	_, err = authenticator.Authenticate(context.Background(), newKey.sign(t, validClaims()))
+	require.NoError(t, err)
+
+	// a failed reload keeps the cached keys
+	server.set(nil)
+	_, err = authenticator.Authenticate(context.Background(), newKey.sign(t, validClaims()))
+	require.NoError(t, err)
+
+	// once the old key is retired and the cache expires it is rejected
+	server.set(jwks(newKey))
+	keySet.refreshInterval = 0
+	_, err = authenticator.Authenticate(context.Background(), oldKey.sign(t, validClaims()))
+	assert.True(t, errors.Is(err, ErrUnauthenticated))
+}
+
+func TestKeySetLookupsDoNotWaitForReload(t *testing.T) {
+	key := newRSAKey(t, "2024-01")
+
+	server := &jwksServer{body: jwks(key)}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	keySet, err := NewKeySet(context.Background(), httpServer.URL, time.Hour, logger.New("ERROR"))
+	require.NoError(t, err)
+	keySet.minRefresh = 0
+
+	// an unknown key ID starts a reload that hangs
+	hold := make(chan struct{})
+	server.mu.Lock()
+	server.hold = hold
+	server.mu.Unlock()
+
+	reloaded := make(chan error, 1)
+	go func() {
+		_, err := keySet.Key(context.Background(), "2024-02")
+		reloaded <- err
+	}()
+	require.Eventually(t, func() bool { return server.requestCount() == 2 }, time.Second, time.Millisecond)
+
+	// cached keys are served meanwhile
+	cached, err := keySet.Key(context.Background(), key.kid)
+	require.NoError(t, err)
+	assert.NotNil(t, cached)
+
+	// callers waiting for the reload give up with their request
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = keySet.Key(ctx, "2024-03")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(hold)
+	assert.ErrorIs(t, <-reloaded, ErrUnauthenticated)
+	assert.Equal(t, 2, server.requestCount(), "the waiting callers shared one fetch")
+}
+
+func TestKeySetSkipsUnusableKeys(t *testing.T) {
+	key := newRSAKey(t, "good")
+	set := map[string][]map[string]string{"keys": {
+		{"kty": "EC", "kid": "p384", "use": "sig", "crv": "P-384", "x": "AA", "y": "AA"},
+		{"kty": "RSA", "kid": "no-modulus", "use": "sig", "e": "AQAB"},
+		key.jwk(),
+	}}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	var out bytes.Buffer
+	log := logger.New("WARN")
+	log.SetOutput(&out)
+
+	keySet, err := NewKeySet(context.Background(), path, time.Hour, log)
+	require.NoError(t, err)
+
+	_, err = keySet.Key(context.Background(), "good")
+	assert.NoError(t, err)
+	_, err = keySet.Key(context.Background(), "p384")
+	assert.ErrorIs(t, err, ErrUnauthenticated)
+	assert.Contains(t, out.String(), "kid: p384")
+	assert.Contains(t, out.String(), "kid: no-modulus")
+}
+
+func TestNewKeySetFailsWithoutKeys(t *testing.T) {
+	_, err := NewKeySet(context.Background(), filepath.Join(t.TempDir(), "missing.json"), time.Hour, logger.New("ERROR"))
+	assert.Error(t, err)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys": [{"kty": "oct", "k": "c2VjcmV0"}]}`), 0o600))
+	_, err = NewKeySet(context.Background(), path, time.Hour, logger.New("ERROR"))
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys": [{"kty": "RSA", "kid": "no-modulus", "e": "AQAB"}]}`), 0o600))
+	_, err = NewKeySet(context.Background(), path, time.Hour, logger.New("ERROR"))
+	assert.Error(t, err)
+}
+
+func TestCheckCustomerAccess(t *testing.T) {
+	owner := int64(42)
+	other := int64(7)
+
+	restricted := WithPrincipal(context.Background(), &Principal{CustomerID: 42, Restricted: true})
+	assert.NoError(t, CheckCustomerAccess(restricted, &owner))
+	assert.True(t, errors.Is(CheckCustomerAccess(restricted, &other), ErrForbidden))
+	assert.True(t, errors.Is(CheckCustomerAccess(restricted, nil), ErrForbidden))
+	assert.True(t, errors.Is(CheckUnrestricted(restricted), ErrForbidden))
+
+	admin := WithPrincipal(context.Background(), &Principal{Roles: []string{"admin"}})
+	assert.NoError(t, CheckCustomerAccess(admin, &other))
+	assert.NoError(t, CheckCustomerAccess(admin, nil))
+	assert.NoError(t, CheckUnrestricted(admin))
+
+	assert.NoError(t, CheckCustomerAccess(context.Background(), &other))
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"txn-service/internal/tenant"
+)
+
+// SignatureScheme is the Authorization scheme of HMAC signed requests:
+//
+//	Authorization: TXN-HMAC-SHA256 client_id=<id>,timestamp=<unix seconds>,nonce=<nonce>,signature=<hex>
+//
+// The signature is the hex encoded HMAC-SHA256, keyed with the client secret,
+// of the canonical string built by CanonicalString.
+const SignatureScheme = "TXN-HMAC-SHA256"
+
+// maxNonceLen bounds the memory a single cached nonce can take
+const maxNonceLen = 128
+
+// SigningClient is a partner that authenticates by signing its requests with a
+// shared secret
+type SigningClient struct {
+	ID     string   `json:"id"`
+	Secret string   `json:"secret"`
+	Scopes []string `json:"scopes"`
+	// Tenant is the tenant the client acts in, the default tenant when empty
+	Tenant string `json:"tenant"`
+}
+
+// LoadSigningClients reads the signing clients from a JSON file of the form
+// {"clients": [{"id": "...", "secret": "...", "scopes": ["..."], "tenant": "..."}]}
+func LoadSigningClients(path string) ([]*SigningClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signing clients: %w", err)
+	}
+
+	var file struct {
+		Clients []*SigningClient `json:"clients"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse signing clients: %w", err)
+	}
+
+	for _, client := range file.Clients {
+		if client.ID == "" || len(client.Secret) < 32 {
+			return nil, fmt.Errorf("signing client %q needs an id and a secret of at least 32 characters", client.ID)
+		}
+		for _, scope := range client.Scopes {
+			if !IsValidScope(scope) {
+				return nil, fmt.Errorf("signing client %q has unknown scope %q", client.ID, scope)
+			}
+		}
+		if client.Tenant == "" {
+			client.Tenant = tenant.Default
+		}
+		if err := tenant.Validate(client.Tenant); err != nil {
+			return nil, fmt.Errorf("signing client %q: %w", client.ID, err)
+		}
+	}
+
+	return file.Clients, nil
+}
+
+// CanonicalString is the string a request signature covers: the method, the
+// path with its query string, the timestamp, the nonce and the hex encoded
+// SHA-256 of the body, separated by newlines
+func CanonicalString(method, requestURI string, timestamp int64, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	return strings.Join([]string{
+		strings.ToUpper(method),
+		requestURI,
+		strconv.FormatInt(timestamp, 10),
+		nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of canonical keyed with secret
+func Sign(secret, canonical string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(canonical))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignRequest adds the Authorization header of a signed request. It is the
+// reference implementation for clients and leaves the body readable.
+func SignRequest(r *http.Request, clientID, secret string, now time.Time) error {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return fmt.Errorf("failed to read body: %w", err)
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	timestamp := now.Unix()
+	nonceHex := hex.EncodeToString(nonce)
+	signature := Sign(secret, CanonicalString(r.Method, r.URL.RequestURI(), timestamp, nonceHex, body))
+
+	r.Header.Set("Authorization", fmt.Sprintf("%s client_id=%s,timestamp=%d,nonce=%s,signature=%s",
+		SignatureScheme, clientID, timestamp, nonceHex, signature))
+	return nil
+}
+
+// SignatureVerifier authenticates signed requests. Requests are accepted
+// within maxSkew of the server clock and each nonce only once; nonces are
+// remembered in memory for as long as their timestamp is acceptable, so the
+// replay protection is per server instance.
+type SignatureVerifier struct {
+	clients map[string]*SigningClient
+	maxSkew time.Duration
+	now     func() time.Time
+
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	nextPurge time.Time
+}
+
+func NewSignatureVerifier(clients []*SigningClient, maxSkew time.Duration) *SignatureVerifier {
+	byID := make(map[string]*SigningClient, len(clients))
+	for _, client := range clients {
+		byID[client.ID] = client
+	}
+
+	return &SignatureVerifier{
+		clients: byID,
+		maxSkew: maxSkew,
+		now:     time.Now,
+		nonces:  make(map[string]time.Time),
+	}
+}
+
+// Verify checks the signature parameters of an Authorization header, without
+// the scheme, against the request and returns the signing client's principal
+func (v *SignatureVerifier) Verify(params, method, requestURI string, body []byte) (*Principal, error) {
+	fields := parseSignatureParams(params)
+	clientID, nonce, signature := fields["client_id"], fields["nonce"], fields["signature"]
+
+	timestamp, err := strconv.ParseInt(fields["timestamp"], 10, 64)
+	if err != nil || clientID == "" || nonce == "" || len(nonce) > maxNonceLen || signature == "" {
+		return nil, fmt.Errorf("%w: malformed signature, client_id, timestamp, nonce and signature are required", ErrUnauthenticated)
+	}
+
+	client, ok := v.clients[clientID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown signing client", ErrUnauthenticated)
+	}
+
+	now := v.now()
+	signedAt := time.Unix(timestamp, 0)
+	if signedAt.Before(now.Add(-v.maxSkew)) || signedAt.After(now.Add(v.maxSkew)) {
+		return nil, fmt.Errorf("%w: signature timestamp is outside the allowed clock skew of %s", ErrUnauthenticated, v.maxSkew)
+	}
+
+	expected := Sign(client.Secret, CanonicalString(method, requestURI, timestamp, nonce, body))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return nil, fmt.Errorf("%w: signature does not match", ErrUnauthenticated)
+	}
+
+	// nonces are only recorded for valid signatures so forged requests cannot
+	// fill the cache
+	if !v.useNonce(clientID+":"+nonce, signedAt.Add(v.maxSkew), now) {
+		return nil, fmt.Errorf("%w: nonce has already been used", ErrUnauthenticated)
+	}
+
+	return &Principal{SigningClientID: client.ID, TenantID: client.Tenant, Scopes: client.Scopes}, nil
+}
+
+// useNonce records the nonce until it expires and reports whether it was new
+func (v *SignatureVerifier) useNonce(key string, expires, now time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if now.After(v.nextPurge) {
+		for nonce, expiry := range v.nonces {
+			if now.After(expiry) {
+				delete(v.nonces, nonce)
+			}
+		}
+		v.nextPurge = now.Add(v.maxSkew)
+	}
+
+	if expiry, seen := v.nonces[key]; seen && !now.After(expiry) {
+		return false
+	}
+
+	v.nonces[key] = expires
+	return true
+}
+
+func parseSignatureParams(params string) map[string]string {
+	fields := make(map[string]string)
+	for _, param := range strings.Split(params, ",") {
+		name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if found {
+			fields[name] = value
+		}
+	}
+	return fields
+}
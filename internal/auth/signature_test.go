@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func signedParams(t *testing.T, method, target, body string, signedAt time.Time) string {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	require.NoError(t, SignRequest(req, "partner", testSecret, signedAt))
+
+	// the body stays readable after signing
+	read, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	require.Equal(t, body, string(read))
+
+	return strings.TrimPrefix(req.Header.Get("Authorization"), SignatureScheme+" ")
+}
+
+func TestSignatureVerifier(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewSignatureVerifier([]*SigningClient{{ID: "partner", Secret: testSecret, Scopes: []string{ScopeTransactionsWrite}}}, 5*time.Minute)
+	verifier.now = func() time.Time { return now }
+
+	body := `{"source_account_id": 1, "destination_account_id": 2, "amount": "10.00"}`
+	params := signedParams(t, "POST", "/transactions", body, now)
+
+	principal, err := verifier.Verify(params, "POST", "/transactions", []byte(body))
+	require.NoError(t, err)
+	assert.Equal(t, "partner", principal.SigningClientID)
+	assert.True(t, principal.HasScope(ScopeTransactionsWrite))
+
+	// the same request cannot be replayed
+	_, err = verifier.Verify(params, "POST", "/transactions", []byte(body))
+	assert.True(t, errors.Is(err, ErrUnauthenticated))
+	assert.Contains(t, err.Error(), "nonce")
+
+	tests := []struct {
+		name   string
+		params string
+		method string
+		uri    string
+		body   string
+	}{
+		{"tampered body", signedParams(t, "POST", "/transactions", body, now), "POST", "/transactions", strings.Replace(body, "10.00", "1000.00", 1)},
+		{"tampered path", signedParams(t, "POST", "/transactions", body, now), "POST", "/accounts", body},
+		{"tampered method", signedParams(t, "POST", "/transactions", body, now), "PUT", "/transactions", body},
+		{"too old", signedParams(t, "POST", "/transactions", body, now.Add(-6*time.Minute)), "POST", "/transactions", body},
+		{"too far ahead", signedParams(t, "POST", "/transactions", body, now.Add(6*time.Minute)), "POST", "/transactions", body},
+		{"unknown client", strings.Replace(signedParams(t, "POST", "/transactions", body, now), "client_id=partner", "client_id=other", 1), "POST", "/transactions", body},
+		{"missing nonce", fmt.Sprintf("client_id=partner,timestamp=%d,signature=00", now.Unix()), "POST", "/transactions", body},
+		{"garbage", "signature", "POST", "/transactions", body},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Verify(tt.params, tt.method, tt.uri, []byte(tt.body))
+			assert.True(t, errors.Is(err, ErrUnauthenticated), "got %v", err)
+		})
+	}
+
+	// nonces are forgotten once their timestamp can no longer be accepted
+	now = now.Add(11 * time.Minute)
+	verifier.useNonce("partner:unrelated", now.Add(time.Minute), now)
+	assert.Len(t, verifier.nonces, 1)
+}
+
+func TestLoadSigningClients(t *testing.T) {
+	write := func(content string) string {
+		path := filepath.Join(t.TempDir(), "clients.json")
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	clients, err := LoadSigningClients(write(`{"clients": [{"id": "partner", "secret": "` + testSecret + `", "scopes": ["transactions:write"]}]}`))
+	require.NoError(t, err)
+	require.Len(t, clients, 1)
+	assert.Equal(t, "partner", clients[0].ID)
+	assert.Equal(t, "default", clients[0].Tenant)
+
+	_, err = LoadSigningClients(write(`{"clients": [{"id": "partner", "secret": "short"}]}`))
+	assert.Error(t, err)
+
+	_, err = LoadSigningClients(write(`{"clients": [{"id": "partner", "secret": "` + testSecret + `", "scopes": ["everything"]}]}`))
+	assert.Error(t, err)
+
+	_, err = LoadSigningClients(write(`{"clients": [{"id": "partner", "secret": "` + testSecret + `", "tenant": "Not A Tenant"}]}`))
+	assert.Error(t, err)
+}
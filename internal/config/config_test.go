@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	path := writeFile(t, "log_level: WARN\nhttp_read_timeout: 5s\nrate_limit_read_burst: 7\n")
+	t.Setenv("HTTP_READ_TIMEOUT", "6s")
+	t.Setenv("RATE_LIMIT_READ_BURST", "8")
+
+	cfg, err := Load(path, []string{"rate_limit_read_burst=9"})
+	require.NoError(t, err)
+	assert.Equal(t, "WARN", cfg.LogLevel, "the file overrides the defaults")
+	assert.Equal(t, 6*time.Second, cfg.HTTPReadTimeout, "the environment overrides the file")
+	assert.Equal(t, int64(9), cfg.RateLimitReadBurst, "flags override the environment")
+	assert.Equal(t, Defaults().GRPCAddress, cfg.GRPCAddress)
+	assert.True(t, cfg.AuthEnabled, "authentication is on unless turned off")
+}
+
+func TestLoadReportsEveryError(t *testing.T) {
+	path := writeFile(t, "unknown_key: 1\nrate_limit_read_burst: many\n")
+	t.Setenv("LOG_LEVEL", "LOUD")
+	t.Setenv("HTTP_IDLE_TIMEOUT", "soon")
+
+	_, err := Load(path, []string{"rate_limit_store=redis", "no_equals"})
+	var configErr *Error
+	require.True(t, errors.As(err, &configErr), "%v", err)
+	assert.Len(t, configErr.Errors, 6)
+	for _, expected := range []string{"unknown_key", "many", "log_level", "HTTP_IDLE_TIMEOUT", "rate_limit_store", "no_equals"} {
+		assert.Contains(t, err.Error(), expected)
+	}
+}
+
+func TestLoadSecretFromFile(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "database_url")
+	require.NoError(t, os.WriteFile(secret, []byte("postgres://app:s3cret@db:5432/txn\n"), 0o600))
+	t.Setenv("DATABASE_URL_FILE", secret)
+
+	cfg, err := Load("", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://app:s3cret@db:5432/txn", cfg.DatabaseURL)
+	assert.Equal(t, "postgres://app:xxxxx@db:5432/txn", cfg.Redacted().DatabaseURL)
+	assert.Equal(t, "postgres://app:s3cret@db:5432/txn", cfg.DatabaseURL, "redacting leaves the config alone")
+
+	t.Setenv("DATABASE_URL", "postgres://other")
+	_, err = Load("", nil)
+	assert.ErrorContains(t, err, "set only one of DATABASE_URL and DATABASE_URL_FILE")
+}
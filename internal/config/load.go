@@ -0,0 +1,228 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the config file when no --config flag is given
+const FileEnv = "CONFIG_FILE"
+
+// redacted replaces the value of a secret setting when it is printed
+const redacted = "REDACTED"
+
+// Error lists every invalid setting of a configuration
+type Error struct {
+	Errors []error
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, 0, len(e.Errors)+1)
+	lines = append(lines, "invalid configuration:")
+	for _, err := range e.Errors {
+		lines = append(lines, "  "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Load builds the configuration from, in increasing precedence, the defaults,
+// the YAML file at path, or at CONFIG_FILE when path is empty, the
+// environment and overrides, which are key=value pairs keyed like the file.
+// The configuration is validated; a returned *Error lists every problem, not
+// just the first.
+func Load(path string, overrides []string) (*Config, error) {
+	cfg := Defaults()
+	var errs []error
+
+	if path == "" {
+		path = os.Getenv(FileEnv)
+	}
+	if path != "" {
+		fileErrs, err := cfg.loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, fileErrs...)
+	}
+
+	errs = append(errs, cfg.loadEnv()...)
+	errs = append(errs, cfg.applyOverrides(overrides)...)
+	errs = append(errs, cfg.Validate()...)
+
+	if len(errs) > 0 {
+		return nil, &Error{Errors: errs}
+	}
+	return cfg, nil
+}
+
+// loadFile reads the settings of the YAML file at path. Unknown keys and
+// values of the wrong type are returned as setting errors.
+func (c *Config) loadFile(path string) ([]error, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	err = decoder.Decode(c)
+
+	var typeErr *yaml.TypeError
+	switch {
+	case err == nil, errors.Is(err, io.EOF):
+		return nil, nil
+	case errors.As(err, &typeErr):
+		errs := make([]error, 0, len(typeErr.Errors))
+		for _, message := range typeErr.Errors {
+			errs = append(errs, fmt.Errorf("%s: %s", path, message))
+		}
+		return errs, nil
+	default:
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+}
+
+// loadEnv reads the settings whose variable is set. A secret may instead be
+// read from the file its <variable>_FILE names, such as a mounted secret.
+func (c *Config) loadEnv() []error {
+	var errs []error
+	c.eachSetting(func(s setting) {
+		value, ok := os.LookupEnv(s.env)
+		fileName, fromFile := os.LookupEnv(s.env + "_FILE")
+		fromFile = fromFile && s.secret
+
+		switch {
+		case ok && value != "" && fromFile:
+			errs = append(errs, fmt.Errorf("%s: set only one of %s and %s_FILE", s.env, s.env, s.env))
+			return
+		case fromFile:
+			content, err := os.ReadFile(fileName)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s_FILE: %w", s.env, err))
+				return
+			}
+			value = strings.TrimRight(string(content), "\r\n")
+		case !ok || value == "":
+			return
+		}
+
+		if err := s.set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s.env, err))
+		}
+	})
+	return errs
+}
+
+// applyOverrides applies key=value pairs, as given by --set flags
+func (c *Config) applyOverrides(overrides []string) []error {
+	settings := settingsByKey(c)
+
+	var errs []error
+	for _, override := range overrides {
+		key, value, ok := strings.Cut(override, "=")
+		s, known := settings[key]
+		switch {
+		case !ok:
+			errs = append(errs, fmt.Errorf("--set %s: must be key=value", override))
+		case !known:
+			errs = append(errs, fmt.Errorf("--set %s: unknown setting %s", override, key))
+		default:
+			if err := s.set(value); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			}
+		}
+	}
+	return errs
+}
+
+// Redacted returns a copy of the configuration that is safe to print: secrets
+// are replaced, and only the password of a URL so the host stays visible
+func (c *Config) Redacted() *Config {
+	safe := *c
+	safe.eachSetting(func(s setting) {
+		if !s.secret || s.value.String() == "" {
+			return
+		}
+		if u, err := url.Parse(s.value.String()); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				s.value.SetString(u.Redacted())
+				return
+			}
+		}
+		s.value.SetString(redacted)
+	})
+	return &safe
+}
+
+// setting is one field of Config with its tags
+type setting struct {
+	key        string
+	env        string
+	secret     bool
+	reloadable bool
+	value      reflect.Value
+}
+
+func (c *Config) eachSetting(fn func(s setting)) {
+	value := reflect.ValueOf(c).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		fn(setting{
+			key:        field.Tag.Get("yaml"),
+			env:        field.Tag.Get("env"),
+			secret:     field.Tag.Get("secret") == "true",
+			reloadable: field.Tag.Get("reload") == "true",
+			value:      value.Field(i),
+		})
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses value into the setting
+func (s setting) set(value string) error {
+	if s.value.Type() == durationType {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		s.value.SetInt(int64(parsed))
+		return nil
+	}
+
+	switch s.value.Kind() {
+	case reflect.String:
+		s.value.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		s.value.SetBool(parsed)
+	case reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", value)
+		}
+		s.value.SetInt(parsed)
+	case reflect.Float64:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		s.value.SetFloat(parsed)
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"txn-service/internal/logger"
+)
+
+// Change is a setting whose value differs between two configurations, with
+// secrets redacted
+type Change struct {
+	Key string `json:"key"`
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Key, c.Old, c.New)
+}
+
+// ReloadResult is what a reload changed. RestartRequired lists the changed
+// settings that a running service cannot apply; they are left as they were.
+type ReloadResult struct {
+	Applied         []Change `json:"applied"`
+	RestartRequired []Change `json:"restart_required"`
+}
+
+// Reloader re-reads the configuration of a running service and applies the
+// settings tagged reload to it
+type Reloader struct {
+	mu       sync.Mutex
+	current  *Config
+	load     func() (*Config, error)
+	appliers []func(cfg *Config)
+	logger   *logger.Logger
+}
+
+// NewReloader creates a reloader for a service running with current. load
+// reads the configuration again from the sources current came from.
+func NewReloader(current *Config, load func() (*Config, error), log *logger.Logger) *Reloader {
+	return &Reloader{
+		current: current,
+		load:    load,
+		logger:  log,
+	}
+}
+
+// OnReload registers apply to push the reloadable settings of a new
+// configuration into a running component. It must not fail: the
+// configuration has been validated by then.
+func (r *Reloader) OnReload(apply func(cfg *Config)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.appliers = append(r.appliers, apply)
+}
+
+// Current returns the configuration the service runs with
+func (r *Reloader) Current() *Config {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.current
+}
+
+// Reload loads the configuration and validates it as a whole before applying
+// anything: an invalid configuration is rejected and the current one stays in
+// place. Otherwise every changed reloadable setting is applied, and the
+// changes are logged.
+func (r *Reloader) Reload() (*ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	loaded, err := r.load()
+	if err != nil {
+		r.logger.Error("Configuration reload rejected, keeping the current configuration: %v", err)
+		return nil, err
+	}
+
+	result := &ReloadResult{Applied: []Change{}, RestartRequired: []Change{}}
+	next := *r.current
+	nextSettings, loadedSettings := settingsByKey(&next), settingsByKey(loaded)
+	for _, change := range diff(r.current, loaded) {
+		if !nextSettings[change.Key].reloadable {
+			result.RestartRequired = append(result.RestartRequired, change)
+			continue
+		}
+		nextSettings[change.Key].value.Set(loadedSettings[change.Key].value)
+		result.Applied = append(result.Applied, change)
+	}
+
+	for _, change := range result.RestartRequired {
+		r.logger.Warn("Configuration change needs a restart, not applied - %s", change)
+	}
+	if len(result.Applied) == 0 {
+		r.logger.Info("Configuration reloaded, nothing to apply")
+		return result, nil
+	}
+
+	for _, apply := range r.appliers {
+		apply(&next)
+	}
+	r.current = &next
+
+	for _, change := range result.Applied {
+		r.logger.Info("Configuration changed - %s", change)
+	}
+	return result, nil
+}
+
+// diff lists the settings that differ between old and new in the order of
+// Config, with secrets redacted
+func diff(old, new *Config) []Change {
+	oldValues := settingsByKey(old.Redacted())
+	newValues := settingsByKey(new.Redacted())
+	rawNew := settingsByKey(new)
+
+	var changes []Change
+	old.eachSetting(func(s setting) {
+		if reflect.DeepEqual(s.value.Interface(), rawNew[s.key].value.Interface()) {
+			return
+		}
+		changes = append(changes, Change{Key: s.key, Old: format(oldValues[s.key].value), New: format(newValues[s.key].value)})
+	})
+	return changes
+}
+
+func settingsByKey(c *Config) map[string]setting {
+	settings := map[string]setting{}
+	c.eachSetting(func(s setting) { settings[s.key] = s })
+	return settings
+}
+
+func format(value reflect.Value) string {
+	if value.Type() == durationType {
+		return time.Duration(value.Int()).String()
+	}
+	return fmt.Sprint(value.Interface())
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"txn-service/internal/logger"
+)
+
+func TestReload(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New("INFO")
+	log.SetOutput(&out)
+
+	current, err := Load("", nil)
+	require.NoError(t, err)
+
+	overrides := []string{"log_level=DEBUG", "rate_limit_write_burst=3", "transfer_daily_limit=1000.50", "server_address=:9090"}
+	reloader := NewReloader(current, func() (*Config, error) { return Load("", overrides) }, log)
+	var applied []*Config
+	reloader.OnReload(func(cfg *Config) { applied = append(applied, cfg) })
+
+	result, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.Equal(t, []Change{
+		{Key: "rate_limit_write_burst", Old: "10", New: "3"},
+		{Key: "log_level", Old: "INFO", New: "DEBUG"},
+		{Key: "transfer_daily_limit", Old: "", New: "1000.50"},
+	}, result.Applied)
+	assert.Equal(t, []Change{{Key: "server_address", Old: ":8080", New: ":9090"}}, result.RestartRequired)
+
+	require.Len(t, applied, 1)
+	assert.Equal(t, logger.LevelDebug, applied[0].Settings().LogLevel)
+	assert.Equal(t, "1000.50", applied[0].Settings().TransferDailyLimit)
+	assert.Equal(t, ":8080", applied[0].ServerAddress, "settings that need a restart are not applied")
+	assert.Same(t, applied[0], reloader.Current())
+	assert.Contains(t, out.String(), "Configuration changed - log_level: INFO -> DEBUG")
+	assert.Contains(t, out.String(), "server_address: :8080 -> :9090")
+
+	// an invalid configuration changes nothing
+	overrides = []string{"transfer_max_amount=-5", "slow_request_threshold=5s"}
+	_, err = reloader.Reload()
+	var configErr *Error
+	require.True(t, errors.As(err, &configErr), "%v", err)
+	assert.Len(t, applied, 1)
+	assert.Empty(t, reloader.Current().TransferMaxAmount)
+	assert.NotEqual(t, 5*time.Second, reloader.Current().SlowRequestThreshold)
+	assert.Contains(t, out.String(), "Configuration reload rejected")
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/validation"
+)
+
+// Validate checks every setting and returns one error per invalid setting,
+// named by its config file key
+func (c *Config) Validate() []error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(c.ServerAddress != "", "server_address", "must be set")
+	check(c.GRPCAddress != "", "grpc_address", "must be set")
+	check(c.DatabaseURL != "", "database_url", "must be set")
+
+	check(c.HTTPReadTimeout > 0, "http_read_timeout", "must be positive")
+	check(c.HTTPWriteTimeout > 0, "http_write_timeout", "must be positive")
+	check(c.HTTPIdleTimeout > 0, "http_idle_timeout", "must be positive")
+	check(c.ShutdownTimeout > 0, "shutdown_timeout", "must be positive")
+	check(c.ShutdownDrainDelay >= 0, "shutdown_drain_delay", "must not be negative")
+	check(c.ReadinessCheckTimeout > 0, "readiness_check_timeout", "must be positive")
+	check(c.GRPCRequestTimeout > 0, "grpc_request_timeout", "must be positive")
+
+	check(c.DatabaseMaxOpenConns > 0, "database_max_open_conns", "must be positive")
+	check(c.DatabaseMaxIdleConns >= 0 && c.DatabaseMaxIdleConns <= c.DatabaseMaxOpenConns,
+		"database_max_idle_conns", "must be between 0 and database_max_open_conns (%d)", c.DatabaseMaxOpenConns)
+	check(c.DatabaseConnMaxLifetime >= 0, "database_conn_max_lifetime", "must not be negative")
+	_, ok := isolationLevels[c.TransferIsolation]
+	check(ok, "transfer_isolation", "unknown level %q, must be read_committed, repeatable_read or serializable", c.TransferIsolation)
+
+	check(c.JWKSRefreshInterval > 0, "jwks_refresh_interval", "must be positive")
+	check(c.SignatureMaxSkew > 0, "signature_max_skew", "must be positive")
+
+	check(c.RateLimitStore == "memory" || c.RateLimitStore == "postgres", "rate_limit_store", "unknown store %q, must be memory or postgres", c.RateLimitStore)
+	check(c.RateLimitReadRate > 0, "rate_limit_read_rps", "must be positive")
+	check(c.RateLimitReadBurst >= 1, "rate_limit_read_burst", "must be at least 1")
+	check(c.RateLimitWriteRate > 0, "rate_limit_write_rps", "must be positive")
+	check(c.RateLimitWriteBurst >= 1, "rate_limit_write_burst", "must be at least 1")
+	check(c.RateLimitIPRate > 0, "rate_limit_ip_rps", "must be positive")
+	check(c.RateLimitIPBurst >= 1, "rate_limit_ip_burst", "must be at least 1")
+
+	check(c.SlowRequestThreshold >= 0, "slow_request_threshold", "must not be negative")
+
+	_, err := logger.ParseLevel(c.LogLevel)
+	check(err == nil, "log_level", "%v", err)
+	_, err = logger.ParseFormat(c.LogFormat)
+	check(err == nil, "log_format", "%v", err)
+	check(c.LogMaxSizeMB >= 0, "log_max_size_mb", "must not be negative")
+	check(c.LogRotateInterval >= 0, "log_rotate_interval", "must not be negative")
+	check(c.LogMaxAge >= 0, "log_max_age", "must not be negative")
+	check(c.LogMaxBackups >= 0, "log_max_backups", "must not be negative")
+
+	exporter := strings.ToLower(c.TracingExporter)
+	check(exporter == "none" || exporter == "otlp" || exporter == "stdout", "tracing_exporter", "unknown exporter %q, must be none, otlp or stdout", c.TracingExporter)
+	check(c.TracingSampleRatio >= 0 && c.TracingSampleRatio <= 1, "tracing_sample_ratio", "must be between 0 and 1")
+
+	check(isLimit(c.TransferMaxAmount), "transfer_max_amount", "must be empty or a positive decimal, got %q", c.TransferMaxAmount)
+	check(isLimit(c.TransferDailyLimit), "transfer_daily_limit", "must be empty or a positive decimal, got %q", c.TransferDailyLimit)
+
+	check(c.InterestExpenseAccountID >= 0, "interest_expense_account_id", "must not be negative")
+	check(c.InterestJobInterval > 0, "interest_job_interval", "must be positive")
+
+	return errs
+}
+
+// isLimit reports whether limit is an amount limit: empty for none, or a
+// positive decimal
+func isLimit(limit string) bool {
+	if limit == "" {
+		return true
+	}
+	amount, ok := validation.ParseDecimal(limit)
+	return ok && amount.Sign() > 0
+}
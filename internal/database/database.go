@@ -1,29 +1,38 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"time"
 
-	_ "github.com/lib/pq"
+	"txn-service/internal/tracing"
+
+	"github.com/lib/pq"
 )
 
-func NewConnection(databaseURL string) (*sql.DB, error) {
-	db, err := sql.Open("postgres", databaseURL)
+// Options size the connection pool
+type Options struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// NewConnection opens the database and applies the pending migrations
+func NewConnection(databaseURL string, options Options) (*sql.DB, error) {
+	db, err := Open(databaseURL, options)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database: %w", err)
+		return nil, err
 	}
 
-	db.SetMaxOpenConns(50)
-	db.SetMaxIdleConns(25)
-	db.SetConnMaxLifetime(10 * time.Minute)
-
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
 	}
-
-	if err := runMigrations(db); err != nil {
+	if _, err := migrator.Up(context.Background()); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to run migrations: %w", err)
 	}
 
@@ -31,45 +40,33 @@ func NewConnection(databaseURL string) (*sql.DB, error) {
 	return db, nil
 }
 
-func runMigrations(db *sql.DB) error {
-
-	accountsTable := `
-	CREATE TABLE IF NOT EXISTS accounts (
-		id SERIAL PRIMARY KEY,
-		account_id BIGINT UNIQUE NOT NULL,
-		balance DECIMAL(20,8) NOT NULL DEFAULT 0,
-		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-	);`
+// Open connects to the database, with every statement traced, without
+// touching the schema
+func Open(databaseURL string, options Options) (*sql.DB, error) {
+	connector, err := pq.NewConnector(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db := sql.OpenDB(tracing.WrapConnector(connector))
 
-	transactionsTable := `
-	CREATE TABLE IF NOT EXISTS transactions (
-		id SERIAL PRIMARY KEY,
-		transaction_id UUID UNIQUE NOT NULL,
-		source_account_id BIGINT NOT NULL,
-		destination_account_id BIGINT NOT NULL,
-		amount DECIMAL(20,8) NOT NULL,
-		status VARCHAR(20) NOT NULL DEFAULT 'pending',
-		created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-	);`
+	db.SetMaxOpenConns(options.MaxOpenConns)
+	db.SetMaxIdleConns(options.MaxIdleConns)
+	db.SetConnMaxLifetime(options.ConnMaxLifetime)
 
-	indexes := []string{
-		"CREATE INDEX IF NOT EXISTS idx_accounts_account_id ON accounts(account_id);",
-		"CREATE INDEX IF NOT EXISTS idx_transactions_source_account_id ON transactions(source_account_id);",
-		"CREATE INDEX IF NOT EXISTS idx_transactions_destination_account_id ON transactions(destination_account_id);",
-		"CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status);",
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	migrations := []string{accountsTable, transactionsTable}
-	migrations = append(migrations, indexes...)
+	return db, nil
+}
 
-	for _, migration := range migrations {
-		if _, err := db.Exec(migration); err != nil {
-			return fmt.Errorf("failed to execute migration: %w", err)
-		}
+// CheckSchemaVersion fails unless db has been migrated to at least the
+// version of this build
+func CheckSchemaVersion(ctx context.Context, db *sql.DB) error {
+	migrator, err := NewMigrator(db)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
 	}
-
-	log.Println("Database migrations completed successfully")
-	return nil
+	return migrator.Check(ctx)
 }
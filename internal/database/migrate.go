@@ -0,0 +1,324 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// migrationFiles are the numbered migrations of the schema, each an
+// NNNN_name.up.sql file with its NNNN_name.down.sql inverse
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the advisory lock replicas take while migrating, so
+// only one of them changes the schema at a time
+const migrationLockKey = 7264501380
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+const schemaMigrationsTable = `
+	CREATE TABLE IF NOT EXISTS schema_migrations (
+		version BIGINT PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum CHAR(64) NOT NULL,
+		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);`
+
+// Migration is one numbered schema change. Checksum is the SHA-256 of Up; a
+// migration may not change once applied.
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string
+}
+
+// MigrationStatus is a migration with the time it was applied, nil when it is
+// pending
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// ErrChecksumMismatch is returned when an applied migration no longer matches
+// the file it was applied from
+var ErrChecksumMismatch = errors.New("migration changed after it was applied")
+
+// Migrator applies and reverts the migrations of this build
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// embeddedMigrations parses migrationFiles once
+var embeddedMigrations = sync.OnceValues(func() ([]Migration, error) {
+	return loadMigrations(migrationFiles)
+})
+
+// NewMigrator creates a migrator for db with the embedded migrations
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := embeddedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// loadMigrations reads the migrations of fsys, which must be numbered 1 to n
+// without gaps and each come with both an up and a down file
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "migrations/*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, file := range files {
+		match := migrationFileName.FindStringSubmatch(path.Base(file))
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %s", file)
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", file, err)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", file, err)
+		}
+
+		migration := byVersion[version]
+		if migration == nil {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	for i, migration := range migrations {
+		if migration.Version != int64(i+1) {
+			return nil, fmt.Errorf("migration %d_%s is out of sequence, expected version %d", migration.Version, migration.Name, i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// LatestVersion is the version this build migrates the database to
+func (m *Migrator) LatestVersion() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Up applies every pending migration in order, each in its own transaction,
+// and returns how many it applied
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sql.Conn, appliedVersions map[int64]appliedMigration) error {
+		for _, migration := range m.migrations {
+			if _, ok := appliedVersions[migration.Version]; ok {
+				continue
+			}
+
+			err := inTransaction(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+					migration.Version, migration.Name, migration.Checksum)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns
+// how many it reverted
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := m.withLock(ctx, func(conn *sql.Conn, appliedVersions map[int64]appliedMigration) error {
+		for i := len(m.migrations) - 1; i >= 0 && reverted < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := appliedVersions[migration.Version]; !ok {
+				continue
+			}
+
+			err := inTransaction(ctx, conn, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.Down); err != nil {
+					return err
+				}
+				_, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			log.Printf("Reverted migration %d_%s", migration.Version, migration.Name)
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status lists every migration of this build with the time it was applied
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.withLock(ctx, func(conn *sql.Conn, appliedVersions map[int64]appliedMigration) error {
+		for _, migration := range m.migrations {
+			status := MigrationStatus{Migration: migration}
+			if applied, ok := appliedVersions[migration.Version]; ok {
+				appliedAt := applied.appliedAt
+				status.AppliedAt = &appliedAt
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Check fails unless every migration of this build has been applied. It takes
+// no lock and creates nothing, so it is cheap enough for a readiness probe.
+func (m *Migrator) Check(ctx context.Context) error {
+	var version int64
+	err := m.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	if version < m.LatestVersion() {
+		return fmt.Errorf("schema is at version %d, expected %d", version, m.LatestVersion())
+	}
+	return nil
+}
+
+type appliedMigration struct {
+	checksum  string
+	appliedAt time.Time
+}
+
+// withLock runs fn on one connection holding the migration lock, with the
+// migrations applied so far. The checksums of applied migrations are verified
+// first; migrations of a newer build are left alone.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]appliedMigration) error) error {
+	// advisory locks belong to the session, so everything runs on one conn
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer func() {
+		// the lock is released with the session anyway if this fails
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			log.Printf("Failed to release migration lock: %v", err)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, schemaMigrationsTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	applied, err := readApplied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	known := map[int64]bool{}
+	for _, migration := range m.migrations {
+		known[migration.Version] = true
+		if previous, ok := applied[migration.Version]; ok && previous.checksum != migration.Checksum {
+			return fmt.Errorf("%w: %d_%s", ErrChecksumMismatch, migration.Version, migration.Name)
+		}
+	}
+	for version := range applied {
+		if !known[version] {
+			log.Printf("Database has migration %d, which this build does not know; it was applied by a newer build", version)
+		}
+	}
+
+	return fn(conn, applied)
+}
+
+func readApplied(ctx context.Context, conn *sql.Conn) (map[int64]appliedMigration, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var version int64
+		var migration appliedMigration
+		if err := rows.Scan(&version, &migration.checksum, &migration.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = migration
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	return applied, nil
+}
+
+func inTransaction(ctx context.Context, conn *sql.Conn, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := embeddedMigrations()
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, migration := range migrations {
+		assert.Equal(t, int64(i+1), migration.Version)
+		assert.Len(t, migration.Checksum, 64)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+
+	migrations, err := loadMigrations(fstest.MapFS{
+		"migrations/0002_add_column.up.sql":     file("ALTER TABLE t ADD COLUMN c INT;"),
+		"migrations/0002_add_column.down.sql":   file("ALTER TABLE t DROP COLUMN c;"),
+		"migrations/0001_create_table.up.sql":   file("CREATE TABLE t (id INT);"),
+		"migrations/0001_create_table.down.sql": file("DROP TABLE t;"),
+	})
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, "create_table", migrations[0].Name)
+	assert.Equal(t, "DROP TABLE t;", migrations[0].Down)
+	assert.Equal(t, "add_column", migrations[1].Name)
+	// sha256 of the up file
+	assert.Equal(t, "c8c556a36a5e9e8a3e80b8e2826374b4105c5ef6e764bb245a7399d984861d92", migrations[1].Checksum)
+
+	tests := map[string]fstest.MapFS{
+		"missing down": {
+			"migrations/0001_create_table.up.sql": file("CREATE TABLE t (id INT);"),
+		},
+		"gap": {
+			"migrations/0001_create_table.up.sql":   file("CREATE TABLE t (id INT);"),
+			"migrations/0001_create_table.down.sql": file("DROP TABLE t;"),
+			"migrations/0003_add_column.up.sql":     file("ALTER TABLE t ADD COLUMN c INT;"),
+			"migrations/0003_add_column.down.sql":   file("ALTER TABLE t DROP COLUMN c;"),
+		},
+		"name mismatch": {
+			"migrations/0001_create_table.up.sql": file("CREATE TABLE t (id INT);"),
+			"migrations/0001_create_t.down.sql":   file("DROP TABLE t;"),
+		},
+		"bad file name": {
+			"migrations/create_table.sql": file("CREATE TABLE t (id INT);"),
+		},
+	}
+	for name, fsys := range tests {
+		_, err := loadMigrations(fsys)
+		assert.Error(t, err, name)
+	}
+}
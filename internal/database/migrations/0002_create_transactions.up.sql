@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS transactions (
+	id SERIAL PRIMARY KEY,
+	transaction_id UUID UNIQUE NOT NULL,
+	source_account_id BIGINT NOT NULL,
+	destination_account_id BIGINT NOT NULL,
+	amount DECIMAL(20,8) NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_transactions_source_account_id ON transactions(source_account_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_destination_account_id ON transactions(destination_account_id);
+CREATE INDEX IF NOT EXISTS idx_transactions_status ON transactions(status);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS account_products (
+	id SERIAL PRIMARY KEY,
+	product_code VARCHAR(50) UNIQUE NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	annual_interest_rate DECIMAL(9,6) NOT NULL DEFAULT 0,
+	day_count_convention VARCHAR(10) NOT NULL DEFAULT 'ACT/365',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
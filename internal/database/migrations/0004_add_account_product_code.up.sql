@@ -0,0 +1,3 @@
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS product_code VARCHAR(50) REFERENCES account_products(product_code);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_product_code ON accounts(product_code);
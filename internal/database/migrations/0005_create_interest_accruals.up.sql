@@ -0,0 +1,15 @@
+-- interest_accruals is unique per (account_id, accrual_date) so the accrual
+-- job can be re-run for any day without accruing twice
+CREATE TABLE IF NOT EXISTS interest_accruals (
+	account_id BIGINT NOT NULL,
+	accrual_date DATE NOT NULL,
+	end_of_day_balance DECIMAL(20,8) NOT NULL,
+	annual_interest_rate DECIMAL(9,6) NOT NULL,
+	amount DECIMAL(20,8) NOT NULL,
+	posted_transaction_id UUID,
+	posted_at TIMESTAMP WITH TIME ZONE,
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (account_id, accrual_date)
+);
+
+CREATE INDEX IF NOT EXISTS idx_interest_accruals_unposted ON interest_accruals(accrual_date) WHERE posted_at IS NULL;
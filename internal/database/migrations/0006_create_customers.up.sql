@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS customers (
+	id BIGSERIAL PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	external_reference VARCHAR(255) UNIQUE NOT NULL,
+	kyc_status VARCHAR(20) NOT NULL DEFAULT 'pending',
+	metadata JSONB NOT NULL DEFAULT '{}',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS customer_id BIGINT REFERENCES customers(id);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_customer_id ON accounts(customer_id);
@@ -0,0 +1,4 @@
+ALTER TABLE transactions
+	DROP COLUMN IF EXISTS reference,
+	DROP COLUMN IF EXISTS description,
+	DROP COLUMN IF EXISTS metadata;
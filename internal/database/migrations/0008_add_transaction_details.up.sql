@@ -0,0 +1,7 @@
+ALTER TABLE transactions
+	ADD COLUMN IF NOT EXISTS reference VARCHAR(255),
+	ADD COLUMN IF NOT EXISTS description VARCHAR(1024),
+	ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_transactions_reference ON transactions(reference) WHERE reference IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_transactions_metadata ON transactions USING GIN (metadata jsonb_path_ops);
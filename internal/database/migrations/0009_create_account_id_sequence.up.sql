@@ -0,0 +1,3 @@
+-- server allocated account IDs start high so they stay clear of the small IDs
+-- clients have been choosing themselves; collisions are still retried
+CREATE SEQUENCE IF NOT EXISTS account_id_seq START WITH 100000000;
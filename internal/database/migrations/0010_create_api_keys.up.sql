@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+	key_id VARCHAR(32) PRIMARY KEY,
+	name VARCHAR(255) NOT NULL,
+	key_hash CHAR(64) NOT NULL,
+	scopes TEXT[] NOT NULL DEFAULT '{}',
+	created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+	revoked_at TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS api_key_id;
@@ -0,0 +1,3 @@
+-- api_key_id records the key a transaction was created with; it stays NULL for
+-- transactions created without authentication or by background jobs
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS api_key_id VARCHAR(32);
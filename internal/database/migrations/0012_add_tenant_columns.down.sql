@@ -0,0 +1,5 @@
+ALTER TABLE accounts DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE customers DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE transactions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE interest_accruals DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
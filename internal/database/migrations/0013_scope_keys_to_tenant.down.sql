@@ -0,0 +1,8 @@
+-- fails when two tenants share an account ID, customer reference or accrual
+-- day, which only the tenant scoped keys allow
+ALTER TABLE accounts ADD CONSTRAINT accounts_account_id_key UNIQUE (account_id);
+ALTER TABLE customers ADD CONSTRAINT customers_external_reference_key UNIQUE (external_reference);
+ALTER TABLE interest_accruals ADD CONSTRAINT interest_accruals_pkey PRIMARY KEY (account_id, accrual_date);
+DROP INDEX IF EXISTS idx_accounts_tenant_account_id;
+DROP INDEX IF EXISTS idx_customers_tenant_external_reference;
+DROP INDEX IF EXISTS idx_interest_accruals_tenant_account_date;
@@ -0,0 +1,9 @@
+-- account IDs, customer references and accrual days are unique per tenant
+-- rather than globally; the tenant scoped keys are created before the global
+-- ones are dropped
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_tenant_account_id ON accounts(tenant_id, account_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_tenant_external_reference ON customers(tenant_id, external_reference);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_interest_accruals_tenant_account_date ON interest_accruals(tenant_id, account_id, accrual_date);
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_account_id_key;
+ALTER TABLE customers DROP CONSTRAINT IF EXISTS customers_external_reference_key;
+ALTER TABLE interest_accruals DROP CONSTRAINT IF EXISTS interest_accruals_pkey;
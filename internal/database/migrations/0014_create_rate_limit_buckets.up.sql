@@ -0,0 +1,10 @@
+-- rate_limit_buckets holds the token buckets the replicas share. It is
+-- unlogged, losing the counters in a crash only refills the buckets.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+	key VARCHAR(255) PRIMARY KEY,
+	tokens DOUBLE PRECISION NOT NULL,
+	allowed BOOLEAN NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated_at ON rate_limit_buckets(updated_at);
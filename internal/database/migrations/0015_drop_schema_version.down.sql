@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS schema_version (
+	version INTEGER NOT NULL,
+	migrated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
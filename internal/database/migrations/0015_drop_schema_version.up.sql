@@ -0,0 +1,2 @@
+-- schema_migrations replaces the single version row of earlier builds
+DROP TABLE IF EXISTS schema_version;
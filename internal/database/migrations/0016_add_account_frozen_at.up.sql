@@ -0,0 +1,2 @@
+-- a frozen account can neither send nor receive transfers until unfrozen
+ALTER TABLE accounts ADD COLUMN IF NOT EXISTS frozen_at TIMESTAMP WITH TIME ZONE;
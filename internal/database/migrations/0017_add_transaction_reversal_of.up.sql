@@ -0,0 +1,5 @@
+-- reversal_of links a reversal to the transaction it undoes; the unique index
+-- keeps a transaction from being reversed twice
+ALTER TABLE transactions ADD COLUMN IF NOT EXISTS reversal_of UUID;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_transactions_reversal_of ON transactions(reversal_of) WHERE reversal_of IS NOT NULL;
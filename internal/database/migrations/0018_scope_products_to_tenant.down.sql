@@ -0,0 +1,7 @@
+-- fails when two tenants share a product code, which only the tenant scoped
+-- key allows
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_tenant_product_code_fkey;
+ALTER TABLE account_products ADD CONSTRAINT account_products_product_code_key UNIQUE (product_code);
+ALTER TABLE accounts ADD CONSTRAINT accounts_product_code_fkey FOREIGN KEY (product_code) REFERENCES account_products(product_code);
+DROP INDEX IF EXISTS idx_account_products_tenant_product_code;
+ALTER TABLE account_products DROP COLUMN IF EXISTS tenant_id;
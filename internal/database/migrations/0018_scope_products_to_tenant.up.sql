@@ -0,0 +1,21 @@
+-- products belong to a tenant like accounts do, and product codes are unique
+-- per tenant; products that predate this belong to the default tenant
+ALTER TABLE account_products ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+-- the global key and the account foreign key on it are replaced by tenant
+-- scoped ones
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_product_code_fkey;
+ALTER TABLE account_products DROP CONSTRAINT IF EXISTS account_products_product_code_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_products_tenant_product_code ON account_products(tenant_id, product_code);
+
+-- accounts of other tenants attached to a shared product get their own copy of it
+INSERT INTO account_products (tenant_id, product_code, name, annual_interest_rate, day_count_convention, created_at)
+SELECT DISTINCT a.tenant_id, p.product_code, p.name, p.annual_interest_rate, p.day_count_convention, p.created_at
+FROM accounts a
+JOIN account_products p ON p.product_code = a.product_code
+WHERE a.tenant_id <> p.tenant_id
+ON CONFLICT (tenant_id, product_code) DO NOTHING;
+
+-- an account can only be attached to a product of its own tenant
+ALTER TABLE accounts ADD CONSTRAINT accounts_tenant_product_code_fkey
+	FOREIGN KEY (tenant_id, product_code) REFERENCES account_products(tenant_id, product_code);
@@ -0,0 +1,77 @@
+package grpcapi
+
+import (
+	"context"
+
+	"txn-service/internal/accountnumber"
+	"txn-service/internal/service"
+	"txn-service/models"
+	txnv1 "txn-service/proto/txn/v1"
+)
+
+type AccountServer struct {
+	txnv1.UnimplementedAccountServiceServer
+	accountService service.AccountService
+}
+
+func NewAccountServer(accountService service.AccountService) *AccountServer {
+	return &AccountServer{
+		accountService: accountService,
+	}
+}
+
+func (s *AccountServer) CreateAccount(ctx context.Context, req *txnv1.CreateAccountRequest) (*txnv1.CreateAccountResponse, error) {
+	account, err := s.accountService.CreateAccount(ctx, &models.CreateAccountRequest{
+		AccountID:      req.GetAccountId(),
+		InitialBalance: req.GetInitialBalance(),
+		ProductCode:    req.GetProductCode(),
+		CustomerID:     req.GetCustomerId(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &txnv1.CreateAccountResponse{Account: toAccountProto(account)}, nil
+}
+
+func (s *AccountServer) GetAccount(ctx context.Context, req *txnv1.GetAccountRequest) (*txnv1.GetAccountResponse, error) {
+	var accountID int64
+
+	switch ref := req.GetAccount().(type) {
+	case *txnv1.GetAccountRequest_AccountId:
+		if ref.AccountId <= 0 {
+			return nil, &service.ValidationError{Field: "account_id", Message: "must be greater than 0"}
+		}
+		accountID = ref.AccountId
+	case *txnv1.GetAccountRequest_AccountNumber:
+		parsed, err := accountnumber.Parse(ref.AccountNumber)
+		if err != nil {
+			return nil, &service.ValidationError{Field: "account_number", Message: err.Error()}
+		}
+		accountID = parsed
+	default:
+		return nil, &service.ValidationError{Field: "account", Message: "account_id or account_number is required"}
+	}
+
+	account, err := s.accountService.GetAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &txnv1.GetAccountResponse{Account: toAccountProto(account)}, nil
+}
+
+func toAccountProto(account *models.Account) *txnv1.Account {
+	result := &txnv1.Account{
+		AccountId:     account.AccountID,
+		AccountNumber: account.AccountNumber,
+		Balance:       account.Balance,
+	}
+	if account.ProductCode != nil {
+		result.ProductCode = *account.ProductCode
+	}
+	if account.CustomerID != nil {
+		result.CustomerId = *account.CustomerID
+	}
+	return result
+}
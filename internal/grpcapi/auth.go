@@ -0,0 +1,74 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"txn-service/internal/auth"
+	txnv1 "txn-service/proto/txn/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodScopes is the scope each unary RPC requires, matching the HTTP routes.
+// The auth interceptor denies unary RPCs that are not listed, so a new RPC
+// stays closed until it is given a scope here. Server reflection is a
+// streaming RPC and never reaches the interceptor.
+var methodScopes = map[string]string{
+	txnv1.AccountService_CreateAccount_FullMethodName:          auth.ScopeAccountsWrite,
+	txnv1.AccountService_GetAccount_FullMethodName:             auth.ScopeAccountsRead,
+	txnv1.TransactionService_CreateTransaction_FullMethodName:  auth.ScopeTransactionsWrite,
+	txnv1.TransactionService_GetTransaction_FullMethodName:     auth.ScopeTransactionsRead,
+	txnv1.TransactionService_SearchTransactions_FullMethodName: auth.ScopeTransactionsRead,
+}
+
+// authInterceptor authenticates the bearer token in the "authorization"
+// metadata and checks the scope of the called method. Methods without a scope
+// are denied.
+func authInterceptor(authenticator auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		scope, ok := methodScopes[info.FullMethod]
+		if !ok {
+			return nil, status.Errorf(codes.PermissionDenied, "method %s has no required scope and is closed", info.FullMethod)
+		}
+
+		token, ok := bearerToken(ctx)
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "missing bearer token in the authorization metadata")
+		}
+
+		principal, err := authenticator.Authenticate(ctx, token)
+		if err != nil {
+			if errors.Is(err, auth.ErrUnauthenticated) {
+				return nil, status.Error(codes.Unauthenticated, err.Error())
+			}
+			return nil, err
+		}
+
+		if !principal.HasScope(scope) {
+			return nil, status.Errorf(codes.PermissionDenied, "API key is missing the %s scope", scope)
+		}
+
+		return handler(auth.WithPrincipal(ctx, principal), req)
+	}
+}
+
+func bearerToken(ctx context.Context) (string, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", false
+	}
+
+	scheme, token, found := strings.Cut(values[0], " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,125 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"txn-service/internal/service"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type errorMapping struct {
+	target error
+	code   codes.Code
+}
+
+// errorMappings is checked in order with errors.Is, the first match wins. It
+// follows the HTTP mapping in internal/handlers/errors.go.
+var errorMappings = []errorMapping{
+	{context.DeadlineExceeded, codes.DeadlineExceeded},
+	{context.Canceled, codes.Canceled},
+	{service.ErrInvalidInput, codes.InvalidArgument},
+	{service.ErrForbidden, codes.PermissionDenied},
+	{service.ErrNotFound, codes.NotFound},
+	{service.ErrDuplicate, codes.AlreadyExists},
+	{service.ErrInsufficientFunds, codes.FailedPrecondition},
+	{service.ErrKYCNotVerified, codes.FailedPrecondition},
+	{service.ErrAccountFrozen, codes.FailedPrecondition},
+	{service.ErrTransferLimitExceeded, codes.FailedPrecondition},
+	{service.ErrNotReversible, codes.FailedPrecondition},
+	{service.ErrConflict, codes.Aborted},
+	{service.ErrUnavailable, codes.Unavailable},
+}
+
+const internalErrorMessage = "an internal error occurred"
+
+// toStatus maps a service error to a gRPC status. Validation errors carry a
+// BadRequest detail listing every invalid field; unknown errors are reported
+// as Internal without their text.
+func toStatus(ctx context.Context, err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+
+	// a query aborted by the deadline may surface as a driver error
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return status.FromContextError(ctxErr)
+	}
+
+	var mapping *errorMapping
+	for i := range errorMappings {
+		if errors.Is(err, errorMappings[i].target) {
+			mapping = &errorMappings[i]
+			break
+		}
+	}
+
+	if mapping == nil {
+		return status.New(codes.Internal, internalErrorMessage)
+	}
+
+	st := status.New(mapping.code, publicMessage(err, mapping.target))
+	if violations := fieldViolations(err); len(violations) > 0 {
+		if detailed, detailErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); detailErr == nil {
+			return detailed
+		}
+	}
+
+	return st
+}
+
+// publicMessage returns the message of the domain error in the chain without
+// the wrapping context, like the HTTP problem detail
+func publicMessage(err, target error) string {
+	var validationErrs service.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return validationErrs.Error()
+	}
+
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Error()
+	}
+
+	var notFound *service.NotFoundError
+	if errors.As(err, &notFound) {
+		return notFound.Error()
+	}
+
+	var duplicate *service.DuplicateError
+	if errors.As(err, &duplicate) {
+		return duplicate.Error()
+	}
+
+	return target.Error()
+}
+
+func fieldViolations(err error) []*errdetails.BadRequest_FieldViolation {
+	var validationErrs service.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(validationErrs))
+		for i, validationErr := range validationErrs {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: validationErr.Field, Description: validationErr.Message}
+		}
+		return violations
+	}
+
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) && validationErr.Field != "" {
+		return []*errdetails.BadRequest_FieldViolation{{Field: validationErr.Field, Description: validationErr.Message}}
+	}
+
+	return nil
+}
+
+func isServerError(code codes.Code) bool {
+	switch code {
+	case codes.Internal, codes.Unavailable, codes.Unknown, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
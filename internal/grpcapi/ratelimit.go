@@ -0,0 +1,66 @@
+package grpcapi
+
+import (
+	"context"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/ratelimit"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// rateLimitInterceptor applies the read or write limit of the called method's
+// scope, sharing the buckets of the HTTP API. Rejected calls fail with
+// ResourceExhausted and a RetryInfo detail.
+func rateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		scope, ok := methodScopes[info.FullMethod]
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		result := limiter.Allow(ctx, ratelimit.ClientKey(auth.FromContext(ctx), remoteAddr(ctx)), ratelimit.ClassOf(scope))
+		if !result.Allowed {
+			return nil, rateLimitedError(result)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// ipRateLimitInterceptor applies the limit of the caller's IP address. It runs
+// before authentication, so calls with missing or invalid credentials are
+// throttled before they are looked up.
+func ipRateLimitInterceptor(limiter *ratelimit.Limiter) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, ok := methodScopes[info.FullMethod]; !ok {
+			return handler(ctx, req)
+		}
+
+		if result := limiter.AllowIP(ctx, remoteAddr(ctx)); !result.Allowed {
+			return nil, rateLimitedError(result)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func remoteAddr(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok {
+		return p.Addr.String()
+	}
+	return ""
+}
+
+func rateLimitedError(result ratelimit.Result) error {
+	st := status.New(codes.ResourceExhausted, "rate limit exceeded")
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(result.RetryAfter)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,107 @@
+// Package grpcapi exposes the account and transaction services over gRPC. It
+// calls the same service interfaces as the HTTP handlers.
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/logger"
+	"txn-service/internal/ratelimit"
+	"txn-service/internal/requestid"
+	"txn-service/internal/service"
+	txnv1 "txn-service/proto/txn/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewServer creates a gRPC server with the account and transaction services and
+// server reflection registered. Calls without a deadline get requestTimeout;
+// the deadline is carried in the context down to the repository queries. A
+// nil authenticator disables authentication and a nil limiter rate limiting.
+func NewServer(accountService service.AccountService, transactionService service.TransactionService, authenticator auth.Authenticator, limiter *ratelimit.Limiter, requestTimeout time.Duration, log *logger.Logger) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{
+		requestIDInterceptor,
+		tracingInterceptor,
+		deadlineInterceptor(requestTimeout),
+		errorInterceptor(log),
+	}
+	// the IP limit goes in front of authentication so bad credentials are
+	// throttled too, the limit of the method's class behind it so it applies
+	// per credential
+	if authenticator != nil {
+		if limiter != nil {
+			interceptors = append(interceptors, ipRateLimitInterceptor(limiter))
+		}
+		interceptors = append(interceptors, authInterceptor(authenticator))
+	}
+	if limiter != nil {
+		interceptors = append(interceptors, rateLimitInterceptor(limiter))
+	}
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+
+	txnv1.RegisterAccountServiceServer(server, NewAccountServer(accountService))
+	txnv1.RegisterTransactionServiceServer(server, NewTransactionServer(transactionService))
+	reflection.Register(server)
+
+	return server
+}
+
+// requestIDInterceptor takes the caller's x-request-id metadata, or generates
+// one, returns it in the response header and puts it in the context with the
+// method as route, so every line logged for the call carries both
+func requestIDInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	var id string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestid.Header); len(values) > 0 {
+			id = values[0]
+		}
+	}
+	id = requestid.OrNew(id)
+
+	// the header can only fail to be sent when the stream is gone, in which
+	// case there is nobody to send it to
+	_ = grpc.SetHeader(ctx, metadata.Pairs(requestid.Header, id))
+
+	ctx = requestid.WithID(ctx, id)
+	ctx = logger.ContextWithFields(ctx, map[string]interface{}{
+		"request_id": id,
+		"route":      info.FullMethod,
+	})
+
+	return handler(ctx, req)
+}
+
+// deadlineInterceptor bounds calls that arrive without a client deadline
+func deadlineInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// errorInterceptor converts service errors into gRPC status errors and logs
+// the ones that are the server's fault
+func errorInterceptor(log *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		st := toStatus(ctx, err)
+		if isServerError(st.Code()) {
+			log.FromContext(ctx).Error("gRPC call failed - method: %s, code: %s, error: %v", info.FullMethod, st.Code(), err)
+		}
+
+		return nil, st.Err()
+	}
+}
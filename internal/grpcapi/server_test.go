@@ -0,0 +1,353 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/logger"
+	"txn-service/internal/ratelimit"
+	"txn-service/internal/repository"
+	"txn-service/internal/requestid"
+	"txn-service/internal/service"
+	"txn-service/models"
+	txnv1 "txn-service/proto/txn/v1"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type stubAccountService struct {
+	getAccount func(ctx context.Context, accountID int64) (*models.Account, error)
+}
+
+func (s *stubAccountService) CreateAccount(ctx context.Context, req *models.CreateAccountRequest) (*models.Account, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubAccountService) GetAccount(ctx context.Context, accountID int64) (*models.Account, error) {
+	return s.getAccount(ctx, accountID)
+}
+
+func (s *stubAccountService) FreezeAccount(ctx context.Context, accountID int64) (*models.Account, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (s *stubAccountService) UnfreezeAccount(ctx context.Context, accountID int64) (*models.Account, error) {
+	return nil, errors.New("not implemented")
+}
+
+type stubTransactionService struct {
+	processTransaction func(ctx context.Context, req *models.CreateTransactionRequest) (*models.CreateTransactionSuccessResponse, error)
+}
+
+func (s *stubTransactionService) ProcessTransaction(ctx context.Context, req *models.CreateTransactionRequest) (*models.CreateTransactionSuccessResponse, error) {
+	return s.processTransaction(ctx, req)
+}
+
+func (s *stubTransactionService) GetTransaction(ctx context.Context, transactionID uuid.UUID) (*models.Transaction, error) {
+	return nil, fmt.Errorf("failed to get transaction: %w", &repository.NotFoundError{Resource: repository.ResourceTransaction, ID: transactionID})
+}
+
+func (s *stubTransactionService) SearchTransactions(ctx context.Context, filter *models.TransactionFilter) ([]*models.Transaction, error) {
+	return nil, nil
+}
+
+func (s *stubTransactionService) ReverseTransaction(ctx context.Context, transactionID uuid.UUID, reason string) (*models.Transaction, error) {
+	return nil, errors.New("not implemented")
+}
+
+func dial(t *testing.T, accountService service.AccountService, transactionService service.TransactionService, requestTimeout time.Duration) *grpc.ClientConn {
+	t.Helper()
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(accountService, transactionService, nil, nil, requestTimeout, logger.New("ERROR"))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestGetAccount(t *testing.T) {
+	accounts := &stubAccountService{getAccount: func(ctx context.Context, accountID int64) (*models.Account, error) {
+		if accountID != 123 {
+			return nil, fmt.Errorf("failed to get account: %w", &repository.NotFoundError{Resource: repository.ResourceAccount, ID: accountID})
+		}
+		productCode := "SAVINGS"
+		return &models.Account{AccountID: 123, AccountNumber: "TX06000000000123", Balance: "10.00000000", ProductCode: &productCode}, nil
+	}}
+	client := txnv1.NewAccountServiceClient(dial(t, accounts, &stubTransactionService{}, time.Second))
+
+	resp, err := client.GetAccount(context.Background(), &txnv1.GetAccountRequest{Account: &txnv1.GetAccountRequest_AccountNumber{AccountNumber: "TX06000000000123"}})
+	require.NoError(t, err)
+	assert.Equal(t, int64(123), resp.Account.AccountId)
+	assert.Equal(t, "SAVINGS", resp.Account.ProductCode)
+
+	_, err = client.GetAccount(context.Background(), &txnv1.GetAccountRequest{Account: &txnv1.GetAccountRequest_AccountId{AccountId: 5}})
+	st := status.Convert(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+	assert.Equal(t, "account not found: 5", st.Message())
+
+	_, err = client.GetAccount(context.Background(), &txnv1.GetAccountRequest{Account: &txnv1.GetAccountRequest_AccountNumber{AccountNumber: "TX07000000000123"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestErrorMapping(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"insufficient funds", fmt.Errorf("failed to transfer funds: %w", service.ErrInsufficientFunds), codes.FailedPrecondition},
+		{"kyc", fmt.Errorf("source account not allowed: %w", service.ErrKYCNotVerified), codes.FailedPrecondition},
+		{"transfer limit", fmt.Errorf("failed to transfer funds: %w", service.ErrTransferLimitExceeded), codes.FailedPrecondition},
+		{"duplicate", &repository.DuplicateError{Resource: repository.ResourceAccount, Field: "ID", Value: 1}, codes.AlreadyExists},
+		{"conflict", fmt.Errorf("failed to commit transfer: %w", service.ErrConflict), codes.Aborted},
+		{"unavailable", fmt.Errorf("failed to begin transaction: %w", service.ErrUnavailable), codes.Unavailable},
+		{"unknown", errors.New("pq: syntax error"), codes.Internal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transactions := &stubTransactionService{processTransaction: func(ctx context.Context, req *models.CreateTransactionRequest) (*models.CreateTransactionSuccessResponse, error) {
+				return nil, tt.err
+			}}
+			client := txnv1.NewTransactionServiceClient(dial(t, &stubAccountService{}, transactions, time.Second))
+
+			_, err := client.CreateTransaction(context.Background(), &txnv1.CreateTransactionRequest{})
+			st := status.Convert(err)
+			assert.Equal(t, tt.code, st.Code())
+			if tt.code == codes.Internal {
+				assert.NotContains(t, st.Message(), "pq:", "internal errors must not leak")
+			}
+		})
+	}
+}
+
+func TestValidationErrorDetails(t *testing.T) {
+	transactions := &stubTransactionService{processTransaction: func(ctx context.Context, req *models.CreateTransactionRequest) (*models.CreateTransactionSuccessResponse, error) {
+		return nil, service.ValidationErrors{
+			{Field: "amount", Message: "is required"},
+			{Field: "reference", Message: "must be at most 255 characters long"},
+		}
+	}}
+	client := txnv1.NewTransactionServiceClient(dial(t, &stubAccountService{}, transactions, time.Second))
+
+	_, err := client.CreateTransaction(context.Background(), &txnv1.CreateTransactionRequest{})
+	st := status.Convert(err)
+	require.Equal(t, codes.InvalidArgument, st.Code())
+
+	require.Len(t, st.Details(), 1)
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.Len(t, badRequest.FieldViolations, 2)
+	assert.Equal(t, "amount", badRequest.FieldViolations[0].Field)
+	assert.Equal(t, "reference", badRequest.FieldViolations[1].Field)
+}
+
+func TestDeadlines(t *testing.T) {
+	deadlines := make(chan time.Time, 1)
+	transactions := &stubTransactionService{processTransaction: func(ctx context.Context, req *models.CreateTransactionRequest) (*models.CreateTransactionSuccessResponse, error) {
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok, "service context has no deadline")
+		deadlines <- deadline
+		<-ctx.Done()
+		return nil, fmt.Errorf("failed to transfer funds: %w", ctx.Err())
+	}}
+	client := txnv1.NewTransactionServiceClient(dial(t, &stubAccountService{}, transactions, 50*time.Millisecond))
+
+	// without a client deadline the server default applies
+	start := time.Now()
+	_, err := client.CreateTransaction(context.Background(), &txnv1.CreateTransactionRequest{})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.WithinDuration(t, start.Add(50*time.Millisecond), <-deadlines, 40*time.Millisecond)
+
+	// a client deadline is propagated as is
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	clientDeadline, _ := ctx.Deadline()
+	_, err = client.CreateTransaction(ctx, &txnv1.CreateTransactionRequest{})
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	assert.WithinDuration(t, clientDeadline, <-deadlines, 10*time.Millisecond)
+}
+
+func TestRequestID(t *testing.T) {
+	requestIDs := make(chan string, 1)
+	transactions := &stubTransactionService{processTransaction: func(ctx context.Context, req *models.CreateTransactionRequest) (*models.CreateTransactionSuccessResponse, error) {
+		requestIDs <- requestid.FromContext(ctx)
+		return &models.CreateTransactionSuccessResponse{TransactionID: uuid.New()}, nil
+	}}
+	client := txnv1.NewTransactionServiceClient(dial(t, &stubAccountService{}, transactions, time.Second))
+
+	var header metadata.MD
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-request-id", "req-123")
+	_, err := client.CreateTransaction(ctx, &txnv1.CreateTransactionRequest{}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, "req-123", <-requestIDs)
+	assert.Equal(t, []string{"req-123"}, header.Get("x-request-id"))
+
+	// calls without one get a generated ID, also on errors
+	_, err = client.GetTransaction(context.Background(), &txnv1.GetTransactionRequest{TransactionId: uuid.NewString()}, grpc.Header(&header))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.True(t, requestid.Valid(header.Get("x-request-id")[0]))
+}
+
+func TestReflectionRegistered(t *testing.T) {
+	server := NewServer(&stubAccountService{}, &stubTransactionService{}, nil, nil, time.Second, logger.New("ERROR"))
+
+	services := server.GetServiceInfo()
+	assert.Contains(t, services, "txn.v1.AccountService")
+	assert.Contains(t, services, "txn.v1.TransactionService")
+	assert.Contains(t, services, "grpc.reflection.v1alpha.ServerReflection")
+}
+
+type stubAuthenticator map[string]*auth.Principal
+
+func (s stubAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	principal, ok := s[token]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown API key", auth.ErrUnauthenticated)
+	}
+	return principal, nil
+}
+
+func TestAuthentication(t *testing.T) {
+	keyIDs := make(chan string, 1)
+	transactions := &stubTransactionService{processTransaction: func(ctx context.Context, req *models.CreateTransactionRequest) (*models.CreateTransactionSuccessResponse, error) {
+		keyIDs <- auth.FromContext(ctx).APIKeyID
+		return &models.CreateTransactionSuccessResponse{TransactionID: uuid.New()}, nil
+	}}
+	authenticator := stubAuthenticator{
+		"reader": {APIKeyID: "r", Scopes: []string{auth.ScopeTransactionsRead}},
+		"writer": {APIKeyID: "w", Scopes: []string{auth.ScopeTransactionsWrite}},
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(&stubAccountService{}, transactions, authenticator, nil, time.Second, logger.New("ERROR"))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client := txnv1.NewTransactionServiceClient(conn)
+
+	withToken := func(token string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	}
+
+	_, err = client.CreateTransaction(context.Background(), &txnv1.CreateTransactionRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.CreateTransaction(withToken("nobody"), &txnv1.CreateTransactionRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.CreateTransaction(withToken("reader"), &txnv1.CreateTransactionRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.CreateTransaction(withToken("writer"), &txnv1.CreateTransactionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "w", <-keyIDs)
+}
+
+func TestEveryMethodHasScope(t *testing.T) {
+	for _, desc := range []grpc.ServiceDesc{txnv1.AccountService_ServiceDesc, txnv1.TransactionService_ServiceDesc} {
+		for _, method := range desc.Methods {
+			fullMethod := "/" + desc.ServiceName + "/" + method.MethodName
+			assert.Contains(t, methodScopes, fullMethod, "%s has no entry in methodScopes", fullMethod)
+		}
+	}
+}
+
+func TestUnlistedMethodDenied(t *testing.T) {
+	authenticator := stubAuthenticator{"admin": {APIKeyID: "a", Scopes: []string{auth.ScopeTransactionsRead, auth.ScopeTransactionsWrite}}}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer admin"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/txn.v1.TransactionService/DeleteTransaction"}
+
+	called := false
+	_, err := authInterceptor(authenticator)(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.False(t, called)
+}
+
+func TestRateLimit(t *testing.T) {
+	transactions := &stubTransactionService{processTransaction: func(ctx context.Context, req *models.CreateTransactionRequest) (*models.CreateTransactionSuccessResponse, error) {
+		return &models.CreateTransactionSuccessResponse{TransactionID: uuid.New()}, nil
+	}}
+	limits := ratelimit.Limits{Read: ratelimit.Limit{Rate: 1, Burst: 5}, Write: ratelimit.Limit{Rate: 1, Burst: 1}, IP: ratelimit.Limit{Rate: 1, Burst: 5}}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limits, logger.New("ERROR"))
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(&stubAccountService{}, transactions, nil, limiter, time.Second, logger.New("ERROR"))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client := txnv1.NewTransactionServiceClient(conn)
+
+	_, err = client.CreateTransaction(context.Background(), &txnv1.CreateTransactionRequest{})
+	require.NoError(t, err)
+
+	_, err = client.CreateTransaction(context.Background(), &txnv1.CreateTransactionRequest{})
+	st := status.Convert(err)
+	require.Equal(t, codes.ResourceExhausted, st.Code())
+	require.Len(t, st.Details(), 1)
+	retryInfo, ok := st.Details()[0].(*errdetails.RetryInfo)
+	require.True(t, ok)
+	assert.Positive(t, retryInfo.RetryDelay.AsDuration())
+}
+
+func TestRateLimitBeforeAuthentication(t *testing.T) {
+	limits := ratelimit.Limits{Read: ratelimit.Limit{Rate: 1, Burst: 5}, Write: ratelimit.Limit{Rate: 1, Burst: 5}, IP: ratelimit.Limit{Rate: 0.01, Burst: 1}}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limits, logger.New("ERROR"))
+
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(&stubAccountService{}, &stubTransactionService{}, stubAuthenticator{}, limiter, time.Second, logger.New("ERROR"))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client := txnv1.NewTransactionServiceClient(conn)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer invalid")
+	_, err = client.CreateTransaction(ctx, &txnv1.CreateTransactionRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	_, err = client.CreateTransaction(ctx, &txnv1.CreateTransactionRequest{})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), "invalid credentials are throttled per IP address")
+}
@@ -0,0 +1,72 @@
+package grpcapi
+
+import (
+	"context"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/requestid"
+	"txn-service/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tracingInterceptor starts a server span per call, continuing the trace of
+// the caller's traceparent metadata, and adds its trace ID to the log fields.
+// It runs before errorInterceptor so it sees the status the caller gets.
+func tracingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+
+	ctx, span := tracing.Tracer().Start(ctx, info.FullMethod,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			semconv.RPCSystemKey.String("grpc"),
+			attribute.String("rpc.method", info.FullMethod),
+			tracing.RequestIDKey.String(requestid.FromContext(ctx)),
+		),
+	)
+	defer span.End()
+
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		ctx = logger.ContextWithField(ctx, "trace_id", traceID)
+	}
+
+	resp, err := handler(ctx, req)
+
+	code := status.Code(err)
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+	// like HTTP client errors, only the server's faults fail the span
+	if isServerError(code) {
+		span.SetStatus(codes.Error, err.Error())
+	}
+	return resp, err
+}
+
+// metadataCarrier adapts incoming metadata to the propagators
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+	return keys
+}
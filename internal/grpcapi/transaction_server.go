@@ -0,0 +1,95 @@
+package grpcapi
+
+import (
+	"context"
+
+	"txn-service/internal/service"
+	"txn-service/models"
+	txnv1 "txn-service/proto/txn/v1"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type TransactionServer struct {
+	txnv1.UnimplementedTransactionServiceServer
+	transactionService service.TransactionService
+}
+
+func NewTransactionServer(transactionService service.TransactionService) *TransactionServer {
+	return &TransactionServer{
+		transactionService: transactionService,
+	}
+}
+
+func (s *TransactionServer) CreateTransaction(ctx context.Context, req *txnv1.CreateTransactionRequest) (*txnv1.CreateTransactionResponse, error) {
+	result, err := s.transactionService.ProcessTransaction(ctx, &models.CreateTransactionRequest{
+		SourceAccountID:          req.GetSourceAccountId(),
+		SourceAccountNumber:      req.GetSourceAccountNumber(),
+		DestinationAccountID:     req.GetDestinationAccountId(),
+		DestinationAccountNumber: req.GetDestinationAccountNumber(),
+		Amount:                   req.GetAmount(),
+		Reference:                req.GetReference(),
+		Description:              req.GetDescription(),
+		Metadata:                 req.GetMetadata(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &txnv1.CreateTransactionResponse{TransactionId: result.TransactionID.String()}, nil
+}
+
+func (s *TransactionServer) GetTransaction(ctx context.Context, req *txnv1.GetTransactionRequest) (*txnv1.GetTransactionResponse, error) {
+	transactionID, err := uuid.Parse(req.GetTransactionId())
+	if err != nil {
+		return nil, &service.ValidationError{Field: "transaction_id", Message: "must be a UUID"}
+	}
+
+	transaction, err := s.transactionService.GetTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &txnv1.GetTransactionResponse{Transaction: toTransactionProto(transaction)}, nil
+}
+
+func (s *TransactionServer) SearchTransactions(ctx context.Context, req *txnv1.SearchTransactionsRequest) (*txnv1.SearchTransactionsResponse, error) {
+	if req.GetLimit() < 0 {
+		return nil, &service.ValidationError{Field: "limit", Message: "must be greater than or equal to 0"}
+	}
+
+	transactions, err := s.transactionService.SearchTransactions(ctx, &models.TransactionFilter{
+		Reference: req.GetReference(),
+		Metadata:  req.GetMetadata(),
+		Limit:     int(req.GetLimit()),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &txnv1.SearchTransactionsResponse{
+		Transactions: make([]*txnv1.Transaction, len(transactions)),
+	}
+	for i, transaction := range transactions {
+		resp.Transactions[i] = toTransactionProto(transaction)
+	}
+
+	return resp, nil
+}
+
+func toTransactionProto(transaction *models.Transaction) *txnv1.Transaction {
+	return &txnv1.Transaction{
+		TransactionId:        transaction.TransactionID.String(),
+		SourceAccountId:      transaction.SourceAccountID,
+		DestinationAccountId: transaction.DestinationAccountID,
+		Amount:               transaction.Amount,
+		Status:               transaction.Status,
+		Reference:            transaction.Reference,
+		Description:          transaction.Description,
+		Metadata:             transaction.Metadata,
+		CreatedAt:            timestamppb.New(transaction.CreatedAt),
+		UpdatedAt:            timestamppb.New(transaction.UpdatedAt),
+		ApiKeyId:             transaction.APIKeyID,
+	}
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/settings"
+
+	"github.com/gorilla/mux"
+)
+
+// AccessLogMiddleware logs one line per request with its status, size,
+// latency and client IP. Requests slower than the slow threshold are logged
+// at WARN. A nil *AccessLogMiddleware logs nothing.
+type AccessLogMiddleware struct {
+	logger   *logger.Logger
+	settings *settings.Store
+}
+
+// NewAccessLogMiddleware creates the middleware. The slow threshold is read
+// from runtime for every request; a zero one logs every request at INFO.
+func NewAccessLogMiddleware(log *logger.Logger, runtime *settings.Store) *AccessLogMiddleware {
+	return &AccessLogMiddleware{
+		logger:   log,
+		settings: runtime,
+	}
+}
+
+// Handler wraps next with the access log. It runs after RequestIDMiddleware,
+// whose request ID, method and route fields are on every line.
+func (m *AccessLogMiddleware) Handler(next http.Handler) http.Handler {
+	if m == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := recordResponse(w)
+
+		next.ServeHTTP(recorder, r)
+
+		latency := time.Since(start)
+		entry := m.logger.FromContext(r.Context()).WithFields(map[string]interface{}{
+			"status":      recorder.status(),
+			"bytes":       recorder.bytes,
+			"duration_ms": float64(latency.Microseconds()) / 1000,
+			"client_ip":   clientIP(r),
+		})
+
+		if slowThreshold := m.settings.Current().SlowRequestThreshold; slowThreshold > 0 && latency >= slowThreshold {
+			entry.Warn("Slow request - %s %s took %s", r.Method, r.URL.Path, latency)
+			return
+		}
+		entry.Info("Request completed - %s %s %d", r.Method, r.URL.Path, recorder.status())
+	})
+}
+
+// RecoveryMiddleware turns a panicking handler into a 500 problem response
+// and logs the panic with its stack to log, instead of dropping the connection
+func RecoveryMiddleware(log *logger.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			recorder := recordResponse(w)
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// the server uses ErrAbortHandler to abort a response on purpose
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				log.FromContext(r.Context()).WithFields(map[string]interface{}{
+					"panic": fmt.Sprint(recovered),
+					"stack": string(debug.Stack()),
+				}).Error("Handler panicked - method: %s, path: %s", r.Method, r.URL.Path)
+
+				// once the status is sent the response can only be cut short
+				if recorder.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+				sendJSONError(recorder, r, CodeInternalError, internalErrorDetail, http.StatusInternalServerError)
+			}()
+
+			next.ServeHTTP(recorder, r)
+		})
+	}
+}
+
+// responseRecorder records the status and size of a response on its way out
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	bytes       int64
+}
+
+// recordResponse wraps w, reusing the recorder when w already is one so the
+// middlewares share a single view of the response
+func recordResponse(w http.ResponseWriter) *responseRecorder {
+	if recorder, ok := w.(*responseRecorder); ok {
+		return recorder
+	}
+	return &responseRecorder{ResponseWriter: w}
+}
+
+func (r *responseRecorder) WriteHeader(statusCode int) {
+	if !r.wroteHeader {
+		r.statusCode = statusCode
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	n, err := r.ResponseWriter.Write(p)
+	r.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// status is the status sent, 200 when the handler wrote nothing at all
+func (r *responseRecorder) status() int {
+	if !r.wroteHeader {
+		return http.StatusOK
+	}
+	return r.statusCode
+}
+
+// clientIP is the address of the connection; X-Forwarded-For is not trusted
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
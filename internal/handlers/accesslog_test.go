@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/settings"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// logLines decodes the JSON lines written to out
+func logLines(t *testing.T, out *bytes.Buffer) []map[string]interface{} {
+	var lines []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &decoded), line)
+		lines = append(lines, decoded)
+	}
+	return lines
+}
+
+func TestAccessLogAndRecovery(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New("INFO")
+	log.SetFormat(logger.FormatJSON)
+	log.SetOutput(&out)
+
+	router := mux.NewRouter()
+	router.Use(RequestIDMiddleware, NewAccessLogMiddleware(log, settings.NewStore(&settings.Settings{SlowRequestThreshold: 50 * time.Millisecond})).Handler, RecoveryMiddleware(log))
+	router.HandleFunc("/accounts/{account_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"account_id":1}`))
+	})
+	router.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(60 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	router.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		var accounts []string
+		_ = accounts[1]
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+	req.RemoteAddr = "192.0.2.1:5555"
+	req.Header.Set("X-Request-ID", "req-1")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := logLines(t, &out)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "INFO", lines[0]["level"])
+	assert.Equal(t, "req-1", lines[0]["request_id"])
+	assert.Equal(t, "GET", lines[0]["method"])
+	assert.Equal(t, "/accounts/{account_id}", lines[0]["route"])
+	assert.Equal(t, float64(200), lines[0]["status"])
+	assert.Equal(t, float64(len(`{"account_id":1}`)), lines[0]["bytes"])
+	assert.Equal(t, "192.0.2.1", lines[0]["client_ip"])
+	assert.Contains(t, lines[0], "duration_ms")
+
+	out.Reset()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	lines = logLines(t, &out)
+	require.Len(t, lines, 1)
+	assert.Equal(t, "WARN", lines[0]["level"])
+	assert.Equal(t, float64(http.StatusNoContent), lines[0]["status"])
+
+	out.Reset()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	var problem Problem
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, CodeInternalError, problem.Code)
+	assert.Equal(t, rec.Header().Get("X-Request-ID"), problem.Instance)
+
+	lines = logLines(t, &out)
+	require.Len(t, lines, 2)
+	assert.Equal(t, "ERROR", lines[0]["level"])
+	assert.Contains(t, lines[0]["panic"], "index out of range")
+	assert.Contains(t, lines[0]["stack"], "accesslog_test.go")
+	assert.Equal(t, float64(http.StatusInternalServerError), lines[1]["status"])
+}
+
+func TestAccessLogUnmatchedRoutes(t *testing.T) {
+	var out bytes.Buffer
+	log := logger.New("INFO")
+	log.SetFormat(logger.FormatJSON)
+	log.SetOutput(&out)
+
+	accessLog := NewAccessLogMiddleware(log, settings.NewStore(&settings.Settings{SlowRequestThreshold: time.Second}))
+	router := SetupRoutes(&AccountHandler{}, &TransactionHandler{}, &ProductHandler{}, &CustomerHandler{}, &HealthHandler{}, &AdminHandler{}, nil, nil, accessLog, log)
+
+	for _, tt := range []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodGet, "/nowhere", http.StatusNotFound},
+		{http.MethodDelete, "/livez", http.StatusMethodNotAllowed},
+	} {
+		out.Reset()
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		assert.Equal(t, tt.status, rec.Code)
+		assert.NotEmpty(t, rec.Header().Get("X-Request-ID"))
+		lines := logLines(t, &out)
+		require.Len(t, lines, 1)
+		assert.Equal(t, float64(tt.status), lines[0]["status"])
+		assert.Equal(t, "unknown", lines[0]["route"])
+	}
+}
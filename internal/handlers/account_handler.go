@@ -2,84 +2,83 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
 
+	"txn-service/internal/accountnumber"
+	"txn-service/internal/logger"
 	"txn-service/internal/service"
+	"txn-service/internal/settings"
 	"txn-service/models"
 
 	"github.com/gorilla/mux"
 )
 
 type AccountHandler struct {
+	problemWriter
 	accountService service.AccountService
 }
 
-func NewAccountHandler(accountService service.AccountService) *AccountHandler {
+func NewAccountHandler(accountService service.AccountService, runtime *settings.Store, log *logger.Logger) *AccountHandler {
 	return &AccountHandler{
+		problemWriter:  newProblemWriter(runtime, log),
 		accountService: accountService,
 	}
 }
 
 func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateAccountRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendJSONError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest)
+	if !h.decodeRequest(w, r, &req) {
 		return
 	}
 
-	if req.AccountID <= 0 {
-		sendJSONError(w, "INVALID_ACCOUNT_ID", "account_id must be a positive integer", http.StatusBadRequest)
-		return
-	}
-
-	if req.InitialBalance == "" {
-		sendJSONError(w, "MISSING_BALANCE", "initial_balance is required", http.StatusBadRequest)
-		return
-	}
-
-	if err := h.accountService.CreateAccount(r.Context(), &req); err != nil {
-
-		if isDuplicateAccountError(err) {
-			sendJSONError(w, "ACCOUNT_ALREADY_EXISTS", err.Error(), http.StatusConflict)
-			return
-		}
-		sendJSONError(w, "CREATE_ACCOUNT_FAILED", err.Error(), http.StatusBadRequest)
+	account, err := h.accountService.CreateAccount(r.Context(), &req)
+	if err != nil {
+		h.sendServiceError(w, r, err)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-}
-
-func isDuplicateAccountError(err error) bool {
-	if err == nil {
-		return false
-	}
-
-	errMsg := err.Error()
-	return len(errMsg) > 0 && errMsg[:25] == "account with ID"
+	json.NewEncoder(w).Encode(account)
 }
 
 func (h *AccountHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
 
 	accountIDStr := mux.Vars(r)["account_id"]
 	if accountIDStr == "" {
-		sendJSONError(w, "MISSING_ACCOUNT_ID", "account_id parameter is required", http.StatusBadRequest)
+		sendFieldError(w, r, "MISSING_ACCOUNT_ID", "account_id", "account_id parameter is required")
 		return
 	}
 
-	accountID, err := strconv.ParseInt(accountIDStr, 10, 64)
+	accountID, err := parseAccountReference(accountIDStr)
 	if err != nil {
-		sendJSONError(w, "INVALID_ACCOUNT_ID_FORMAT", "Invalid account_id format", http.StatusBadRequest)
+		sendFieldError(w, r, "INVALID_ACCOUNT_ID_FORMAT", "account_id", err.Error())
 		return
 	}
 
 	account, err := h.accountService.GetAccount(r.Context(), accountID)
 	if err != nil {
-		sendJSONError(w, "ACCOUNT_NOT_FOUND", err.Error(), http.StatusNotFound)
+		h.sendServiceError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(account)
 }
+
+// parseAccountReference accepts either a numeric account ID or an account number
+// and returns the account ID, rejecting account numbers with wrong check digits
+func parseAccountReference(reference string) (int64, error) {
+	if accountnumber.IsAccountNumber(reference) {
+		return accountnumber.Parse(reference)
+	}
+
+	accountID, err := strconv.ParseInt(reference, 10, 64)
+	if err != nil || accountID <= 0 {
+		return 0, fmt.Errorf("Invalid account_id format")
+	}
+
+	return accountID, nil
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/config"
+	"txn-service/internal/logger"
+	"txn-service/internal/settings"
+)
+
+// ConfigReloader reloads the configuration of the running service
+type ConfigReloader interface {
+	Reload() (*config.ReloadResult, error)
+}
+
+// AdminHandler serves the operator endpoints, which act on the whole service
+// and are only open to operators: unrestricted callers of the default tenant
+type AdminHandler struct {
+	problemWriter
+	reloader ConfigReloader
+}
+
+func NewAdminHandler(reloader ConfigReloader, runtime *settings.Store, log *logger.Logger) *AdminHandler {
+	return &AdminHandler{
+		problemWriter: newProblemWriter(runtime, log),
+		reloader:      reloader,
+	}
+}
+
+// ReloadConfig reloads the configuration and answers with what changed. An
+// invalid configuration is rejected with 422 and every problem in the detail.
+func (h *AdminHandler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if err := auth.CheckOperator(r.Context()); err != nil {
+		h.sendServiceError(w, r, err)
+		return
+	}
+
+	result, err := h.reloader.Reload()
+	var configErr *config.Error
+	if errors.As(err, &configErr) {
+		sendJSONError(w, r, CodeInvalidConfiguration, configErr.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		h.sendServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/logger"
+	"txn-service/internal/settings"
+	"txn-service/internal/validation"
+)
+
+// AuthMiddleware authenticates requests with a bearer token or an HMAC
+// signature and checks that the caller was granted the scope a route requires.
+// A nil *AuthMiddleware disables authentication and lets every request through.
+type AuthMiddleware struct {
+	problemWriter
+	authenticator auth.Authenticator
+	signatures    *auth.SignatureVerifier
+}
+
+// NewAuthMiddleware creates the middleware. signatures may be nil, in which
+// case signed requests are rejected.
+func NewAuthMiddleware(authenticator auth.Authenticator, signatures *auth.SignatureVerifier, runtime *settings.Store, log *logger.Logger) *AuthMiddleware {
+	return &AuthMiddleware{
+		problemWriter: newProblemWriter(runtime, log),
+		authenticator: authenticator,
+		signatures:    signatures,
+	}
+}
+
+// Require wraps next so that it only runs for callers holding scope. The
+// principal is stored in the request context for the services.
+func (m *AuthMiddleware) Require(scope string, next http.HandlerFunc) http.HandlerFunc {
+	if m == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, err := m.authenticate(r)
+		if err != nil {
+			if errors.Is(err, validation.ErrBodyTooLarge) {
+				sendJSONError(w, r, CodePayloadTooLarge, err.Error(), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if errors.Is(err, auth.ErrUnauthenticated) {
+				sendUnauthenticated(w, r, err.Error())
+				return
+			}
+			m.sendServiceError(w, r, err)
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+			sendJSONError(w, r, CodeForbidden, "credentials are missing the "+scope+" scope", http.StatusForbidden)
+			return
+		}
+
+		next(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	}
+}
+
+func (m *AuthMiddleware) authenticate(r *http.Request) (*auth.Principal, error) {
+	scheme, credentials, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+
+	switch {
+	case strings.EqualFold(scheme, "Bearer"):
+		token := strings.TrimSpace(credentials)
+		if token == "" {
+			return nil, fmt.Errorf("%w: empty bearer token", auth.ErrUnauthenticated)
+		}
+		return m.authenticator.Authenticate(r.Context(), token)
+
+	case strings.EqualFold(scheme, auth.SignatureScheme) && m.signatures != nil:
+		body, err := readBody(r)
+		if err != nil {
+			return nil, err
+		}
+		return m.signatures.Verify(credentials, r.Method, r.URL.RequestURI(), body)
+
+	default:
+		return nil, fmt.Errorf("%w: missing bearer token or signature in the Authorization header", auth.ErrUnauthenticated)
+	}
+}
+
+// readBody reads the body a signature covers and puts it back for the handler
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, validation.MaxBodySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	if len(body) > validation.MaxBodySize {
+		return nil, validation.ErrBodyTooLarge
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func sendUnauthenticated(w http.ResponseWriter, r *http.Request, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="txn-service"`)
+	sendJSONError(w, r, CodeUnauthenticated, message, http.StatusUnauthorized)
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/logger"
+	"txn-service/internal/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stubAuthenticator map[string]*auth.Principal
+
+func (s stubAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	principal, ok := s[token]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown API key", auth.ErrUnauthenticated)
+	}
+	return principal, nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	middleware := NewAuthMiddleware(stubAuthenticator{
+		"reader": {APIKeyID: "r", Scopes: []string{auth.ScopeAccountsRead}},
+		"writer": {APIKeyID: "w", Scopes: []string{auth.ScopeAccountsRead, auth.ScopeAccountsWrite}},
+	}, nil, nil, logger.New("ERROR"))
+
+	handler := middleware.Require(auth.ScopeAccountsWrite, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(auth.FromContext(r.Context()).APIKeyID))
+	})
+
+	tests := []struct {
+		name          string
+		authorization string
+		statusCode    int
+		errorCode     string
+	}{
+		{"missing header", "", http.StatusUnauthorized, CodeUnauthenticated},
+		{"wrong scheme", "Basic writer", http.StatusUnauthorized, CodeUnauthenticated},
+		{"unknown key", "Bearer nobody", http.StatusUnauthorized, CodeUnauthenticated},
+		{"missing scope", "Bearer reader", http.StatusForbidden, CodeForbidden},
+		{"granted", "Bearer writer", http.StatusOK, ""},
+		{"scheme is case insensitive", "bearer writer", http.StatusOK, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/accounts", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+
+			handler(rec, req)
+
+			require.Equal(t, tt.statusCode, rec.Code)
+			if tt.statusCode == http.StatusOK {
+				assert.Equal(t, "w", rec.Body.String())
+				return
+			}
+
+			assert.Contains(t, rec.Header().Get("WWW-Authenticate"), "Bearer")
+			var problem Problem
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, tt.errorCode, problem.Code)
+		})
+	}
+}
+
+func TestNilAuthMiddlewarePassesThrough(t *testing.T) {
+	var middleware *AuthMiddleware
+	handler := middleware.Require(auth.ScopeAccountsWrite, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodPost, "/accounts", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+}
+
+func TestAuthMiddlewareSignedRequests(t *testing.T) {
+	secret := "0123456789abcdef0123456789abcdef"
+	signatures := auth.NewSignatureVerifier([]*auth.SigningClient{{ID: "partner", Secret: secret, Scopes: []string{auth.ScopeTransactionsWrite}}}, time.Minute)
+	middleware := NewAuthMiddleware(stubAuthenticator{}, signatures, nil, logger.New("ERROR"))
+
+	handler := middleware.Require(auth.ScopeTransactionsWrite, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+
+	body := `{"amount": "1.00"}`
+	req := httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(body))
+	require.NoError(t, auth.SignRequest(req, "partner", secret, time.Now()))
+	replay := req.Header.Get("Authorization")
+
+	rec := httptest.NewRecorder()
+	handler(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, body, rec.Body.String(), "the handler reads the signed body")
+
+	req = httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(body))
+	req.Header.Set("Authorization", replay)
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code, "replayed requests are rejected")
+
+	req = httptest.NewRequest(http.MethodPost, "/transactions", strings.NewReader(strings.Repeat("x", validation.MaxBodySize+1)))
+	require.NoError(t, auth.SignRequest(req, "partner", secret, time.Now()))
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
@@ -2,22 +2,136 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/requestid"
+	"txn-service/internal/settings"
+	"txn-service/internal/validation"
 )
 
-type ErrorResponse struct {
-	Error   string `json:"error"`
+const problemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details response. Code is an extension member
+// carrying the stable machine-readable error code from the error catalog.
+type Problem struct {
+	Type     string        `json:"type"`
+	Title    string        `json:"title"`
+	Status   int           `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Instance string        `json:"instance,omitempty"`
+	Code     string        `json:"code"`
+	Errors   []*FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a single invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
 }
 
-func sendJSONError(w http.ResponseWriter, errorCode, message string, statusCode int) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
+// problemWriter writes the error responses of a handler and logs the server
+// errors among them. Debug mode, read from settings, exposes internal error
+// text in problem details. It must stay off in production, where only the
+// messages of known domain errors are returned.
+type problemWriter struct {
+	settings *settings.Store
+	logger   *logger.Logger
+}
+
+func newProblemWriter(runtime *settings.Store, log *logger.Logger) problemWriter {
+	return problemWriter{settings: runtime, logger: log}
+}
+
+func (p problemWriter) debugMode() bool {
+	return p.settings != nil && p.settings.Current().DebugMode
+}
+
+func sendProblem(w http.ResponseWriter, r *http.Request, problem *Problem) {
+	if problem.Type == "" {
+		problem.Type = problemType(problem.Code)
+	}
+	if problem.Title == "" {
+		problem.Title = problemTitle(problem.Code, problem.Status)
+	}
+	problem.Instance = requestID(w, r)
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+
+	json.NewEncoder(w).Encode(problem)
+}
+
+func sendJSONError(w http.ResponseWriter, r *http.Request, errorCode, message string, statusCode int) {
+	sendProblem(w, r, &Problem{
+		Status: statusCode,
+		Detail: message,
+		Code:   errorCode,
+	})
+}
+
+// sendFieldError reports a single invalid request field with status 400
+func sendFieldError(w http.ResponseWriter, r *http.Request, errorCode, field, message string) {
+	sendProblem(w, r, &Problem{
+		Status: http.StatusBadRequest,
+		Detail: message,
+		Code:   errorCode,
+		Errors: []*FieldError{{Field: field, Message: message}},
+	})
+}
+
+// decodeRequest decodes the JSON body into dst and writes the error response when
+// it cannot: 413 for oversized bodies, a field error for unknown or mistyped
+// fields and INVALID_REQUEST for anything else. Field values are validated by
+// the services.
+func (p problemWriter) decodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	err := validation.DecodeJSON(w, r, dst)
+	if err == nil {
+		return true
+	}
+
+	var fieldErr *validation.FieldError
+	switch {
+	case errors.Is(err, validation.ErrBodyTooLarge):
+		sendJSONError(w, r, CodePayloadTooLarge, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.As(err, &fieldErr):
+		p.sendServiceError(w, r, err)
+	default:
+		sendJSONError(w, r, CodeInvalidRequest, err.Error(), http.StatusBadRequest)
+	}
+
+	return false
+}
+
+// requestID returns the ID RequestIDMiddleware gave the request. Requests it
+// did not see, like unknown routes, get the caller supplied X-Request-ID or a
+// generated one, echoed back so the problem instance can be matched with
+// server logs.
+func requestID(w http.ResponseWriter, r *http.Request) string {
+	if id := requestid.FromContext(r.Context()); id != "" {
+		return id
+	}
 
-	response := ErrorResponse{
-		Error:   errorCode,
-		Message: message,
+	id := w.Header().Get(requestid.Header)
+	if id == "" {
+		id = requestid.OrNew(r.Header.Get(requestid.Header))
 	}
 
-	json.NewEncoder(w).Encode(response)
+	w.Header().Set(requestid.Header, id)
+	return id
+}
+
+// problemType builds the problem type URI reference from the error code,
+// e.g. INSUFFICIENT_FUNDS becomes /problems/insufficient-funds
+func problemType(errorCode string) string {
+	return "/problems/" + strings.ReplaceAll(strings.ToLower(errorCode), "_", "-")
+}
+
+func problemTitle(errorCode string, statusCode int) string {
+	if title, ok := problemTitles[errorCode]; ok {
+		return title
+	}
+	return http.StatusText(statusCode)
 }
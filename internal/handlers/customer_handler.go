@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/service"
+	"txn-service/internal/settings"
+	"txn-service/models"
+
+	"github.com/gorilla/mux"
+)
+
+type CustomerHandler struct {
+	problemWriter
+	customerService service.CustomerService
+}
+
+func NewCustomerHandler(customerService service.CustomerService, runtime *settings.Store, log *logger.Logger) *CustomerHandler {
+	return &CustomerHandler{
+		problemWriter:   newProblemWriter(runtime, log),
+		customerService: customerService,
+	}
+}
+
+func (h *CustomerHandler) CreateCustomer(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateCustomerRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	customer, err := h.customerService.CreateCustomer(r.Context(), &req)
+	if err != nil {
+		h.sendServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(customer)
+}
+
+func (h *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := parseCustomerID(w, r)
+	if !ok {
+		return
+	}
+
+	customer, err := h.customerService.GetCustomer(r.Context(), customerID)
+	if err != nil {
+		h.sendServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(customer)
+}
+
+func (h *CustomerHandler) UpdateKYCStatus(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := parseCustomerID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateKYCStatusRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.customerService.UpdateKYCStatus(r.Context(), customerID, req.KYCStatus); err != nil {
+		h.sendServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CustomerHandler) GetCustomerAccounts(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := parseCustomerID(w, r)
+	if !ok {
+		return
+	}
+
+	accounts, err := h.customerService.GetCustomerAccounts(r.Context(), customerID)
+	if err != nil {
+		h.sendServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accounts)
+}
+
+func parseCustomerID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	customerIDStr := mux.Vars(r)["customer_id"]
+	if customerIDStr == "" {
+		sendFieldError(w, r, "MISSING_CUSTOMER_ID", "customer_id", "customer_id parameter is required")
+		return 0, false
+	}
+
+	customerID, err := strconv.ParseInt(customerIDStr, 10, 64)
+	if err != nil {
+		sendFieldError(w, r, "INVALID_CUSTOMER_ID_FORMAT", "customer_id", "Invalid customer_id format")
+		return 0, false
+	}
+
+	return customerID, true
+}
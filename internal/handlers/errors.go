@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"txn-service/internal/service"
+)
+
+// Stable machine-readable error codes returned in the "code" field. Clients may
+// branch on these, so existing values must never change meaning. The full
+// catalog is documented in the README.
+const (
+	CodeInvalidRequest       = "INVALID_REQUEST"
+	CodePayloadTooLarge      = "PAYLOAD_TOO_LARGE"
+	CodeUnauthenticated      = "UNAUTHENTICATED"
+	CodeForbidden            = "FORBIDDEN"
+	CodeRateLimited          = "RATE_LIMITED"
+	CodeValidationFailed     = "VALIDATION_FAILED"
+	CodeNotFound             = "NOT_FOUND"
+	CodeAlreadyExists        = "ALREADY_EXISTS"
+	CodeInsufficientFunds    = "INSUFFICIENT_FUNDS"
+	CodeKYCNotVerified       = "KYC_NOT_VERIFIED"
+	CodeAccountFrozen        = "ACCOUNT_FROZEN"
+	CodeTransferLimit        = "TRANSFER_LIMIT_EXCEEDED"
+	CodeNotReversible        = "NOT_REVERSIBLE"
+	CodeInvalidConfiguration = "INVALID_CONFIGURATION"
+	CodeConflict             = "CONFLICT"
+	CodeServiceUnavailable   = "SERVICE_UNAVAILABLE"
+	CodeInternalError        = "INTERNAL_ERROR"
+)
+
+type errorMapping struct {
+	target     error
+	statusCode int
+	errorCode  string
+}
+
+// errorMappings is checked in order with errors.Is, the first match wins
+var errorMappings = []errorMapping{
+	{service.ErrInvalidInput, http.StatusBadRequest, CodeValidationFailed},
+	{service.ErrForbidden, http.StatusForbidden, CodeForbidden},
+	{service.ErrNotFound, http.StatusNotFound, CodeNotFound},
+	{service.ErrDuplicate, http.StatusConflict, CodeAlreadyExists},
+	{service.ErrInsufficientFunds, http.StatusUnprocessableEntity, CodeInsufficientFunds},
+	{service.ErrKYCNotVerified, http.StatusUnprocessableEntity, CodeKYCNotVerified},
+	{service.ErrAccountFrozen, http.StatusUnprocessableEntity, CodeAccountFrozen},
+	{service.ErrTransferLimitExceeded, http.StatusUnprocessableEntity, CodeTransferLimit},
+	{service.ErrNotReversible, http.StatusConflict, CodeNotReversible},
+	{service.ErrConflict, http.StatusConflict, CodeConflict},
+	{service.ErrUnavailable, http.StatusServiceUnavailable, CodeServiceUnavailable},
+}
+
+// mapServiceError returns the HTTP status and error code for an error returned by
+// a service. Not found and duplicate errors carry the resource in the code, e.g.
+// ACCOUNT_NOT_FOUND or ACCOUNT_ALREADY_EXISTS.
+func mapServiceError(err error) (int, string) {
+	var notFound *service.NotFoundError
+	if errors.As(err, &notFound) {
+		return http.StatusNotFound, resourceCode(notFound.Resource, CodeNotFound)
+	}
+
+	var duplicate *service.DuplicateError
+	if errors.As(err, &duplicate) {
+		return http.StatusConflict, resourceCode(duplicate.Resource, CodeAlreadyExists)
+	}
+
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.target) {
+			return mapping.statusCode, mapping.errorCode
+		}
+	}
+
+	return http.StatusInternalServerError, CodeInternalError
+}
+
+func resourceCode(resource, code string) string {
+	return strings.ToUpper(resource) + "_" + code
+}
+
+var problemTitles = map[string]string{
+	CodeInvalidRequest:       "Invalid request body",
+	CodePayloadTooLarge:      "Request body too large",
+	CodeUnauthenticated:      "Authentication required",
+	CodeForbidden:            "Access denied",
+	CodeRateLimited:          "Too many requests",
+	CodeValidationFailed:     "Request validation failed",
+	CodeNotFound:             "Resource not found",
+	CodeAlreadyExists:        "Resource already exists",
+	CodeInsufficientFunds:    "Insufficient funds",
+	CodeKYCNotVerified:       "Account owner not KYC verified",
+	CodeAccountFrozen:        "Account frozen",
+	CodeTransferLimit:        "Transfer limit exceeded",
+	CodeNotReversible:        "Transaction not reversible",
+	CodeInvalidConfiguration: "Invalid configuration",
+	CodeConflict:             "Concurrent update conflict",
+	CodeServiceUnavailable:   "Service unavailable",
+	CodeInternalError:        "Internal server error",
+}
+
+const internalErrorDetail = "An internal error occurred, quote the instance when contacting support"
+
+func (p problemWriter) sendServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	statusCode, errorCode := mapServiceError(err)
+
+	problem := &Problem{
+		Status: statusCode,
+		Title:  problemTitle(genericCode(errorCode), statusCode),
+		Code:   errorCode,
+		Detail: publicDetail(err, statusCode, p.debugMode()),
+	}
+
+	problem.Errors = fieldErrors(err)
+
+	if statusCode >= http.StatusInternalServerError {
+		p.logger.FromContext(r.Context()).Error("Request failed - method: %s, path: %s, status: %d, error: %v", r.Method, r.URL.Path, statusCode, err)
+	}
+
+	sendProblem(w, r, problem)
+}
+
+// fieldErrors lists the invalid fields reported by a validation error
+func fieldErrors(err error) []*FieldError {
+	var validationErrs service.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make([]*FieldError, len(validationErrs))
+		for i, validationErr := range validationErrs {
+			fields[i] = &FieldError{Field: validationErr.Field, Message: validationErr.Message}
+		}
+		return fields
+	}
+
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) && validationErr.Field != "" {
+		return []*FieldError{{Field: validationErr.Field, Message: validationErr.Message}}
+	}
+
+	return nil
+}
+
+// publicDetail returns the message of the domain error in the chain without the
+// wrapping context, and hides errors that are not part of the domain (driver
+// errors, bugs) entirely unless debug is set
+func publicDetail(err error, statusCode int, debug bool) string {
+	if debug {
+		return err.Error()
+	}
+
+	var validationErrs service.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return validationErrs.Error()
+	}
+
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) {
+		return validationErr.Error()
+	}
+
+	var notFound *service.NotFoundError
+	if errors.As(err, &notFound) {
+		return notFound.Error()
+	}
+
+	var duplicate *service.DuplicateError
+	if errors.As(err, &duplicate) {
+		return duplicate.Error()
+	}
+
+	if statusCode >= http.StatusInternalServerError {
+		return internalErrorDetail
+	}
+
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.target) {
+			return mapping.target.Error()
+		}
+	}
+
+	return internalErrorDetail
+}
+
+// genericCode strips the resource prefix from resource specific codes so
+// ACCOUNT_NOT_FOUND shares the title of NOT_FOUND
+func genericCode(errorCode string) string {
+	for _, code := range []string{CodeNotFound, CodeAlreadyExists} {
+		if strings.HasSuffix(errorCode, "_"+code) {
+			return code
+		}
+	}
+	return errorCode
+}
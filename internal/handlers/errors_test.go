@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"txn-service/internal/repository"
+	"txn-service/internal/service"
+	"txn-service/internal/settings"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapServiceError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		statusCode int
+		errorCode  string
+	}{
+		{
+			name:       "validation",
+			err:        fmt.Errorf("invalid transaction request: %w", &service.ValidationError{Field: "amount", Message: "amount must be greater than zero"}),
+			statusCode: http.StatusBadRequest,
+			errorCode:  CodeValidationFailed,
+		},
+		{
+			name:       "account not found",
+			err:        fmt.Errorf("failed to transfer funds: %w", &repository.NotFoundError{Resource: repository.ResourceAccount, ID: 5}),
+			statusCode: http.StatusNotFound,
+			errorCode:  "ACCOUNT_NOT_FOUND",
+		},
+		{
+			name:       "duplicate account",
+			err:        fmt.Errorf("failed to create account: %w", &repository.DuplicateError{Resource: repository.ResourceAccount, Field: "ID", Value: 5}),
+			statusCode: http.StatusConflict,
+			errorCode:  "ACCOUNT_ALREADY_EXISTS",
+		},
+		{
+			name:       "insufficient funds",
+			err:        fmt.Errorf("failed to transfer funds: %w", repository.ErrInsufficientFunds),
+			statusCode: http.StatusUnprocessableEntity,
+			errorCode:  CodeInsufficientFunds,
+		},
+		{
+			name:       "kyc",
+			err:        fmt.Errorf("source account not allowed: %w", service.ErrKYCNotVerified),
+			statusCode: http.StatusUnprocessableEntity,
+			errorCode:  CodeKYCNotVerified,
+		},
+		{
+			name:       "transfer limit",
+			err:        fmt.Errorf("failed to transfer funds: %w", repository.ErrTransferLimitExceeded),
+			statusCode: http.StatusUnprocessableEntity,
+			errorCode:  CodeTransferLimit,
+		},
+		{
+			name:       "conflict",
+			err:        fmt.Errorf("failed to commit transfer: %w", repository.ErrConflict),
+			statusCode: http.StatusConflict,
+			errorCode:  CodeConflict,
+		},
+		{
+			name:       "database down",
+			err:        fmt.Errorf("failed to begin transaction: %w", repository.ErrUnavailable),
+			statusCode: http.StatusServiceUnavailable,
+			errorCode:  CodeServiceUnavailable,
+		},
+		{
+			name:       "unknown",
+			err:        errors.New("boom"),
+			statusCode: http.StatusInternalServerError,
+			errorCode:  CodeInternalError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statusCode, errorCode := mapServiceError(tt.err)
+			assert.Equal(t, tt.statusCode, statusCode)
+			assert.Equal(t, tt.errorCode, errorCode)
+		})
+	}
+}
+
+func TestSendServiceErrorProblemDetails(t *testing.T) {
+	err := fmt.Errorf("invalid transaction request: %w", &service.ValidationError{Field: "amount", Message: "amount must be greater than zero"})
+
+	req := httptest.NewRequest(http.MethodPost, "/transactions", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	rec := httptest.NewRecorder()
+
+	problemWriter{}.sendServiceError(rec, req, err)
+
+	var problem Problem
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "/problems/validation-failed", problem.Type)
+	assert.Equal(t, "req-123", problem.Instance)
+	assert.Equal(t, CodeValidationFailed, problem.Code)
+	assert.Equal(t, []*FieldError{{Field: "amount", Message: "amount must be greater than zero"}}, problem.Errors)
+}
+
+func TestInternalErrorsAreScrubbed(t *testing.T) {
+	err := fmt.Errorf("failed to get account: %w", errors.New(`pq: relation "accounts" does not exist`))
+
+	assert.Equal(t, internalErrorDetail, publicDetail(err, http.StatusInternalServerError, problemWriter{}.debugMode()))
+
+	debug := newProblemWriter(settings.NewStore(&settings.Settings{DebugMode: true}), nil)
+	assert.Equal(t, err.Error(), publicDetail(err, http.StatusInternalServerError, debug.debugMode()))
+}
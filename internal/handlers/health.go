@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"txn-service/internal/database"
+	"txn-service/internal/logger"
+)
+
+// Health statuses of the probes and of each readiness check
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+// HealthResponse is the body of /livez and /readyz. Checks is only set by
+// /readyz.
+type HealthResponse struct {
+	Status string                       `json:"status"`
+	Checks map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+// HealthCheckResult is the outcome of one readiness check; Detail says why
+// it failed
+type HealthCheckResult struct {
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// healthCheck is one dependency of readiness; it fails by returning an error
+type healthCheck func(ctx context.Context) error
+
+// HealthHandler serves the liveness and readiness probes. The service is
+// ready while the database answers within the check timeout, its schema is
+// migrated to the version of this build and it is not shutting down.
+type HealthHandler struct {
+	checks       map[string]healthCheck
+	checkTimeout time.Duration
+	shuttingDown atomic.Bool
+}
+
+// NewHealthHandler creates the probes for db. Each readiness check is given at
+// most checkTimeout; failed checks are logged to log.
+func NewHealthHandler(db *sql.DB, checkTimeout time.Duration, log *logger.Logger) (*HealthHandler, error) {
+	migrator, err := database.NewMigrator(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return &HealthHandler{
+		checks: map[string]healthCheck{
+			"database": func(ctx context.Context) error {
+				if err := db.PingContext(ctx); err != nil {
+					log.FromContext(ctx).Error("Readiness check failed - database ping: %v", err)
+					return errors.New("database is unreachable")
+				}
+				return nil
+			},
+			"migrations": func(ctx context.Context) error {
+				if err := migrator.Check(ctx); err != nil {
+					log.FromContext(ctx).Error("Readiness check failed - migrations: %v", err)
+					return errors.New("schema is not at the expected version")
+				}
+				return nil
+			},
+		},
+		checkTimeout: checkTimeout,
+	}, nil
+}
+
+// ShutDown marks the service as shutting down, failing readiness so load
+// balancers stop routing to it while in-flight requests complete
+func (h *HealthHandler) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Livez answers 200 as long as the process serves HTTP at all
+func (h *HealthHandler) Livez(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, &HealthResponse{Status: HealthStatusOK})
+}
+
+// Readyz runs the readiness checks concurrently and answers 503 with the
+// result of every check when any of them fails
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), h.checkTimeout)
+	defer cancel()
+
+	response := &HealthResponse{Status: HealthStatusOK, Checks: map[string]HealthCheckResult{}}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range h.checks {
+		wg.Add(1)
+		go func(name string, check healthCheck) {
+			defer wg.Done()
+			result := h.runCheck(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			response.Checks[name] = result
+		}(name, check)
+	}
+	wg.Wait()
+
+	response.Checks["shutdown"] = HealthCheckResult{Status: HealthStatusOK}
+	if h.shuttingDown.Load() {
+		response.Checks["shutdown"] = HealthCheckResult{Status: HealthStatusUnavailable, Detail: "service is shutting down"}
+	}
+
+	status := http.StatusOK
+	for _, result := range response.Checks {
+		if result.Status != HealthStatusOK {
+			response.Status = HealthStatusUnavailable
+			status = http.StatusServiceUnavailable
+		}
+	}
+	writeHealth(w, status, response)
+}
+
+func (h *HealthHandler) runCheck(ctx context.Context, check healthCheck) HealthCheckResult {
+	err := check(ctx)
+	if err == nil {
+		return HealthCheckResult{Status: HealthStatusOK}
+	}
+
+	detail := err.Error()
+	if ctx.Err() == context.DeadlineExceeded {
+		detail = fmt.Sprintf("timed out after %s", h.checkTimeout)
+	}
+	return HealthCheckResult{Status: HealthStatusUnavailable, Detail: detail}
+}
+
+func writeHealth(w http.ResponseWriter, status int, response *HealthResponse) {
+	// probes must always see the current state
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadyz(t *testing.T) {
+	var databaseErr error
+	h := &HealthHandler{
+		checks: map[string]healthCheck{
+			"database": func(ctx context.Context) error { return databaseErr },
+			"migrations": func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		},
+		checkTimeout: 10 * time.Millisecond,
+	}
+
+	readyz := func() (int, HealthResponse) {
+		recorder := httptest.NewRecorder()
+		h.Readyz(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var response HealthResponse
+		require.NoError(t, json.NewDecoder(recorder.Body).Decode(&response))
+		return recorder.Code, response
+	}
+
+	// a check that hangs fails at the timeout instead of holding the probe
+	status, response := readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, HealthStatusUnavailable, response.Status)
+	assert.Equal(t, HealthCheckResult{Status: HealthStatusOK}, response.Checks["database"])
+	assert.Equal(t, HealthCheckResult{Status: HealthStatusUnavailable, Detail: "timed out after 10ms"}, response.Checks["migrations"])
+	assert.Equal(t, HealthCheckResult{Status: HealthStatusOK}, response.Checks["shutdown"])
+
+	h.checks["migrations"] = func(ctx context.Context) error { return nil }
+	status, response = readyz()
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, HealthStatusOK, response.Status)
+
+	databaseErr = errors.New("database is unreachable")
+	status, response = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "database is unreachable", response.Checks["database"].Detail)
+
+	databaseErr = nil
+	h.ShutDown()
+	status, response = readyz()
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, HealthStatusUnavailable, response.Checks["shutdown"].Status)
+
+	// liveness does not depend on readiness
+	recorder := httptest.NewRecorder()
+	h.Livez(recorder, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
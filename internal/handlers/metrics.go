@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"txn-service/internal/metrics"
+)
+
+// MetricsMiddleware counts requests and their latency by route template and
+// status code
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := recordResponse(w)
+
+		next.ServeHTTP(recorder, r)
+
+		metrics.ObserveHTTPRequest(r.Method, routeTemplate(r), recorder.status(), time.Since(start))
+	})
+}
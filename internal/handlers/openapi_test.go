@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"txn-service/internal/config"
+	"txn-service/internal/logger"
+	"txn-service/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type openAPIDocument struct {
+	OpenAPI    string                                 `json:"openapi"`
+	Paths      map[string]map[string]openAPIOperation `json:"paths"`
+	Components struct {
+		Schemas   map[string]*openAPISchema   `json:"schemas"`
+		Responses map[string]*openAPIResponse `json:"responses"`
+	} `json:"components"`
+}
+
+type openAPIOperation struct {
+	OperationID string                      `json:"operationId"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+}
+
+type openAPIResponse struct {
+	Ref     string `json:"$ref"`
+	Content map[string]struct {
+		Schema *openAPISchema `json:"schema"`
+	} `json:"content"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `json:"$ref"`
+	Type       string                    `json:"type"`
+	Required   []string                  `json:"required"`
+	Properties map[string]*openAPISchema `json:"properties"`
+	Items      *openAPISchema            `json:"items"`
+}
+
+// schemaModels maps every component schema to the Go type encoded or decoded
+// for it. Request schemas are only checked for their properties; response
+// schemas also have to list every field without omitempty as required.
+var schemaModels = map[string]struct {
+	model    interface{}
+	response bool
+}{
+	"Account":                          {models.Account{}, true},
+	"CreateAccountRequest":             {models.CreateAccountRequest{}, false},
+	"Transaction":                      {models.Transaction{}, true},
+	"CreateTransactionRequest":         {models.CreateTransactionRequest{}, false},
+	"CreateTransactionSuccessResponse": {models.CreateTransactionSuccessResponse{}, true},
+	"TransactionListResponse":          {models.TransactionListResponse{}, true},
+	"Product":                          {models.Product{}, true},
+	"CreateProductRequest":             {models.CreateProductRequest{}, false},
+	"Customer":                         {models.Customer{}, true},
+	"CreateCustomerRequest":            {models.CreateCustomerRequest{}, false},
+	"UpdateKYCStatusRequest":           {models.UpdateKYCStatusRequest{}, false},
+	"CustomerAccountsResponse":         {models.CustomerAccountsResponse{}, true},
+	"Problem":                          {Problem{}, true},
+	"FieldError":                       {FieldError{}, true},
+	"HealthResponse":                   {HealthResponse{}, true},
+	"HealthCheckResult":                {HealthCheckResult{}, true},
+	"ReloadResult":                     {config.ReloadResult{}, true},
+	"Change":                           {config.Change{}, true},
+}
+
+func loadOpenAPIDocument(t *testing.T) *openAPIDocument {
+	t.Helper()
+
+	var doc openAPIDocument
+	require.NoError(t, json.Unmarshal(openAPISpec, &doc))
+	require.True(t, strings.HasPrefix(doc.OpenAPI, "3."), "not an OpenAPI 3 document")
+	return &doc
+}
+
+func TestOpenAPIRoutesMatchRouter(t *testing.T) {
+	doc := loadOpenAPIDocument(t)
+	router := SetupRoutes(&AccountHandler{}, &TransactionHandler{}, &ProductHandler{}, &CustomerHandler{}, &HealthHandler{}, &AdminHandler{}, nil, nil, nil, logger.New("ERROR"))
+
+	var routed []string
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, method := range methods {
+			routed = append(routed, method+" "+path)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	var documented []string
+	for path, operations := range doc.Paths {
+		for method, operation := range operations {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+			assert.NotEmpty(t, operation.OperationID, "%s %s has no operationId", method, path)
+			assert.NotEmpty(t, operation.Responses, "%s %s has no responses", method, path)
+		}
+	}
+
+	sort.Strings(routed)
+	sort.Strings(documented)
+	assert.Equal(t, routed, documented, "routes in SetupRoutes and paths in openapi.json differ")
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	doc := loadOpenAPIDocument(t)
+
+	var checkSchema func(where string, schema *openAPISchema)
+	checkSchema = func(where string, schema *openAPISchema) {
+		if schema == nil {
+			return
+		}
+		if schema.Ref != "" {
+			name := strings.TrimPrefix(schema.Ref, "#/components/schemas/")
+			assert.Contains(t, doc.Components.Schemas, name, "%s refers to an unknown schema", where)
+		}
+		checkSchema(where, schema.Items)
+		for _, property := range schema.Properties {
+			checkSchema(where, property)
+		}
+	}
+
+	for path, operations := range doc.Paths {
+		for method, operation := range operations {
+			for status, response := range operation.Responses {
+				where := method + " " + path + " " + status
+				if response.Ref != "" {
+					name := strings.TrimPrefix(response.Ref, "#/components/responses/")
+					assert.Contains(t, doc.Components.Responses, name, "%s refers to an unknown response", where)
+					continue
+				}
+				for _, content := range response.Content {
+					checkSchema(where, content.Schema)
+				}
+			}
+		}
+	}
+
+	for name, schema := range doc.Components.Schemas {
+		checkSchema(name, schema)
+	}
+}
+
+func TestOpenAPISchemasMatchModels(t *testing.T) {
+	doc := loadOpenAPIDocument(t)
+
+	for name := range doc.Components.Schemas {
+		assert.Contains(t, schemaModels, name, "schema %s has no Go model in schemaModels", name)
+	}
+
+	for name, mapping := range schemaModels {
+		t.Run(name, func(t *testing.T) {
+			schema, ok := doc.Components.Schemas[name]
+			require.True(t, ok, "schema %s is missing from openapi.json", name)
+
+			modelType := reflect.TypeOf(mapping.model)
+			var properties, required []string
+
+			for i := 0; i < modelType.NumField(); i++ {
+				field := modelType.Field(i)
+				jsonName, omitempty := jsonField(field)
+				if jsonName == "" {
+					continue
+				}
+
+				properties = append(properties, jsonName)
+				if !omitempty {
+					required = append(required, jsonName)
+				}
+
+				property, ok := schema.Properties[jsonName]
+				if !assert.True(t, ok, "field %s.%s is not documented", modelType.Name(), jsonName) {
+					continue
+				}
+				assertSchemaType(t, name+"."+jsonName, property, field.Type)
+			}
+
+			documented := make([]string, 0, len(schema.Properties))
+			for property := range schema.Properties {
+				documented = append(documented, property)
+			}
+
+			sort.Strings(properties)
+			sort.Strings(documented)
+			assert.Equal(t, properties, documented, "properties of schema %s differ from %s", name, modelType)
+
+			if mapping.response {
+				sort.Strings(required)
+				documentedRequired := append([]string(nil), schema.Required...)
+				sort.Strings(documentedRequired)
+				assert.Equal(t, required, documentedRequired, "required properties of schema %s differ from the fields without omitempty", name)
+			}
+		})
+	}
+}
+
+func TestServeOpenAPISpec(t *testing.T) {
+	router := SetupRoutes(&AccountHandler{}, &TransactionHandler{}, &ProductHandler{}, &CustomerHandler{}, &HealthHandler{}, &AdminHandler{}, nil, nil, nil, logger.New("ERROR"))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.True(t, json.Valid(rec.Body.Bytes()))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "openapi.json")
+}
+
+func jsonField(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" || !field.IsExported() {
+		return "", false
+	}
+
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	for _, option := range parts[1:] {
+		if option == "omitempty" {
+			return name, true
+		}
+	}
+	return name, false
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+func assertSchemaType(t *testing.T, where string, schema *openAPISchema, goType reflect.Type) {
+	t.Helper()
+
+	if goType.Kind() == reflect.Ptr {
+		goType = goType.Elem()
+	}
+
+	if goType == timeType || goType == uuidType {
+		assert.Equal(t, "string", schema.Type, "%s should be a string", where)
+		return
+	}
+
+	switch goType.Kind() {
+	case reflect.String:
+		assert.Equal(t, "string", schema.Type, "%s should be a string", where)
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		assert.Equal(t, "integer", schema.Type, "%s should be an integer", where)
+	case reflect.Float32, reflect.Float64:
+		assert.Equal(t, "number", schema.Type, "%s should be a number", where)
+	case reflect.Bool:
+		assert.Equal(t, "boolean", schema.Type, "%s should be a boolean", where)
+	case reflect.Map:
+		assert.Equal(t, "object", schema.Type, "%s should be an object", where)
+	case reflect.Slice:
+		if assert.Equal(t, "array", schema.Type, "%s should be an array", where) && assert.NotNil(t, schema.Items, "%s has no items", where) {
+			assertSchemaType(t, where+"[]", schema.Items, goType.Elem())
+		}
+	case reflect.Struct:
+		assert.Equal(t, "#/components/schemas/"+goType.Name(), schema.Ref, "%s should refer to the %s schema", where, goType.Name())
+	default:
+		t.Errorf("%s has unsupported Go type %s", where, goType)
+	}
+}
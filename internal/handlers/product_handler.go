@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/service"
+	"txn-service/internal/settings"
+	"txn-service/models"
+
+	"github.com/gorilla/mux"
+)
+
+type ProductHandler struct {
+	problemWriter
+	productService service.ProductService
+}
+
+func NewProductHandler(productService service.ProductService, runtime *settings.Store, log *logger.Logger) *ProductHandler {
+	return &ProductHandler{
+		problemWriter:  newProblemWriter(runtime, log),
+		productService: productService,
+	}
+}
+
+func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateProductRequest
+	if !h.decodeRequest(w, r, &req) {
+		return
+	}
+
+	if req.DayCountConvention == "" {
+		req.DayCountConvention = models.DayCountActual365
+	}
+
+	if err := h.productService.CreateProduct(r.Context(), &req); err != nil {
+		h.sendServiceError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	productCode := mux.Vars(r)["product_code"]
+	if productCode == "" {
+		sendFieldError(w, r, "MISSING_PRODUCT_CODE", "product_code", "product_code parameter is required")
+		return
+	}
+
+	product, err := h.productService.GetProduct(r.Context(), productCode)
+	if err != nil {
+		h.sendServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(product)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/ratelimit"
+)
+
+// RateLimitMiddleware throttles each client to the read or write limit of the
+// routes it calls. Limit runs after authentication, so authenticated clients
+// are limited per credential and anonymous ones per IP address; LimitIP runs
+// before it and bounds each IP address over all routes. A nil
+// *RateLimitMiddleware lets every request through.
+type RateLimitMiddleware struct {
+	limiter *ratelimit.Limiter
+}
+
+func NewRateLimitMiddleware(limiter *ratelimit.Limiter) *RateLimitMiddleware {
+	return &RateLimitMiddleware{limiter: limiter}
+}
+
+// Limit wraps next with the limit of class. Every response carries the
+// RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers; rejected
+// requests get 429 with Retry-After.
+func (m *RateLimitMiddleware) Limit(class ratelimit.Class, next http.HandlerFunc) http.HandlerFunc {
+	if m == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		client := ratelimit.ClientKey(auth.FromContext(r.Context()), r.RemoteAddr)
+		if allow(w, r, m.limiter.Allow(r.Context(), client, class)) {
+			next(w, r)
+		}
+	}
+}
+
+// LimitIP wraps next with the limit of the client's IP address. It goes in
+// front of authentication, so requests with missing or invalid credentials
+// are throttled before they are looked up. The headers of a limit applied
+// after it replace its own.
+func (m *RateLimitMiddleware) LimitIP(next http.HandlerFunc) http.HandlerFunc {
+	if m == nil {
+		return next
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if allow(w, r, m.limiter.AllowIP(r.Context(), r.RemoteAddr)) {
+			next(w, r)
+		}
+	}
+}
+
+// allow sets the rate limit headers of result and answers 429 when the
+// request was rejected
+func allow(w http.ResponseWriter, r *http.Request, result ratelimit.Result) bool {
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+	if !result.Allowed {
+		retryAfter := ceilSeconds(result.RetryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		sendJSONError(w, r, CodeRateLimited, "rate limit exceeded, retry in "+strconv.Itoa(retryAfter)+"s", http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// ceilSeconds rounds up so clients never retry before a token is available
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
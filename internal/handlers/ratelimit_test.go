@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/logger"
+	"txn-service/internal/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limits := ratelimit.Limits{Read: ratelimit.Limit{Rate: 1, Burst: 5}, Write: ratelimit.Limit{Rate: 0.5, Burst: 2}, IP: ratelimit.Limit{Rate: 1, Burst: 1}}
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limits, logger.New("ERROR"))
+	middleware := NewRateLimitMiddleware(limiter)
+
+	noContent := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+	handler := middleware.Limit(ratelimit.Write, noContent)
+
+	send := func(remoteAddr string, principal *auth.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/transactions", nil)
+		req.RemoteAddr = remoteAddr
+		if principal != nil {
+			req = req.WithContext(auth.WithPrincipal(req.Context(), principal))
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		return rec
+	}
+
+	rec := send("10.0.0.1:1234", nil)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "1", rec.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", rec.Header().Get("RateLimit-Reset"))
+
+	assert.Equal(t, http.StatusNoContent, send("10.0.0.1:4321", nil).Code, "clients are identified by IP, not port")
+
+	rec = send("10.0.0.1:1234", nil)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("RateLimit-Remaining"))
+
+	var problem Problem
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+	assert.Equal(t, CodeRateLimited, problem.Code)
+
+	// authenticated clients have their own bucket, wherever they connect from
+	assert.Equal(t, http.StatusNoContent, send("10.0.0.1:1234", &auth.Principal{APIKeyID: "k1"}).Code)
+	assert.Equal(t, http.StatusNoContent, send("10.0.0.2:1234", &auth.Principal{APIKeyID: "k1"}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, send("10.0.0.3:1234", &auth.Principal{APIKeyID: "k1"}).Code)
+
+	var disabled *RateLimitMiddleware
+	rec = httptest.NewRecorder()
+	disabled.Limit(ratelimit.Write, noContent)(rec, httptest.NewRequest(http.MethodPost, "/transactions", nil))
+	assert.Empty(t, rec.Header().Get("RateLimit-Limit"))
+}
+
+// countingAuthenticator rejects every token and counts the lookups
+type countingAuthenticator struct {
+	lookups int
+}
+
+func (a *countingAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	a.lookups++
+	return nil, auth.ErrUnauthenticated
+}
+
+func TestRateLimitBeforeAuthentication(t *testing.T) {
+	limits := ratelimit.Limits{Read: ratelimit.Limit{Rate: 1, Burst: 5}, Write: ratelimit.Limit{Rate: 1, Burst: 5}, IP: ratelimit.Limit{Rate: 0.01, Burst: 2}}
+	rateLimit := NewRateLimitMiddleware(ratelimit.NewLimiter(ratelimit.NewMemoryStore(), limits, logger.New("ERROR")))
+
+	authenticator := &countingAuthenticator{}
+	router := SetupRoutes(&AccountHandler{}, &TransactionHandler{}, &ProductHandler{}, &CustomerHandler{}, &HealthHandler{}, &AdminHandler{},
+		NewAuthMiddleware(authenticator, nil, nil, logger.New("ERROR")), rateLimit, nil, logger.New("ERROR"))
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("Authorization", "Bearer invalid")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, send())
+	assert.Equal(t, http.StatusUnauthorized, send())
+	assert.Equal(t, http.StatusTooManyRequests, send())
+	assert.Equal(t, 2, authenticator.lookups, "throttled requests are not authenticated")
+}
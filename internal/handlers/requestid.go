@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"net/http"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/requestid"
+
+	"github.com/gorilla/mux"
+)
+
+// RequestIDMiddleware takes the caller's X-Request-ID, or generates one,
+// echoes it in the response and puts it in the request context with the
+// route, so every line logged for the request carries both
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := requestid.OrNew(r.Header.Get(requestid.Header))
+		w.Header().Set(requestid.Header, id)
+
+		ctx := requestid.WithID(r.Context(), id)
+		ctx = logger.ContextWithFields(ctx, map[string]interface{}{
+			"request_id": id,
+			"method":     r.Method,
+			"route":      routeTemplate(r),
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// routeTemplate is the template of the route that matched r, like
+// /accounts/{account_id}, or "unknown" outside of the router
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"txn-service/internal/requestid"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	router := mux.NewRouter()
+	router.Use(RequestIDMiddleware)
+	router.HandleFunc("/accounts/{account_id}", func(w http.ResponseWriter, r *http.Request) {
+		seen = requestid.FromContext(r.Context())
+		sendJSONError(w, r, CodeNotFound, "account not found", http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, "req-123", seen)
+	assert.Equal(t, "req-123", rec.Header().Get("X-Request-ID"))
+	assert.Contains(t, rec.Body.String(), `"instance":"req-123"`)
+
+	// IDs that could garble the logs are replaced
+	req = httptest.NewRequest(http.MethodGet, "/accounts/1", nil)
+	req.Header.Set("X-Request-ID", "req 123\n")
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	assert.NotEqual(t, "req 123\n", seen)
+	assert.True(t, requestid.Valid(seen))
+	assert.Equal(t, seen, rec.Header().Get("X-Request-ID"))
+}
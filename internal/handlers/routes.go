@@ -3,22 +3,79 @@ package handlers
 import (
 	"net/http"
 
+	"txn-service/internal/auth"
+	"txn-service/internal/logger"
+	"txn-service/internal/metrics"
+	"txn-service/internal/ratelimit"
+
 	"github.com/gorilla/mux"
 )
 
-func SetupRoutes(accountHandler *AccountHandler, transactionHandler *TransactionHandler) *mux.Router {
+// SetupRoutes registers the API routes. Each API route requires one scope when
+// authMiddleware is non-nil and is rate limited by the class of that scope when
+// rateLimitMiddleware is non-nil, and per IP address before authentication
+// when both are; the /livez and /readyz probes, /metrics and the API
+// documentation stay public. The /admin routes additionally require an
+// operator, so they are closed while authentication is disabled.
+// Every request, including those answered 404 or 405, gets a request ID, is
+// access logged when accessLogMiddleware is non-nil and answers 500 when its
+// handler panics, logging the panic to log.
+func SetupRoutes(accountHandler *AccountHandler, transactionHandler *TransactionHandler, productHandler *ProductHandler, customerHandler *CustomerHandler, healthHandler *HealthHandler, adminHandler *AdminHandler, authMiddleware *AuthMiddleware, rateLimitMiddleware *RateLimitMiddleware, accessLogMiddleware *AccessLogMiddleware, log *logger.Logger) *mux.Router {
 	router := mux.NewRouter()
+	// the access log and metrics sit outside recovery so they record the 500
+	// of a panic
+	middlewares := []mux.MiddlewareFunc{RequestIDMiddleware, TracingMiddleware, MetricsMiddleware, accessLogMiddleware.Handler, RecoveryMiddleware(log)}
+	router.Use(middlewares...)
+	// mux runs its middleware on matched routes only, so the 404 and 405
+	// answers get the same chain
+	router.NotFoundHandler = chain(http.NotFoundHandler(), middlewares)
+	router.MethodNotAllowedHandler = chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}), middlewares)
+
+	// the rate limit of the class applies per credential, so it runs after
+	// authentication; the IP limit in front of it throttles bad credentials
+	// before they are looked up
+	protect := func(scope string, handler http.HandlerFunc) http.HandlerFunc {
+		limited := authMiddleware.Require(scope, rateLimitMiddleware.Limit(ratelimit.ClassOf(scope), handler))
+		if authMiddleware == nil {
+			return limited
+		}
+		return rateLimitMiddleware.LimitIP(limited)
+	}
+
+	router.HandleFunc("/accounts", protect(auth.ScopeAccountsWrite, accountHandler.CreateAccount)).Methods("POST")
+	router.HandleFunc("/accounts/{account_id}", protect(auth.ScopeAccountsRead, accountHandler.GetAccount)).Methods("GET")
+
+	router.HandleFunc("/transactions", protect(auth.ScopeTransactionsWrite, transactionHandler.ProcessTransaction)).Methods("POST")
+	router.HandleFunc("/transactions", protect(auth.ScopeTransactionsRead, transactionHandler.SearchTransactions)).Methods("GET")
+	router.HandleFunc("/transactions/{transaction_id}", protect(auth.ScopeTransactionsRead, transactionHandler.GetTransaction)).Methods("GET")
 
-	router.HandleFunc("/accounts", accountHandler.CreateAccount).Methods("POST")
-	router.HandleFunc("/accounts/{account_id}", accountHandler.GetAccount).Methods("GET")
+	router.HandleFunc("/products", protect(auth.ScopeProductsWrite, productHandler.CreateProduct)).Methods("POST")
+	router.HandleFunc("/products/{product_code}", protect(auth.ScopeProductsRead, productHandler.GetProduct)).Methods("GET")
 
-	router.HandleFunc("/transactions", transactionHandler.ProcessTransaction).Methods("POST")
+	router.HandleFunc("/customers", protect(auth.ScopeCustomersWrite, customerHandler.CreateCustomer)).Methods("POST")
+	router.HandleFunc("/customers/{customer_id}", protect(auth.ScopeCustomersRead, customerHandler.GetCustomer)).Methods("GET")
+	router.HandleFunc("/customers/{customer_id}/kyc_status", protect(auth.ScopeCustomersWrite, customerHandler.UpdateKYCStatus)).Methods("PUT")
+	router.HandleFunc("/customers/{customer_id}/accounts", protect(auth.ScopeCustomersRead, customerHandler.GetCustomerAccounts)).Methods("GET")
 
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"OK"}`))
-	}).Methods("GET")
+	router.HandleFunc("/admin/config/reload", protect(auth.ScopeConfigWrite, adminHandler.ReloadConfig)).Methods("POST")
+
+	router.HandleFunc("/livez", healthHandler.Livez).Methods("GET")
+	router.HandleFunc("/readyz", healthHandler.Readyz).Methods("GET")
+
+	router.Handle("/metrics", metrics.Handler()).Methods("GET")
+	router.HandleFunc("/openapi.json", ServeOpenAPISpec).Methods("GET")
+	router.HandleFunc("/docs", ServeDocs).Methods("GET")
 
 	return router
 }
+
+// chain wraps handler in middlewares, the first one outermost, as router.Use
+// does for matched routes
+func chain(handler http.Handler, middlewares []mux.MiddlewareFunc) http.Handler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
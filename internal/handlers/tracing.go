@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/requestid"
+	"txn-service/internal/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracingMiddleware starts a server span per request, continuing the trace of
+// the caller's traceparent header, and adds its trace ID to the log fields.
+// It runs after RequestIDMiddleware so the span carries the request ID.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		route := routeTemplate(r)
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPMethod(r.Method),
+				semconv.HTTPRoute(route),
+				tracing.RequestIDKey.String(requestid.FromContext(ctx)),
+			),
+		)
+		defer span.End()
+
+		if traceID := tracing.TraceID(ctx); traceID != "" {
+			ctx = logger.ContextWithField(ctx, "trace_id", traceID)
+		}
+
+		recorder := recordResponse(w)
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		status := recorder.status()
+		span.SetAttributes(semconv.HTTPStatusCode(status))
+		// client errors are the caller's, only server errors fail the span
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, fmt.Sprintf("HTTP %d", status))
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"txn-service/internal/tracing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(previous)
+
+	var traceID string
+	router := mux.NewRouter()
+	router.Use(RequestIDMiddleware, TracingMiddleware)
+	router.HandleFunc("/accounts/{account_id}", func(w http.ResponseWriter, r *http.Request) {
+		traceID = tracing.TraceID(r.Context())
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/42", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+
+	// the caller's trace is continued
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", traceID)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+
+	assert.Equal(t, "GET /accounts/{account_id}", span.Name())
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+	assert.Equal(t, codes.Error, span.Status().Code)
+
+	attributes := map[string]interface{}{}
+	for _, attribute := range span.Attributes() {
+		attributes[string(attribute.Key)] = attribute.Value.AsInterface()
+	}
+	assert.Equal(t, "/accounts/{account_id}", attributes["http.route"])
+	assert.Equal(t, int64(http.StatusInternalServerError), attributes["http.status_code"])
+	assert.NotEmpty(t, attributes["txn.request_id"])
+}
@@ -3,49 +3,99 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"txn-service/internal/logger"
 	"txn-service/internal/service"
+	"txn-service/internal/settings"
 	"txn-service/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 )
 
 type TransactionHandler struct {
+	problemWriter
 	transactionService service.TransactionService
 }
 
-func NewTransactionHandler(transactionService service.TransactionService) *TransactionHandler {
+func NewTransactionHandler(transactionService service.TransactionService, runtime *settings.Store, log *logger.Logger) *TransactionHandler {
 	return &TransactionHandler{
+		problemWriter:      newProblemWriter(runtime, log),
 		transactionService: transactionService,
 	}
 }
 
 func (h *TransactionHandler) ProcessTransaction(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateTransactionRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		sendJSONError(w, "INVALID_REQUEST", "Invalid request body", http.StatusBadRequest)
+	if !h.decodeRequest(w, r, &req) {
 		return
 	}
 
-	if req.SourceAccountID <= 0 {
-		sendJSONError(w, "INVALID_SOURCE_ACCOUNT", "source_account_id must be a positive integer", http.StatusBadRequest)
+	transaction, err := h.transactionService.ProcessTransaction(r.Context(), &req)
+	if err != nil {
+		h.sendServiceError(w, r, err)
 		return
 	}
 
-	if req.DestinationAccountID <= 0 {
-		sendJSONError(w, "INVALID_DESTINATION_ACCOUNT", "destination_account_id must be a positive integer", http.StatusBadRequest)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	transactionID, err := uuid.Parse(mux.Vars(r)["transaction_id"])
+	if err != nil {
+		sendFieldError(w, r, "INVALID_TRANSACTION_ID_FORMAT", "transaction_id", "Invalid transaction_id format")
 		return
 	}
 
-	if req.Amount == "" {
-		sendJSONError(w, "MISSING_AMOUNT", "amount is required", http.StatusBadRequest)
+	transaction, err := h.transactionService.GetTransaction(r.Context(), transactionID)
+	if err != nil {
+		h.sendServiceError(w, r, err)
 		return
 	}
 
-	transaction, err := h.transactionService.ProcessTransaction(r.Context(), &req)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(transaction)
+}
+
+// SearchTransactions handles GET /transactions?reference=...&metadata.key=value&limit=n
+func (h *TransactionHandler) SearchTransactions(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := &models.TransactionFilter{
+		Reference: query.Get("reference"),
+		Metadata:  map[string]string{},
+	}
+
+	for key, values := range query {
+		if !strings.HasPrefix(key, "metadata.") {
+			continue
+		}
+		metadataKey := strings.TrimPrefix(key, "metadata.")
+		if metadataKey == "" || len(values) != 1 {
+			sendFieldError(w, r, "INVALID_METADATA_FILTER", "metadata", "metadata filters must be given once as metadata.<key>=<value>")
+			return
+		}
+		filter.Metadata[metadataKey] = values[0]
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		parsed, err := strconv.Atoi(limit)
+		if err != nil || parsed <= 0 {
+			sendFieldError(w, r, "INVALID_LIMIT", "limit", "limit must be a positive integer")
+			return
+		}
+		filter.Limit = parsed
+	}
+
+	transactions, err := h.transactionService.SearchTransactions(r.Context(), filter)
 	if err != nil {
-		sendJSONError(w, "TRANSACTION_FAILED", err.Error(), http.StatusBadRequest)
+		h.sendServiceError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(transaction)
+	json.NewEncoder(w).Encode(&models.TransactionListResponse{Transactions: transactions})
 }
@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"txn-service/internal/logger"
+)
+
+// JobFunc is a unit of background work. It receives the runner's context,
+// which is cancelled on shutdown, and must be safe to run repeatedly.
+type JobFunc func(ctx context.Context) error
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      JobFunc
+}
+
+// Runner executes registered jobs periodically in the background until it
+// is stopped. Each job runs once at start and then on its own interval; a
+// job never overlaps with itself.
+type Runner struct {
+	jobs   []job
+	logger *logger.Logger
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRunner(log *logger.Logger) *Runner {
+	return &Runner{
+		logger: log,
+	}
+}
+
+// Register adds a job to the runner. It must be called before Start.
+func (r *Runner) Register(name string, interval time.Duration, run JobFunc) {
+	r.jobs = append(r.jobs, job{
+		name:     name,
+		interval: interval,
+		run:      run,
+	})
+}
+
+func (r *Runner) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go r.loop(ctx, j)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return
+func (r *Runner) Stop() {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	r.wg.Wait()
+}
+
+func (r *Runner) loop(ctx context.Context, j job) {
+	defer r.wg.Done()
+
+	// lines logged by the job's work name the job
+	ctx = logger.ContextWithField(ctx, "job", j.name)
+
+	r.logger.FromContext(ctx).Info("Starting job - name: %s, interval: %s", j.name, j.interval)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		r.runOnce(ctx, j)
+
+		select {
+		case <-ctx.Done():
+			r.logger.FromContext(ctx).Info("Job stopped - name: %s", j.name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Runner) runOnce(ctx context.Context, j job) {
+	start := time.Now()
+	r.logger.FromContext(ctx).Debug("Running job - name: %s", j.name)
+
+	if err := j.run(ctx); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		r.logger.FromContext(ctx).Error("Job failed - name: %s, error: %v", j.name, err)
+		return
+	}
+
+	r.logger.FromContext(ctx).Debug("Job completed - name: %s, duration: %s", j.name, time.Since(start))
+}
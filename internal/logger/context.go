@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type fieldsKey struct{}
+
+// defaultLogger is the logger FromContext logs to; SetDefault replaces it
+// with the service's logger
+var defaultLogger atomic.Pointer[Logger]
+
+func init() {
+	defaultLogger.Store(New("INFO"))
+}
+
+// SetDefault sets the logger that FromContext logs to
+func SetDefault(l *Logger) {
+	defaultLogger.Store(l)
+}
+
+// ContextWithFields returns a copy of ctx carrying fields in addition to the
+// ones it already carries. Lines logged through FromContext include them, so
+// a request ID set by the HTTP middleware reaches the repository log lines.
+func ContextWithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	entry := &Entry{fields: contextFields(ctx)}
+	return context.WithValue(ctx, fieldsKey{}, entry.WithFields(fields).fields)
+}
+
+// ContextWithField returns a copy of ctx carrying one more field
+func ContextWithField(ctx context.Context, key string, value interface{}) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, withField(contextFields(ctx), key, value))
+}
+
+// FromContext returns an entry on the default logger with the fields carried
+// by ctx, for code that is not handed a logger of its own
+func FromContext(ctx context.Context) *Entry {
+	return defaultLogger.Load().FromContext(ctx)
+}
+
+// FromContext returns an entry with the fields carried by ctx. Components
+// that are constructed with a logger use this form, so tests and the CLI can
+// give each one its own output.
+func (l *Logger) FromContext(ctx context.Context) *Entry {
+	return &Entry{
+		logger: l,
+		fields: contextFields(ctx),
+	}
+}
+
+func contextFields(ctx context.Context) []Field {
+	fields, _ := ctx.Value(fieldsKey{}).([]Field)
+	return fields
+}
@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+const (
+	textTimeFormat = "2006-01-02 15:04:05"
+	errorKey       = "error"
+	errorChainKey  = "error_chain"
+)
+
+// reservedKeys are written by the logger itself; fields with these keys are
+// renamed to fields.<key> in JSON so they cannot overwrite them
+var reservedKeys = map[string]bool{
+	"time":        true,
+	"level":       true,
+	"msg":         true,
+	"caller":      true,
+	errorChainKey: true,
+}
+
+type record struct {
+	time    time.Time
+	level   Level
+	message string
+	caller  string
+	fields  []Field
+	// err is the error the line is about, from WithError or else the first
+	// error in the format arguments
+	err error
+}
+
+// appendText formats the record as `[time] [LEVEL] message key=value ...`.
+// The fields are appended after formatting the message, so a % in a field
+// value is printed as is.
+func (r *record) appendText(buf []byte) []byte {
+	buf = append(buf, '[')
+	buf = r.time.AppendFormat(buf, textTimeFormat)
+	buf = append(buf, "] ["...)
+	buf = append(buf, r.level.String()...)
+	buf = append(buf, "] "...)
+	buf = append(buf, r.message...)
+
+	for _, field := range r.fields {
+		buf = append(buf, ' ')
+		buf = append(buf, field.Key...)
+		buf = append(buf, '=')
+		buf = fmt.Append(buf, field.Value)
+	}
+
+	return append(buf, '\n')
+}
+
+// appendJSON formats the record as a JSON object with the keys time, level,
+// msg, caller, then the fields sorted by key, then error and error_chain
+func (r *record) appendJSON(buf []byte) []byte {
+	buf = append(buf, `{"time":`...)
+	buf = appendJSONValue(buf, r.time.Format(time.RFC3339Nano))
+	buf = append(buf, `,"level":`...)
+	buf = appendJSONValue(buf, r.level.String())
+	buf = append(buf, `,"msg":`...)
+	buf = appendJSONValue(buf, r.message)
+	if r.caller != "" {
+		buf = append(buf, `,"caller":`...)
+		buf = appendJSONValue(buf, r.caller)
+	}
+
+	hasErrorField := false
+	for _, field := range r.fields {
+		key := field.Key
+		if reservedKeys[key] {
+			key = "fields." + key
+		}
+		hasErrorField = hasErrorField || key == errorKey
+
+		buf = append(buf, ',')
+		buf = appendJSONValue(buf, key)
+		buf = append(buf, ':')
+		buf = appendJSONValue(buf, field.Value)
+	}
+
+	if r.err != nil {
+		if !hasErrorField {
+			buf = append(buf, `,"`+errorKey+`":`...)
+			buf = appendJSONValue(buf, r.err.Error())
+		}
+		if chain := errorChain(r.err); len(chain) > 1 {
+			buf = append(buf, `,"`+errorChainKey+`":`...)
+			buf = appendJSONValue(buf, chain)
+		}
+	}
+
+	return append(buf, "}\n"...)
+}
+
+// appendJSONValue encodes value with its JSON type: numbers and booleans stay
+// numbers and booleans, errors and durations become their text and times
+// RFC 3339 strings. Values JSON cannot encode fall back to their %v text.
+func appendJSONValue(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case time.Time:
+		value = v.Format(time.RFC3339Nano)
+	case time.Duration:
+		value = v.String()
+	}
+
+	var encoded bytes.Buffer
+	encoder := json.NewEncoder(&encoded)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return strconv.AppendQuote(buf, fmt.Sprint(value))
+	}
+
+	return append(buf, bytes.TrimSuffix(encoded.Bytes(), []byte("\n"))...)
+}
+
+// errorChain lists the message of err and of every error it wraps
+func errorChain(err error) []string {
+	var chain []string
+	for err != nil {
+		chain = append(chain, err.Error())
+
+		switch wrapped := err.(type) {
+		case interface{ Unwrap() error }:
+			err = wrapped.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, joined := range wrapped.Unwrap() {
+				chain = append(chain, errorChain(joined)...)
+			}
+			return chain
+		default:
+			return chain
+		}
+	}
+	return chain
+}
+
+func firstError(fields []Field, args []interface{}) error {
+	for _, field := range fields {
+		if err, ok := field.Value.(error); ok && field.Key == errorKey {
+			return err
+		}
+	}
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// caller returns the package directory, file and line of the function depth
+// frames up, e.g. service/transaction_service.go:42
+func caller(depth int) string {
+	_, file, line, ok := runtime.Caller(depth)
+	if !ok {
+		return ""
+	}
+	return path.Join(path.Base(path.Dir(file)), path.Base(file)) + ":" + strconv.Itoa(line)
+}
@@ -4,8 +4,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -33,152 +35,319 @@ func (l Level) String() string {
 	}
 }
 
+// Format is how log lines are written
+type Format int
+
+const (
+	// FormatText writes `[time] [LEVEL] message key=value ...` lines
+	FormatText Format = iota
+	// FormatJSON writes one JSON object per line with the time, level,
+	// message, caller, fields and error chain as separate keys
+	FormatJSON
+)
+
+// ParseFormat parses the LOG_FORMAT values "text" and "json"
+func ParseFormat(format string) (Format, error) {
+	switch strings.ToLower(format) {
+	case "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	default:
+		return FormatText, fmt.Errorf("unknown log format %q, must be text or json", format)
+	}
+}
+
 type Logger struct {
-	level    Level
-	output   io.Writer
-	filePath string
-	mu       sync.Mutex
+	// level changes at runtime with SetLevel, while other goroutines log;
+	// levelOf replaces it once the logger follows a level set elsewhere
+	level   atomic.Int32
+	levelOf atomic.Pointer[func() Level]
+	format  Format
+	output  io.Writer
+	file    *RotatingFile
+	mu      sync.Mutex
 }
 
-func New(level string) *Logger {
-	var logLevel Level
+// ParseLevel parses the LOG_LEVEL values ERROR, WARN, INFO and DEBUG, in any
+// case
+func ParseLevel(level string) (Level, error) {
 	switch strings.ToUpper(level) {
 	case "ERROR":
-		logLevel = LevelError
+		return LevelError, nil
 	case "WARN":
-		logLevel = LevelWarn
+		return LevelWarn, nil
 	case "INFO":
-		logLevel = LevelInfo
+		return LevelInfo, nil
 	case "DEBUG":
-		logLevel = LevelDebug
+		return LevelDebug, nil
 	default:
-		logLevel = LevelInfo
+		return LevelInfo, fmt.Errorf("unknown log level %q, must be ERROR, WARN, INFO or DEBUG", level)
 	}
+}
 
-	return &Logger{
-		level:  logLevel,
-		output: os.Stdout,
-	}
+// New creates a logger writing to stdout at level, or INFO when level is
+// unknown
+func New(level string) *Logger {
+	logLevel, _ := ParseLevel(level)
+
+	logger := &Logger{output: os.Stdout}
+	logger.level.Store(int32(logLevel))
+	return logger
 }
 
-func NewFromEnv() *Logger {
-	level := os.Getenv("LOG_LEVEL")
-	if level == "" {
-		level = "INFO"
-	}
+// Config is the level, format and optional log file of a logger
+type Config struct {
+	Level    string
+	Format   string
+	File     string
+	Rotation RotationConfig
+}
+
+// NewFromConfig creates a logger as configured. An unknown format falls back
+// to text and a log file that cannot be opened to stdout only, rather than
+// losing the logs. It is meant to be called once per process; the logger is
+// then passed to everything that logs.
+func NewFromConfig(config Config) *Logger {
+	logger := New(config.Level)
 
-	logger := New(level)
+	if format, err := ParseFormat(config.Format); err == nil {
+		logger.SetFormat(format)
+	}
 
-	logFile := os.Getenv("LOG_FILE")
-	if logFile != "" {
-		logger.SetLogFile(logFile)
+	if config.File != "" {
+		if err := logger.SetRotatingLogFile(config.File, config.Rotation); err != nil {
+			logger.Error("Failed to open log file, logging to stdout only: %v", err)
+		}
 	}
 
 	return logger
 }
 
-func (l *Logger) SetLogFile(filePath string) error {
+func (l *Logger) SetFormat(format Format) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	l.format = format
+}
+
+// SetOutput replaces stdout, and any log file, with w
+func (l *Logger) SetOutput(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.output = w
+}
+
+// SetLogFile also writes the logs to filePath, without rotating it
+func (l *Logger) SetLogFile(filePath string) error {
+	return l.SetRotatingLogFile(filePath, RotationConfig{})
+}
+
+// SetRotatingLogFile also writes the logs to filePath, rotated as configured.
+// A log file set before is closed.
+func (l *Logger) SetRotatingLogFile(filePath string, config RotationConfig) error {
+	file, err := OpenRotatingFile(filePath, config)
 	if err != nil {
-		return fmt.Errorf("failed to open log file: %w", err)
+		return err
 	}
 
+	l.mu.Lock()
+	previous := l.file
+	l.file = file
 	l.output = io.MultiWriter(os.Stdout, file)
-	l.filePath = filePath
+	l.mu.Unlock()
 
+	if previous != nil {
+		return previous.Close()
+	}
 	return nil
 }
 
+// Reopen reopens the log file, after an external tool like logrotate moved it.
+// It does nothing when logging to stdout only.
+func (l *Logger) Reopen() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	return l.file.Reopen()
+}
+
+// Close closes the log file; later lines only go to stdout
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	file := l.file
+	l.file = nil
+	l.output = os.Stdout
+	l.mu.Unlock()
+
+	if file == nil {
+		return nil
+	}
+	return file.Close()
+}
+
+// Level returns the most verbose level logged
+func (l *Logger) Level() Level {
+	if levelOf := l.levelOf.Load(); levelOf != nil {
+		return (*levelOf)()
+	}
+	return Level(l.level.Load())
+}
+
+// FollowLevel makes the logger ask levelOf for its level on every line, in
+// place of the level set with SetLevel
+func (l *Logger) FollowLevel(levelOf func() Level) {
+	l.levelOf.Store(&levelOf)
+}
+
+// SetLevel changes the most verbose level logged; it is safe to call while
+// other goroutines log
+func (l *Logger) SetLevel(level Level) {
+	l.level.Store(int32(level))
+}
+
 func (l *Logger) shouldLog(level Level) bool {
-	return level <= l.level
+	return level <= l.Level()
 }
 
-func (l *Logger) log(level Level, format string, args ...interface{}) {
+// callerDepth skips caller, log and the exported Logger or Entry method
+const callerDepth = 3
+
+// log writes one line. It must be called directly by the exported logging
+// methods so the caller location points at their caller.
+func (l *Logger) log(level Level, fields []Field, format string, args ...interface{}) {
 	if !l.shouldLog(level) {
 		return
 	}
 
-	message := fmt.Sprintf(format, args...)
-	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	logMessage := fmt.Sprintf("[%s] [%s] %s", timestamp, level.String(), message)
+	record := &record{
+		time:    time.Now(),
+		level:   level,
+		message: fmt.Sprintf(format, args...),
+		fields:  fields,
+		err:     firstError(fields, args),
+	}
 
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	fmt.Fprintln(l.output, logMessage)
+	if l.format == FormatJSON {
+		record.caller = caller(callerDepth)
+		l.output.Write(record.appendJSON(nil))
+		return
+	}
+
+	l.output.Write(record.appendText(nil))
 }
 
 func (l *Logger) Error(format string, args ...interface{}) {
-	l.log(LevelError, format, args...)
+	l.log(LevelError, nil, format, args...)
 }
 
 func (l *Logger) Warn(format string, args ...interface{}) {
-	l.log(LevelWarn, format, args...)
+	l.log(LevelWarn, nil, format, args...)
 }
 
 func (l *Logger) Info(format string, args ...interface{}) {
-	l.log(LevelInfo, format, args...)
+	l.log(LevelInfo, nil, format, args...)
 }
 
 func (l *Logger) Debug(format string, args ...interface{}) {
-	l.log(LevelDebug, format, args...)
+	l.log(LevelDebug, nil, format, args...)
 }
 
 func (l *Logger) WithFields(fields map[string]interface{}) *Entry {
 	return &Entry{
 		logger: l,
-		fields: fields,
+		fields: sortedFields(fields),
+	}
+}
+
+// WithError returns an entry carrying err; in JSON its whole chain is logged
+func (l *Logger) WithError(err error) *Entry {
+	return l.WithFields(nil).WithError(err)
+}
+
+// Field is a key and its typed value. Entries keep their fields sorted by key,
+// so lines always list them in the same order.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+func sortedFields(fields map[string]interface{}) []Field {
+	sorted := make([]Field, 0, len(fields))
+	for key, value := range fields {
+		sorted = append(sorted, Field{Key: key, Value: value})
 	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Key < sorted[j].Key })
+	return sorted
 }
 
 type Entry struct {
 	logger *Logger
-	fields map[string]interface{}
+	fields []Field
+}
+
+// WithField returns a copy of the entry with one more field set
+func (e *Entry) WithField(key string, value interface{}) *Entry {
+	return &Entry{
+		logger: e.logger,
+		fields: withField(e.fields, key, value),
+	}
 }
 
-func (e *Entry) formatFields() string {
-	if len(e.fields) == 0 {
-		return ""
+// WithFields returns a copy of the entry with the fields set
+func (e *Entry) WithFields(fields map[string]interface{}) *Entry {
+	merged := e.fields
+	for _, field := range sortedFields(fields) {
+		merged = withField(merged, field.Key, field.Value)
 	}
 
-	var pairs []string
-	for key, value := range e.fields {
-		pairs = append(pairs, fmt.Sprintf("%s=%v", key, value))
+	return &Entry{
+		logger: e.logger,
+		fields: merged,
 	}
-	return " " + strings.Join(pairs, " ")
 }
 
-func (e *Entry) Error(format string, args ...interface{}) {
-	fields := e.formatFields()
-	if fields != "" {
-		format += fields
+// withField returns a copy of the sorted fields with key set to value
+func withField(sorted []Field, key string, value interface{}) []Field {
+	fields := make([]Field, 0, len(sorted)+1)
+	for _, field := range sorted {
+		if field.Key != key {
+			fields = append(fields, field)
+		}
 	}
-	e.logger.log(LevelError, format, args...)
+
+	i := sort.Search(len(fields), func(i int) bool { return fields[i].Key >= key })
+	fields = append(fields, Field{})
+	copy(fields[i+1:], fields[i:])
+	fields[i] = Field{Key: key, Value: value}
+
+	return fields
+}
+
+// WithError returns a copy of the entry with err in the error field
+func (e *Entry) WithError(err error) *Entry {
+	return e.WithField(errorKey, err)
+}
+
+func (e *Entry) Error(format string, args ...interface{}) {
+	e.logger.log(LevelError, e.fields, format, args...)
 }
 
 func (e *Entry) Warn(format string, args ...interface{}) {
-	fields := e.formatFields()
-	if fields != "" {
-		format += fields
-	}
-	e.logger.log(LevelWarn, format, args...)
+	e.logger.log(LevelWarn, e.fields, format, args...)
 }
 
 func (e *Entry) Info(format string, args ...interface{}) {
-	fields := e.formatFields()
-	if fields != "" {
-		format += fields
-	}
-	e.logger.log(LevelInfo, format, args...)
+	e.logger.log(LevelInfo, e.fields, format, args...)
 }
 
 func (e *Entry) Debug(format string, args ...interface{}) {
-	fields := e.formatFields()
-	if fields != "" {
-		format += fields
-	}
-	e.logger.log(LevelDebug, format, args...)
+	e.logger.log(LevelDebug, e.fields, format, args...)
 }
@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger(format Format) (*Logger, *bytes.Buffer) {
+	var out bytes.Buffer
+	logger := New("DEBUG")
+	logger.output = &out
+	logger.SetFormat(format)
+	return logger, &out
+}
+
+func TestTextFormat(t *testing.T) {
+	logger, out := newTestLogger(FormatText)
+
+	logger.WithFields(map[string]interface{}{"b": "100%", "a": 1}).Info("Transfer %s", "done")
+
+	line := out.String()
+	assert.Regexp(t, `^\[\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\] \[INFO\] Transfer done a=1 b=100%\n$`, line)
+}
+
+func TestJSONFormat(t *testing.T) {
+	logger, out := newTestLogger(FormatJSON)
+
+	cause := errors.New("connection refused")
+	err := fmt.Errorf("failed to begin transaction: %w", cause)
+
+	entry := logger.WithFields(map[string]interface{}{
+		"transaction_id": "abc",
+		"amount":         12.5,
+		"attempt":        3,
+		"msg":            "shadowed",
+	})
+	entry.WithField("account_id", int64(7)).Error("Transfer failed: %v", err)
+
+	line := out.String()
+	require.True(t, strings.HasSuffix(line, "}\n"))
+
+	// keys are in a stable order
+	keys := []string{`"time"`, `"level"`, `"msg"`, `"caller"`, `"account_id"`, `"amount"`, `"attempt"`, `"fields.msg"`, `"transaction_id"`, `"error"`, `"error_chain"`}
+	position := 0
+	for _, key := range keys {
+		next := strings.Index(line[position:], key+":")
+		require.GreaterOrEqual(t, next, 0, "%s missing or out of order in %s", key, line)
+		position += next
+	}
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(line), &decoded))
+
+	assert.Equal(t, "ERROR", decoded["level"])
+	assert.Equal(t, "Transfer failed: failed to begin transaction: connection refused", decoded["msg"])
+	assert.Equal(t, "shadowed", decoded["fields.msg"])
+	assert.Equal(t, float64(7), decoded["account_id"])
+	assert.Equal(t, 12.5, decoded["amount"])
+	assert.Regexp(t, `^logger/logger_test\.go:\d+$`, decoded["caller"])
+	assert.Equal(t, err.Error(), decoded["error"])
+	assert.Equal(t, []interface{}{err.Error(), cause.Error()}, decoded["error_chain"])
+
+	timestamp, parseErr := time.Parse(time.RFC3339Nano, decoded["time"].(string))
+	require.NoError(t, parseErr)
+	assert.WithinDuration(t, time.Now(), timestamp, time.Minute)
+}
+
+func TestJSONWithError(t *testing.T) {
+	logger, out := newTestLogger(FormatJSON)
+
+	logger.WithError(errors.Join(errors.New("first"), errors.New("second"))).Warn("Job failed")
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, "first\nsecond", decoded["error"])
+	assert.Equal(t, []interface{}{"first\nsecond", "first", "second"}, decoded["error_chain"])
+	assert.Regexp(t, `^logger/logger_test\.go:\d+$`, decoded["caller"])
+}
+
+func TestLevelFiltering(t *testing.T) {
+	logger, out := newTestLogger(FormatJSON)
+	logger.SetLevel(LevelWarn)
+
+	logger.Info("hidden")
+	logger.WithFields(nil).Debug("hidden")
+	assert.Empty(t, out.String())
+
+	logger.Warn("shown")
+	assert.Contains(t, out.String(), `"msg":"shown"`)
+}
+
+func TestFollowLevel(t *testing.T) {
+	logger, out := newTestLogger(FormatJSON)
+	level := LevelError
+	logger.FollowLevel(func() Level { return level })
+
+	logger.SetLevel(LevelDebug)
+	logger.Warn("hidden")
+	assert.Empty(t, out.String(), "a followed level replaces SetLevel")
+
+	level = LevelWarn
+	logger.Warn("shown")
+	assert.Contains(t, out.String(), `"msg":"shown"`)
+}
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("JSON")
+	require.NoError(t, err)
+	assert.Equal(t, FormatJSON, format)
+
+	_, err = ParseFormat("xml")
+	assert.Error(t, err)
+}
+
+func TestContextFields(t *testing.T) {
+	logger, out := newTestLogger(FormatText)
+
+	ctx := ContextWithFields(context.Background(), map[string]interface{}{"request_id": "req-1", "route": "/transactions"})
+	child := ContextWithField(ctx, "transaction_id", "abc")
+
+	logger.FromContext(child).WithField("amount", "1.00").Info("Transfer completed")
+	assert.Contains(t, out.String(), "Transfer completed amount=1.00 request_id=req-1 route=/transactions transaction_id=abc\n")
+
+	// the parent context is not changed by its children
+	out.Reset()
+	logger.FromContext(ctx).Info("Request done")
+	assert.Contains(t, out.String(), "Request done request_id=req-1 route=/transactions\n")
+
+	out.Reset()
+	logger.FromContext(context.Background()).Info("No fields")
+	assert.Contains(t, out.String(), "[INFO] No fields\n")
+}
+
+func TestPackageFromContext(t *testing.T) {
+	logger, out := newTestLogger(FormatText)
+	SetDefault(logger)
+	defer SetDefault(New("INFO"))
+
+	ctx := ContextWithField(context.Background(), "request_id", "req-1")
+	FromContext(ctx).Info("Request failed")
+	assert.Contains(t, out.String(), "[INFO] Request failed request_id=req-1\n")
+}
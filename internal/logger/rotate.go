@@ -0,0 +1,382 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat stamps rotated files; it sorts chronologically and holds no
+// characters that are awkward in file names
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+const compressSuffix = ".gz"
+
+// rotationRetryDelay is how long writes go on to the current file after a
+// rotation failed before the next rotation is tried
+const rotationRetryDelay = time.Minute
+
+// RotationConfig controls when a log file is rotated and how long the rotated
+// files are kept. Zero values disable the matching rule.
+type RotationConfig struct {
+	// MaxSize rotates the file before a write would take it past this many bytes
+	MaxSize int64
+	// Interval rotates the file at every multiple of the interval (UTC), so
+	// 24h rotates at midnight
+	Interval time.Duration
+	// MaxAge removes rotated files older than this
+	MaxAge time.Duration
+	// MaxBackups keeps at most this many rotated files
+	MaxBackups int
+	// Compress gzips rotated files
+	Compress bool
+}
+
+// RotatingFile is a log file that rotates itself by size and time. Rotated
+// files are renamed to <name>-<time><ext> next to it, then compressed and
+// pruned in the background. When the file cannot be renamed it is written
+// under its own name until the next try; when it cannot be opened, every
+// write tries again.
+type RotatingFile struct {
+	path   string
+	config RotationConfig
+	now    func() time.Time
+
+	mu           sync.Mutex
+	file         *os.File
+	closed       bool
+	size         int64
+	nextRotation time.Time
+	// retryRotation holds rotations off until then after one failed
+	retryRotation time.Time
+
+	// millMu serializes compression and pruning; mills tracks the running ones
+	// so Close can wait for them
+	millMu sync.Mutex
+	mills  sync.WaitGroup
+}
+
+// OpenRotatingFile opens or creates the file at path. A file last written
+// before the current rotation interval began is rotated right away.
+func OpenRotatingFile(path string, config RotationConfig) (*RotatingFile, error) {
+	f := &RotatingFile{
+		path:   path,
+		config: config,
+		now:    time.Now,
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	if f.size > 0 && config.Interval > 0 {
+		info, err := f.file.Stat()
+		if err != nil {
+			f.file.Close()
+			return nil, fmt.Errorf("failed to stat log file: %w", err)
+		}
+		if info.ModTime().Before(f.now().UTC().Truncate(config.Interval)) {
+			if err := f.rotate(); err != nil {
+				f.closeFile()
+				return nil, err
+			}
+		}
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return 0, fmt.Errorf("log file %s is closed", f.path)
+	}
+
+	// a failed rotation or reopen left no file open
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			if f.file == nil {
+				return 0, err
+			}
+			// the file is open under its own name again, so the line is
+			// not lost
+			fmt.Fprintf(os.Stderr, "log rotation: %v\n", err)
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Reopen closes the file and opens the one now at its path. Tools like
+// logrotate rename the file and then signal the service to reopen it. When
+// the new file cannot be opened, the next write tries again.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return fmt.Errorf("log file %s is closed", f.path)
+	}
+
+	if err := f.closeFile(); err != nil {
+		return err
+	}
+
+	return f.open()
+}
+
+// Close closes the file and waits for background compression and pruning
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	f.closed = true
+	err := f.closeFile()
+	f.mu.Unlock()
+
+	f.mills.Wait()
+
+	return err
+}
+
+// closeFile closes the open file, if any. The file is unusable afterwards
+// even when closing it fails, so it is dropped either way.
+func (f *RotatingFile) closeFile() error {
+	if f.file == nil {
+		return nil
+	}
+
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	return nil
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	if f.config.Interval > 0 {
+		f.nextRotation = f.now().UTC().Truncate(f.config.Interval).Add(f.config.Interval)
+	}
+
+	return nil
+}
+
+func (f *RotatingFile) shouldRotate(writeSize int64) bool {
+	if f.size == 0 || f.now().Before(f.retryRotation) {
+		return false
+	}
+	if f.config.MaxSize > 0 && f.size+writeSize > f.config.MaxSize {
+		return true
+	}
+	return f.config.Interval > 0 && !f.now().Before(f.nextRotation)
+}
+
+// rotate renames the current file out of the way and opens a new one. When
+// the rename fails the file is opened again under its own name, and rotations
+// are held off for rotationRetryDelay; when the open fails no file is left
+// open, and the next write opens it.
+func (f *RotatingFile) rotate() error {
+	if err := f.closeFile(); err != nil {
+		f.retryRotation = f.now().Add(rotationRetryDelay)
+		return err
+	}
+
+	// a second rotation within the same millisecond gets the next free name
+	rotatedAt := f.now()
+	for {
+		if !fileExists(f.backupName(rotatedAt)) && !fileExists(f.backupName(rotatedAt)+compressSuffix) {
+			break
+		}
+		rotatedAt = rotatedAt.Add(time.Millisecond)
+	}
+
+	if err := os.Rename(f.path, f.backupName(rotatedAt)); err != nil {
+		f.retryRotation = f.now().Add(rotationRetryDelay)
+		if openErr := f.open(); openErr != nil {
+			return fmt.Errorf("failed to rotate log file: %w; %v", err, openErr)
+		}
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.mills.Add(1)
+	go func() {
+		defer f.mills.Done()
+		f.mill(rotatedAt)
+	}()
+
+	return nil
+}
+
+func (f *RotatingFile) backupName(t time.Time) string {
+	dir, prefix, ext := f.nameParts()
+	return filepath.Join(dir, prefix+t.UTC().Format(backupTimeFormat)+ext)
+}
+
+func (f *RotatingFile) nameParts() (dir, prefix, ext string) {
+	dir = filepath.Dir(f.path)
+	base := filepath.Base(f.path)
+	ext = filepath.Ext(base)
+	return dir, strings.TrimSuffix(base, ext) + "-", ext
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return !os.IsNotExist(err)
+}
+
+type backup struct {
+	path       string
+	rotatedAt  time.Time
+	compressed bool
+}
+
+// backups lists the rotated files, newest first
+func (f *RotatingFile) backups() ([]backup, error) {
+	dir, prefix, ext := f.nameParts()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list log directory: %w", err)
+	}
+
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		stamp := strings.TrimPrefix(name, prefix)
+		compressed := strings.HasSuffix(stamp, ext+compressSuffix)
+		if compressed {
+			stamp = strings.TrimSuffix(stamp, ext+compressSuffix)
+		} else if strings.HasSuffix(stamp, ext) {
+			stamp = strings.TrimSuffix(stamp, ext)
+		} else {
+			continue
+		}
+
+		rotatedAt, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, backup{
+			path:       filepath.Join(dir, name),
+			rotatedAt:  rotatedAt,
+			compressed: compressed,
+		})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].rotatedAt.After(backups[j].rotatedAt) })
+	return backups, nil
+}
+
+// mill removes the rotated files past MaxBackups or MaxAge and compresses
+// the rest. Errors are reported on stderr, as the log itself is the file.
+func (f *RotatingFile) mill(now time.Time) {
+	f.millMu.Lock()
+	defer f.millMu.Unlock()
+
+	backups, err := f.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log rotation: %v\n", err)
+		return
+	}
+
+	cutoff := now.Add(-f.config.MaxAge)
+	for i, backup := range backups {
+		expired := f.config.MaxAge > 0 && backup.rotatedAt.Before(cutoff)
+		if expired || (f.config.MaxBackups > 0 && i >= f.config.MaxBackups) {
+			if err := os.Remove(backup.path); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "log rotation: failed to remove %s: %v\n", backup.path, err)
+			}
+			continue
+		}
+
+		if f.config.Compress && !backup.compressed {
+			if err := compressFile(backup.path); err != nil {
+				fmt.Fprintf(os.Stderr, "log rotation: %v\n", err)
+			}
+		}
+	}
+}
+
+// compressFile gzips path to path.gz and removes path
+func compressFile(path string) error {
+	source, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer source.Close()
+
+	// write to a temporary name so a crash never leaves a truncated .gz behind
+	target := path + compressSuffix
+	partial := target + ".tmp"
+	destination, err := os.OpenFile(partial, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", partial, err)
+	}
+
+	gz := gzip.NewWriter(destination)
+	_, err = io.Copy(gz, source)
+	if err == nil {
+		err = gz.Close()
+	}
+	if closeErr := destination.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(partial)
+		return fmt.Errorf("failed to compress %s: %w", path, err)
+	}
+
+	if err := os.Rename(partial, target); err != nil {
+		return fmt.Errorf("failed to compress %s: %w", path, err)
+	}
+	source.Close()
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func listDir(t *testing.T, dir string) []string {
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readGzip(t *testing.T, path string) string {
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	require.NoError(t, err)
+	content, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestRotateBySize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "service.log")
+
+	file, err := OpenRotatingFile(path, RotationConfig{MaxSize: 10, MaxBackups: 2, Compress: true})
+	require.NoError(t, err)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	file.now = func() time.Time { return now }
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err := file.Write([]byte(line))
+		require.NoError(t, err)
+		now = now.Add(time.Second)
+	}
+	require.NoError(t, file.Close())
+
+	// the oldest backup is pruned, the others are compressed
+	assert.Equal(t, []string{
+		"service-2024-05-01T12-00-02.000.log.gz",
+		"service-2024-05-01T12-00-03.000.log.gz",
+		"service.log",
+	}, listDir(t, dir))
+	assert.Equal(t, "second\n", readGzip(t, filepath.Join(dir, "service-2024-05-01T12-00-02.000.log.gz")))
+	assert.Equal(t, "third\n", readGzip(t, filepath.Join(dir, "service-2024-05-01T12-00-03.000.log.gz")))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "fourth\n", string(content))
+}
+
+func TestRotateByInterval(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "service.log")
+
+	now := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+	file := &RotatingFile{path: path, config: RotationConfig{Interval: 24 * time.Hour, MaxAge: 48 * time.Hour}, now: func() time.Time { return now }}
+	require.NoError(t, file.open())
+
+	// an old backup past MaxAge is removed on the next rotation
+	old := filepath.Join(dir, "service-2024-04-01T00-00-00.000.log")
+	require.NoError(t, os.WriteFile(old, []byte("old\n"), 0666))
+
+	_, err := file.Write([]byte("before midnight\n"))
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	_, err = file.Write([]byte("after midnight\n"))
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	assert.Equal(t, []string{"service-2024-05-02T00-01-00.000.log", "service.log"}, listDir(t, dir))
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "after midnight\n", string(content))
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "service.log")
+
+	logger := New("INFO")
+	require.NoError(t, logger.SetLogFile(path))
+	logger.output = logger.file
+	defer logger.Close()
+
+	logger.Info("before")
+
+	// what logrotate does before sending SIGHUP
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, logger.Reopen())
+
+	logger.Info("after")
+
+	rotated, err := os.ReadFile(path + ".1")
+	require.NoError(t, err)
+	assert.Contains(t, string(rotated), "before")
+	assert.NotContains(t, string(rotated), "after")
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(current), "after")
+}
+
+func TestRotateFailureKeepsLogging(t *testing.T) {
+	tests := []struct {
+		name        string
+		breakRename func(t *testing.T, dir, path string) (restore func())
+	}{
+		{
+			name: "read-only directory",
+			breakRename: func(t *testing.T, dir, path string) func() {
+				if os.Geteuid() == 0 {
+					t.Skip("root can rename files in a read-only directory")
+				}
+				require.NoError(t, os.Chmod(dir, 0555))
+				return func() { require.NoError(t, os.Chmod(dir, 0755)) }
+			},
+		},
+		{
+			name: "file removed",
+			breakRename: func(t *testing.T, dir, path string) func() {
+				require.NoError(t, os.Remove(path))
+				return func() {}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "service.log")
+
+			file, err := OpenRotatingFile(path, RotationConfig{MaxSize: 10})
+			require.NoError(t, err)
+			now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			file.now = func() time.Time { return now }
+			defer file.Close()
+
+			_, err = file.Write([]byte("first\n"))
+			require.NoError(t, err)
+
+			restore := tt.breakRename(t, dir, path)
+			_, err = file.Write([]byte("second\n"))
+			require.NoError(t, err, "a failed rename keeps writing to the file")
+			restore()
+
+			content, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Contains(t, string(content), "second\n")
+
+			// the rotation is tried again once the retry delay has passed
+			now = now.Add(rotationRetryDelay)
+			_, err = file.Write([]byte("third\n"))
+			require.NoError(t, err)
+
+			content, err = os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, "third\n", string(content))
+			assert.Len(t, listDir(t, dir), 2)
+		})
+	}
+}
+
+func TestReopenFailureRetriedOnWrite(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "service.log")
+
+	file, err := OpenRotatingFile(path, RotationConfig{})
+	require.NoError(t, err)
+	defer file.Close()
+
+	// a directory in place of the file makes the open fail
+	require.NoError(t, os.Rename(path, path+".1"))
+	require.NoError(t, os.Mkdir(path, 0755))
+	assert.Error(t, file.Reopen())
+	_, err = file.Write([]byte("lost\n"))
+	assert.Error(t, err)
+
+	require.NoError(t, os.Remove(path))
+	_, err = file.Write([]byte("kept\n"))
+	require.NoError(t, err, "the next write opens the file again")
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "kept\n", string(content))
+
+	require.NoError(t, file.Close())
+	_, err = file.Write([]byte("closed\n"))
+	assert.Error(t, err, "a closed file stays closed")
+}
@@ -0,0 +1,150 @@
+// Package metrics holds the Prometheus metrics of the service and the handler
+// that exposes them. Label values are limited to HTTP methods, route
+// templates, status codes, fixed transfer outcomes and the build information,
+// so the number of series stays bounded whatever the traffic.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "txn"
+
+// Version is the service version reported by txn_build_info, set at build
+// time with -ldflags "-X txn-service/internal/metrics.Version=..."
+var Version = "dev"
+
+// Transfer outcomes other than the failure reasons of the service errors
+const (
+	OutcomeCompleted = "completed"
+	OutcomeError     = "error"
+)
+
+var (
+	registry = prometheus.NewRegistry()
+
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route template and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route template and status code.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"method", "route", "status"})
+
+	transfers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "transfers_total",
+		Help:      "Transfer requests by outcome: completed or the failure reason.",
+	}, []string{"outcome"})
+
+	transferAmount = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "transfer_amount",
+		Help:      "Amounts of completed transfers.",
+		Buckets:   prometheus.ExponentialBuckets(1, 10, 8),
+	})
+
+	transferLockWait = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "transfer_lock_wait_seconds",
+		Help:      "Time a transfer waited to lock both of its accounts.",
+		Buckets:   []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	})
+
+	buildInfo = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "build_info",
+		Help:      "Always 1, labeled with the version, VCS revision and Go version of the binary.",
+	}, []string{"version", "revision", "go_version"})
+
+	// dbStats is the collector of the database registered last
+	dbStatsMu sync.Mutex
+	dbStats   prometheus.Collector
+)
+
+func init() {
+	registry.MustRegister(
+		httpRequests,
+		httpRequestDuration,
+		transfers,
+		transferAmount,
+		transferLockWait,
+		buildInfo,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	buildInfo.WithLabelValues(Version, revision(), runtime.Version()).Set(1)
+}
+
+// revision is the VCS revision the binary was built from, when Go recorded it
+func revision() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// RegisterDB exports the connection pool stats of db as the go_sql_* metrics.
+// A database registered before is replaced.
+func RegisterDB(db *sql.DB) {
+	dbStatsMu.Lock()
+	defer dbStatsMu.Unlock()
+
+	if dbStats != nil {
+		registry.Unregister(dbStats)
+	}
+	dbStats = collectors.NewDBStatsCollector(db, "txn_service")
+	registry.MustRegister(dbStats)
+}
+
+// ObserveHTTPRequest records a served HTTP request. route is the route
+// template, never the raw path, to keep the label bounded.
+func ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpRequestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveTransfer records the outcome of a transfer request, and the amount of
+// a completed one. amount is the decimal string of the request; amounts that
+// do not parse are only counted.
+func ObserveTransfer(outcome, amount string) {
+	transfers.WithLabelValues(outcome).Inc()
+
+	if outcome != OutcomeCompleted {
+		return
+	}
+	if value, err := strconv.ParseFloat(amount, 64); err == nil {
+		transferAmount.Observe(value)
+	}
+}
+
+// ObserveTransferLockWait records how long a transfer waited for its row locks
+func ObserveTransferLockWait(duration time.Duration) {
+	transferLockWait.Observe(duration.Seconds())
+}
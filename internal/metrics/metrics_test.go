@@ -0,0 +1,53 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveTransfer(t *testing.T) {
+	completed := testutil.ToFloat64(transfers.WithLabelValues(OutcomeCompleted))
+	rejected := testutil.ToFloat64(transfers.WithLabelValues("insufficient_funds"))
+
+	observed := sampleCount(t)
+
+	ObserveTransfer(OutcomeCompleted, "12.50")
+	ObserveTransfer("insufficient_funds", "1000.00")
+	ObserveTransfer(OutcomeCompleted, "not a number")
+
+	assert.Equal(t, completed+2, testutil.ToFloat64(transfers.WithLabelValues(OutcomeCompleted)))
+	assert.Equal(t, rejected+1, testutil.ToFloat64(transfers.WithLabelValues("insufficient_funds")))
+
+	// only the parsed amount of the completed transfer is observed
+	assert.Equal(t, observed+1, sampleCount(t))
+}
+
+func sampleCount(t *testing.T) uint64 {
+	t.Helper()
+	var metric dto.Metric
+	require.NoError(t, transferAmount.Write(&metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+func TestHandler(t *testing.T) {
+	ObserveHTTPRequest(http.MethodGet, "/accounts/{account_id}", http.StatusOK, 20*time.Millisecond)
+	ObserveTransferLockWait(time.Millisecond)
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `txn_http_requests_total{method="GET",route="/accounts/{account_id}",status="200"} 1`)
+	assert.Contains(t, body, `txn_http_request_duration_seconds_bucket{method="GET",route="/accounts/{account_id}",status="200",le="0.025"} 1`)
+	assert.Contains(t, body, `txn_transfer_lock_wait_seconds_count 1`)
+	assert.Contains(t, body, `txn_build_info{go_version=`)
+	assert.Contains(t, body, `go_goroutines`)
+}
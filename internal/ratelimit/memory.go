@@ -0,0 +1,61 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     Limit
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewMemoryStore keeps the buckets in process memory. Each replica then
+// enforces the limits on its own, so a client spread over n replicas gets up
+// to n times its limit.
+func NewMemoryStore() Store {
+	return &memoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *memoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	var allowed bool
+	b.tokens, allowed = take(b.tokens, now.Sub(b.updatedAt), limit)
+	b.updatedAt = now
+	b.limit = limit
+
+	return newResult(b.tokens, allowed, limit), nil
+}
+
+func (s *memoryStore) Cleanup(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, b := range s.buckets {
+		if now.Sub(b.updatedAt) >= b.limit.refillDuration() {
+			delete(s.buckets, key)
+		}
+	}
+	return nil
+}
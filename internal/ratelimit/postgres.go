@@ -0,0 +1,72 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// refilled is the token count of the existing bucket b after refilling it for
+// the time since its last update, capped at the burst ($2) with the rate ($3)
+const refilled = `LEAST($2::double precision, b.tokens + GREATEST(EXTRACT(EPOCH FROM now() - b.updated_at)::double precision, 0) * $3::double precision)`
+
+// takeQuery refills and takes from a bucket in a single statement, so the row
+// lock of the upsert serializes concurrent requests of a client across replicas
+const takeQuery = `
+	INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+	VALUES ($1, $2::double precision - 1, TRUE, now())
+	ON CONFLICT (key) DO UPDATE SET
+		tokens = CASE WHEN ` + refilled + ` >= 1 THEN ` + refilled + ` - 1 ELSE ` + refilled + ` END,
+		allowed = ` + refilled + ` >= 1,
+		updated_at = now()
+	RETURNING tokens, allowed`
+
+type postgresStore struct {
+	db *sql.DB
+
+	// maxRefill is the longest refill duration of the limits seen so far;
+	// buckets idle for longer are full and can be deleted
+	mu        sync.Mutex
+	maxRefill time.Duration
+}
+
+// NewPostgresStore keeps the buckets in the rate_limit_buckets table, so all
+// replicas share them. Each Take costs one upsert on db, which is usually the
+// pool the API queries run on.
+func NewPostgresStore(db *sql.DB) Store {
+	return &postgresStore{db: db}
+}
+
+func (s *postgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	if refill := limit.refillDuration(); refill > s.maxRefill {
+		s.maxRefill = refill
+	}
+	s.mu.Unlock()
+
+	var tokens float64
+	var allowed bool
+	if err := s.db.QueryRowContext(ctx, takeQuery, key, float64(limit.Burst), limit.Rate).Scan(&tokens, &allowed); err != nil {
+		return Result{}, fmt.Errorf("failed to take rate limit token: %w", err)
+	}
+
+	return newResult(tokens, allowed, limit), nil
+}
+
+func (s *postgresStore) Cleanup(ctx context.Context) error {
+	s.mu.Lock()
+	maxRefill := s.maxRefill
+	s.mu.Unlock()
+
+	if maxRefill == 0 {
+		return nil
+	}
+
+	_, err := s.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < now() - $1::double precision * INTERVAL '1 second'", maxRefill.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to clean up rate limit buckets: %w", err)
+	}
+	return nil
+}
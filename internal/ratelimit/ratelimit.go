@@ -0,0 +1,205 @@
+// Package ratelimit throttles API clients with token buckets. Every client has
+// one bucket for reads and one for writes, and every IP address one more for
+// all of its requests before they are authenticated; the buckets live in a
+// Store, which is either local to the process or shared by all replicas
+// through Postgres.
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/logger"
+)
+
+// Limit is a token bucket refilled with Rate tokens per second up to Burst
+// tokens. Each request takes one token.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// refillDuration is how long an empty bucket takes to fill up again
+func (l Limit) refillDuration() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// Limits are the limits of each class
+type Limits struct {
+	Read  Limit
+	Write Limit
+	IP    Limit
+}
+
+// Class separates the read and write limits of a client. IP is the limit of
+// an IP address over all routes, applied before the caller is authenticated.
+type Class string
+
+const (
+	Read  Class = "read"
+	Write Class = "write"
+	IP    Class = "ip"
+)
+
+// ClassOf returns the class of the requests that require scope
+func ClassOf(scope string) Class {
+	if strings.HasSuffix(scope, ":write") {
+		return Write
+	}
+	return Read
+}
+
+// Result is the state of a bucket after a request tried to take a token
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long a rejected client has to wait for the next token
+	RetryAfter time.Duration
+	// Reset is how long the bucket takes to fill up completely
+	Reset time.Duration
+}
+
+// Store keeps the token buckets. Take must be atomic per key, so concurrent
+// requests of one client cannot take the same token.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+	// Cleanup forgets buckets that have been full for a while; a forgotten
+	// bucket behaves exactly like a full one
+	Cleanup(ctx context.Context) error
+}
+
+// take refills a bucket holding tokens for the time elapsed since its last
+// update and takes a token if one is available
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, bool) {
+	if elapsed > 0 {
+		tokens = math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+	}
+	if tokens < 1 {
+		return tokens, false
+	}
+	return tokens - 1, true
+}
+
+func newResult(tokens float64, allowed bool, limit Limit) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+	return result
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Max(0, seconds) * float64(time.Second))
+}
+
+// Limiter applies the limits to clients
+type Limiter struct {
+	store  Store
+	limits atomic.Pointer[LimitsFunc]
+	logger *logger.Logger
+}
+
+// LimitsFunc returns the limits in force. All of them come from one call, so a
+// request never sees the read limit of one configuration and the write limit
+// of another.
+type LimitsFunc func() Limits
+
+func NewLimiter(store Store, limits Limits, log *logger.Logger) *Limiter {
+	limiter := &Limiter{
+		store:  store,
+		logger: log,
+	}
+	limiter.FollowLimits(func() Limits { return limits })
+	return limiter
+}
+
+// FollowLimits makes the limiter ask limits for the limits of every request.
+// Buckets keep their tokens and refill at the new rate when the limits change.
+func (l *Limiter) FollowLimits(limits LimitsFunc) {
+	l.limits.Store(&limits)
+}
+
+// Allow takes a token from the client's bucket of the class. When the store
+// fails the request is let through: an unavailable rate limiter must not take
+// the API down with it.
+func (l *Limiter) Allow(ctx context.Context, client string, class Class) Result {
+	limits := (*l.limits.Load())()
+	limit := limits.Read
+	switch class {
+	case Write:
+		limit = limits.Write
+	case IP:
+		limit = limits.IP
+	}
+
+	result, err := l.store.Take(ctx, client+":"+string(class), limit)
+	if err != nil {
+		l.logger.FromContext(ctx).Error("Rate limit check failed, allowing request - client: %s, error: %v", client, err)
+		return Result{Allowed: true, Limit: limit.Burst, Remaining: limit.Burst}
+	}
+
+	if !result.Allowed {
+		l.logger.FromContext(ctx).Warn("Rate limit exceeded - client: %s, class: %s", client, class)
+	}
+	return result
+}
+
+// ClientKey identifies the client a bucket belongs to: the credentials of an
+// authenticated principal, or the IP address of remoteAddr otherwise
+func ClientKey(principal *auth.Principal, remoteAddr string) string {
+	switch {
+	case principal == nil:
+	case principal.APIKeyID != "":
+		return "key:" + principal.APIKeyID
+	case principal.SigningClientID != "":
+		return "hmac:" + principal.SigningClientID
+	case principal.Subject != "":
+		return "jwt:" + principal.TenantID + ":" + principal.Subject
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	return "ip:" + host
+}
+
+// AllowIP takes a token from the IP bucket of remoteAddr. It runs before
+// authentication, so floods of missing or invalid credentials are throttled
+// before they cost a credential lookup.
+func (l *Limiter) AllowIP(ctx context.Context, remoteAddr string) Result {
+	return l.Allow(ctx, ClientKey(nil, remoteAddr), IP)
+}
+
+// Validate checks that the limit lets at least one request through
+func (l Limit) Validate() error {
+	if l.Rate <= 0 || l.Burst < 1 {
+		return fmt.Errorf("invalid rate limit %g/s with burst %d: the rate must be positive and the burst at least 1", l.Rate, l.Burst)
+	}
+	return nil
+}
+
+// NewStore creates the store named kind, "memory" or "postgres"
+func NewStore(kind string, db *sql.DB) (Store, error) {
+	switch kind {
+	case "memory":
+		return NewMemoryStore(), nil
+	case "postgres":
+		return NewPostgresStore(db), nil
+	default:
+		return nil, fmt.Errorf("unknown rate limit store %q, must be memory or postgres", kind)
+	}
+}
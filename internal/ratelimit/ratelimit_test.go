@@ -0,0 +1,97 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	store := NewMemoryStore().(*memoryStore)
+	store.now = func() time.Time { return now }
+
+	limit := Limit{Rate: 2, Burst: 3}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(ctx, "client", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+		assert.Equal(t, 3, result.Limit)
+	}
+
+	result, err := store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, result.Reset)
+
+	// other clients have their own bucket
+	result, _ = store.Take(ctx, "other", limit)
+	assert.True(t, result.Allowed)
+
+	// the bucket refills at the rate
+	now = now.Add(500 * time.Millisecond)
+	result, _ = store.Take(ctx, "client", limit)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// and never beyond the burst
+	now = now.Add(time.Hour)
+	result, _ = store.Take(ctx, "client", limit)
+	assert.Equal(t, 2, result.Remaining)
+
+	require.NoError(t, store.Cleanup(ctx))
+	assert.Len(t, store.buckets, 1, "the idle bucket of other is forgotten")
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	return Result{}, errors.New("database is down")
+}
+
+func (failingStore) Cleanup(ctx context.Context) error { return nil }
+
+func TestLimiter(t *testing.T) {
+	limits := Limits{Read: Limit{Rate: 1, Burst: 2}, Write: Limit{Rate: 1, Burst: 1}, IP: Limit{Rate: 1, Burst: 1}}
+	limiter := NewLimiter(NewMemoryStore(), limits, logger.New("ERROR"))
+	ctx := context.Background()
+
+	assert.True(t, limiter.Allow(ctx, "client", Write).Allowed)
+	assert.False(t, limiter.Allow(ctx, "client", Write).Allowed)
+	assert.True(t, limiter.Allow(ctx, "client", Read).Allowed, "reads have their own bucket")
+
+	assert.True(t, limiter.AllowIP(ctx, "10.0.0.1:5000").Allowed)
+	assert.False(t, limiter.AllowIP(ctx, "10.0.0.1:6000").Allowed, "the IP bucket is shared by all ports")
+	assert.True(t, limiter.Allow(ctx, "ip:10.0.0.1", Read).Allowed, "the IP bucket is apart from the read bucket of the address")
+
+	// followed limits apply from the next request on
+	write := Limit{Rate: 1, Burst: 1}
+	limiter.FollowLimits(func() Limits { return Limits{Read: limits.Read, Write: write, IP: limits.IP} })
+	write = Limit{Rate: 1, Burst: 3}
+	assert.Equal(t, 3, limiter.Allow(ctx, "other", Write).Limit)
+
+	limiter = NewLimiter(failingStore{}, limits, logger.New("ERROR"))
+	assert.True(t, limiter.Allow(ctx, "client", Read).Allowed, "a failing store lets requests through")
+}
+
+func TestClientKey(t *testing.T) {
+	assert.Equal(t, "key:abc", ClientKey(&auth.Principal{APIKeyID: "abc"}, "10.0.0.1:5000"))
+	assert.Equal(t, "hmac:partner", ClientKey(&auth.Principal{SigningClientID: "partner"}, "10.0.0.1:5000"))
+	assert.Equal(t, "jwt:acme:user-1", ClientKey(&auth.Principal{Subject: "user-1", TenantID: "acme"}, "10.0.0.1:5000"))
+	assert.Equal(t, "ip:10.0.0.1", ClientKey(nil, "10.0.0.1:5000"))
+	assert.Equal(t, "ip:::1", ClientKey(nil, "[::1]:5000"))
+
+	assert.Equal(t, Write, ClassOf(auth.ScopeTransactionsWrite))
+	assert.Equal(t, Read, ClassOf(auth.ScopeAccountsRead))
+}
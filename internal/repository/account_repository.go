@@ -3,15 +3,21 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
+	"txn-service/internal/accountnumber"
 	"txn-service/internal/logger"
+	"txn-service/internal/tenant"
 	"txn-service/models"
 )
 
 type AccountRepository interface {
 	Create(ctx context.Context, account *models.Account) error
 	GetByAccountID(ctx context.Context, accountID int64) (*models.Account, error)
+	ListByCustomerID(ctx context.Context, customerID int64) ([]*models.Account, error)
+	SetFrozen(ctx context.Context, accountID int64, frozen bool) (*models.Account, error)
+	ListNegativeBalances(ctx context.Context) ([]*models.Account, error)
 }
 
 type accountRepository struct {
@@ -19,15 +25,17 @@ type accountRepository struct {
 	logger *logger.Logger
 }
 
-func NewAccountRepository(db *sql.DB) AccountRepository {
+func NewAccountRepository(db *sql.DB, log *logger.Logger) AccountRepository {
 	return &accountRepository{
 		db:     db,
-		logger: logger.NewFromEnv(),
+		logger: log,
 	}
 }
 
 func (r *accountRepository) Create(ctx context.Context, account *models.Account) error {
-	entry := r.logger.WithFields(map[string]interface{}{
+	tenantID := tenant.FromContext(ctx)
+	entry := r.logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id":  tenantID,
 		"account_id": account.AccountID,
 		"balance":    account.Balance,
 	})
@@ -37,73 +45,199 @@ func (r *accountRepository) Create(ctx context.Context, account *models.Account)
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead})
 	if err != nil {
 		entry.Error("Failed to begin transaction: %v", err)
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", classifyDBError(err))
 	}
 
 	defer tx.Rollback()
 
+	if account.AccountID == 0 {
+		entry.Debug("Allocating account ID from sequence")
+		accountID, err := r.allocateAccountID(ctx, tx)
+		if err != nil {
+			entry.Error("Failed to allocate account ID: %v", err)
+			return fmt.Errorf("failed to allocate account ID: %w", err)
+		}
+		account.AccountID = accountID
+		entry = entry.WithField("account_id", accountID)
+	}
+
 	entry.Debug("Checking if account exists")
 	exists, err := r.accountExistsWithLock(ctx, tx, account.AccountID)
 	if err != nil {
 		entry.Error("Failed to check account existence: %v", err)
-		return fmt.Errorf("failed to check account existence: %w", err)
+		return fmt.Errorf("failed to check account existence: %w", classifyDBError(err))
 	}
 
 	if exists {
 		entry.Warn("Account already exists")
-		return fmt.Errorf("account with ID %d already exists", account.AccountID)
+		return &DuplicateError{Resource: ResourceAccount, Field: "ID", Value: account.AccountID}
 	}
 
 	entry.Debug("Creating new account")
 	query := `
-		INSERT INTO accounts (account_id, balance)
-		VALUES ($1, $2)
+		INSERT INTO accounts (tenant_id, account_id, balance, product_code, customer_id)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at, updated_at`
 
-	err = tx.QueryRowContext(ctx, query, account.AccountID, account.Balance).
+	err = tx.QueryRowContext(ctx, query, tenantID, account.AccountID, account.Balance, account.ProductCode, account.CustomerID).
 		Scan(&account.ID, &account.CreatedAt, &account.UpdatedAt)
 
 	if err != nil {
 		entry.Error("Failed to insert account: %v", err)
+		err = classifyDBError(err)
+		if errors.Is(err, ErrDuplicate) {
+			// another request created the same account between our check and the insert
+			return &DuplicateError{Resource: ResourceAccount, Field: "ID", Value: account.AccountID}
+		}
 		return fmt.Errorf("failed to create account: %w", err)
 	}
 
+	account.AccountNumber = accountnumber.Format(account.AccountID)
+
 	entry.Debug("Account created successfully, DB_ID: %d", account.ID)
-	return tx.Commit()
+	return classifyDBError(tx.Commit())
 }
 
 func (r *accountRepository) GetByAccountID(ctx context.Context, accountID int64) (*models.Account, error) {
 	query := `
-		SELECT id, account_id, balance, created_at, updated_at
+		SELECT id, account_id, balance, product_code, customer_id, frozen_at, created_at, updated_at
 		FROM accounts
-		WHERE account_id = $1`
+		WHERE tenant_id = $1 AND account_id = $2`
 
 	account := &models.Account{}
-	err := r.db.QueryRowContext(ctx, query, accountID).
-		Scan(&account.ID, &account.AccountID, &account.Balance, &account.CreatedAt, &account.UpdatedAt)
+	err := r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), accountID).
+		Scan(&account.ID, &account.AccountID, &account.Balance, &account.ProductCode, &account.CustomerID, &account.FrozenAt, &account.CreatedAt, &account.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("account not found: %d", accountID)
+			return nil, &NotFoundError{Resource: ResourceAccount, ID: accountID}
 		}
-		return nil, fmt.Errorf("failed to get account: %w", err)
+		return nil, fmt.Errorf("failed to get account: %w", classifyDBError(err))
 	}
 
+	account.AccountNumber = accountnumber.Format(account.AccountID)
 	return account, nil
 }
 
+func (r *accountRepository) ListByCustomerID(ctx context.Context, customerID int64) ([]*models.Account, error) {
+	query := `
+		SELECT id, account_id, balance, product_code, customer_id, frozen_at, created_at, updated_at
+		FROM accounts
+		WHERE tenant_id = $1 AND customer_id = $2
+		ORDER BY account_id`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.FromContext(ctx), customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", classifyDBError(err))
+	}
+	defer rows.Close()
+
+	accounts := []*models.Account{}
+	for rows.Next() {
+		account := &models.Account{}
+		if err := rows.Scan(&account.ID, &account.AccountID, &account.Balance, &account.ProductCode, &account.CustomerID, &account.FrozenAt, &account.CreatedAt, &account.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", classifyDBError(err))
+		}
+		account.AccountNumber = accountnumber.Format(account.AccountID)
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+// SetFrozen freezes or unfreezes the account. Freezing an account that is
+// already frozen keeps the time it was first frozen.
+func (r *accountRepository) SetFrozen(ctx context.Context, accountID int64, frozen bool) (*models.Account, error) {
+	query := `
+		UPDATE accounts
+		SET frozen_at = CASE WHEN $3 THEN COALESCE(frozen_at, CURRENT_TIMESTAMP) END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE tenant_id = $1 AND account_id = $2
+		RETURNING id, account_id, balance, product_code, customer_id, frozen_at, created_at, updated_at`
+
+	account := &models.Account{}
+	err := r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), accountID, frozen).
+		Scan(&account.ID, &account.AccountID, &account.Balance, &account.ProductCode, &account.CustomerID, &account.FrozenAt, &account.CreatedAt, &account.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &NotFoundError{Resource: ResourceAccount, ID: accountID}
+		}
+		return nil, fmt.Errorf("failed to update account: %w", classifyDBError(err))
+	}
+
+	r.logger.FromContext(ctx).WithField("account_id", accountID).Info("Account frozen: %t", frozen)
+
+	account.AccountNumber = accountnumber.Format(account.AccountID)
+	return account, nil
+}
+
+// ListNegativeBalances returns the accounts of the tenant whose balance is
+// below zero, which only internal accounts may be
+func (r *accountRepository) ListNegativeBalances(ctx context.Context) ([]*models.Account, error) {
+	query := `
+		SELECT id, account_id, balance, product_code, customer_id, frozen_at, created_at, updated_at
+		FROM accounts
+		WHERE tenant_id = $1 AND balance < 0
+		ORDER BY account_id`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.FromContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", classifyDBError(err))
+	}
+	defer rows.Close()
+
+	accounts := []*models.Account{}
+	for rows.Next() {
+		account := &models.Account{}
+		if err := rows.Scan(&account.ID, &account.AccountID, &account.Balance, &account.ProductCode, &account.CustomerID, &account.FrozenAt, &account.CreatedAt, &account.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", classifyDBError(err))
+		}
+		account.AccountNumber = accountnumber.Format(account.AccountID)
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+// maxAccountIDAllocationAttempts bounds how many sequence values are skipped
+// because a client already picked them as its own account ID
+const maxAccountIDAllocationAttempts = 10
+
+// allocateAccountID takes the next free value of the sequence, which is shared
+// by all tenants
+func (r *accountRepository) allocateAccountID(ctx context.Context, tx *sql.Tx) (int64, error) {
+	for attempt := 0; attempt < maxAccountIDAllocationAttempts; attempt++ {
+		var accountID int64
+		if err := tx.QueryRowContext(ctx, "SELECT nextval('account_id_seq')").Scan(&accountID); err != nil {
+			return 0, fmt.Errorf("failed to get next account ID: %w", classifyDBError(err))
+		}
+
+		exists, err := r.accountExistsWithLock(ctx, tx, accountID)
+		if err != nil {
+			return 0, err
+		}
+
+		if !exists {
+			return accountID, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no free account ID after %d attempts", maxAccountIDAllocationAttempts)
+}
+
 func (r *accountRepository) accountExistsWithLock(ctx context.Context, tx *sql.Tx, accountID int64) (bool, error) {
 	query := `
 		SELECT EXISTS(
-			SELECT 1 FROM accounts 
-			WHERE account_id = $1 
+			SELECT 1 FROM accounts
+			WHERE tenant_id = $1 AND account_id = $2
 			FOR UPDATE
 		)`
 
 	var exists bool
-	err := tx.QueryRowContext(ctx, query, accountID).Scan(&exists)
+	err := tx.QueryRowContext(ctx, query, tenant.FromContext(ctx), accountID).Scan(&exists)
 	if err != nil {
-		return false, fmt.Errorf("failed to check account existence: %w", err)
+		return false, fmt.Errorf("failed to check account existence: %w", classifyDBError(err))
 	}
 
 	return exists, nil
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"txn-service/internal/logger"
+	"txn-service/models"
+
+	"github.com/lib/pq"
+)
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByKeyID(ctx context.Context, keyID string) (*models.APIKey, error)
+	List(ctx context.Context) ([]*models.APIKey, error)
+	Revoke(ctx context.Context, keyID string) error
+}
+
+type apiKeyRepository struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+func NewAPIKeyRepository(db *sql.DB, log *logger.Logger) APIKeyRepository {
+	return &apiKeyRepository{
+		db:     db,
+		logger: log,
+	}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	query := `
+		INSERT INTO api_keys (key_id, name, tenant_id, key_hash, scopes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING created_at`
+
+	err := r.db.QueryRowContext(ctx, query, key.KeyID, key.Name, key.TenantID, key.KeyHash, pq.Array(key.Scopes)).
+		Scan(&key.CreatedAt)
+
+	if err != nil {
+		err = classifyDBError(err)
+		if errors.Is(err, ErrDuplicate) {
+			return &DuplicateError{Resource: ResourceAPIKey, Field: "ID", Value: key.KeyID}
+		}
+		r.logger.FromContext(ctx).Error("Failed to insert API key: %v", err)
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return nil
+}
+
+const apiKeyColumns = `key_id, name, tenant_id, key_hash, scopes, created_at, revoked_at`
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := &models.APIKey{}
+	err := row.Scan(&key.KeyID, &key.Name, &key.TenantID, &key.KeyHash, pq.Array(&key.Scopes), &key.CreatedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (r *apiKeyRepository) GetByKeyID(ctx context.Context, keyID string) (*models.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		WHERE key_id = $1`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, keyID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &NotFoundError{Resource: ResourceAPIKey, ID: keyID}
+		}
+		return nil, fmt.Errorf("failed to get API key: %w", classifyDBError(err))
+	}
+
+	return key, nil
+}
+
+func (r *apiKeyRepository) List(ctx context.Context) ([]*models.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys
+		ORDER BY created_at, key_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", classifyDBError(err))
+	}
+	defer rows.Close()
+
+	keys := []*models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", classifyDBError(err))
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
+
+// Revoke marks the key as revoked; revoking an already revoked key keeps the
+// original revocation time
+func (r *apiKeyRepository) Revoke(ctx context.Context, keyID string) error {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, CURRENT_TIMESTAMP)
+		WHERE key_id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, keyID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", classifyDBError(err))
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", classifyDBError(err))
+	}
+
+	if updated == 0 {
+		return &NotFoundError{Resource: ResourceAPIKey, ID: keyID}
+	}
+
+	return nil
+}
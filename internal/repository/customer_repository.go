@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/tenant"
+	"txn-service/models"
+)
+
+type CustomerRepository interface {
+	Create(ctx context.Context, customer *models.Customer) error
+	GetByID(ctx context.Context, customerID int64) (*models.Customer, error)
+	UpdateKYCStatus(ctx context.Context, customerID int64, kycStatus string) error
+}
+
+type customerRepository struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+func NewCustomerRepository(db *sql.DB, log *logger.Logger) CustomerRepository {
+	return &customerRepository{
+		db:     db,
+		logger: log,
+	}
+}
+
+func (r *customerRepository) Create(ctx context.Context, customer *models.Customer) error {
+	metadata, err := json.Marshal(customer.Metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode customer metadata: %w", err)
+	}
+
+	query := `
+		INSERT INTO customers (tenant_id, name, external_reference, kyc_status, metadata)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id, external_reference) DO NOTHING
+		RETURNING id, created_at, updated_at`
+
+	err = r.db.QueryRowContext(ctx, query,
+		tenant.FromContext(ctx),
+		customer.Name,
+		customer.ExternalReference,
+		customer.KYCStatus,
+		metadata,
+	).Scan(&customer.CustomerID, &customer.CreatedAt, &customer.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &DuplicateError{Resource: ResourceCustomer, Field: "external reference", Value: customer.ExternalReference}
+		}
+		r.logger.FromContext(ctx).Error("Failed to insert customer: %v", err)
+		return fmt.Errorf("failed to create customer: %w", classifyDBError(err))
+	}
+
+	return nil
+}
+
+func (r *customerRepository) GetByID(ctx context.Context, customerID int64) (*models.Customer, error) {
+	query := `
+		SELECT id, name, external_reference, kyc_status, metadata, created_at, updated_at
+		FROM customers
+		WHERE id = $1 AND tenant_id = $2`
+
+	customer := &models.Customer{}
+	var metadata []byte
+	err := r.db.QueryRowContext(ctx, query, customerID, tenant.FromContext(ctx)).
+		Scan(&customer.CustomerID, &customer.Name, &customer.ExternalReference, &customer.KYCStatus, &metadata, &customer.CreatedAt, &customer.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &NotFoundError{Resource: ResourceCustomer, ID: customerID}
+		}
+		return nil, fmt.Errorf("failed to get customer: %w", classifyDBError(err))
+	}
+
+	if err := json.Unmarshal(metadata, &customer.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode customer metadata: %w", err)
+	}
+
+	return customer, nil
+}
+
+func (r *customerRepository) UpdateKYCStatus(ctx context.Context, customerID int64, kycStatus string) error {
+	query := `
+		UPDATE customers
+		SET kyc_status = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND tenant_id = $3`
+
+	result, err := r.db.ExecContext(ctx, query, kycStatus, customerID, tenant.FromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to update kyc status: %w", classifyDBError(err))
+	}
+
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update kyc status: %w", classifyDBError(err))
+	}
+
+	if updated == 0 {
+		return &NotFoundError{Resource: ResourceCustomer, ID: customerID}
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/lib/pq"
+)
+
+// Sentinel errors returned (wrapped) by the repositories. Callers check them
+// with errors.Is instead of matching on error strings.
+var (
+	ErrNotFound              = errors.New("not found")
+	ErrDuplicate             = errors.New("already exists")
+	ErrInsufficientFunds     = errors.New("insufficient balance")
+	ErrConflict              = errors.New("conflicting concurrent update")
+	ErrUnavailable           = errors.New("database unavailable")
+	ErrAccountFrozen         = errors.New("account is frozen")
+	ErrNotReversible         = errors.New("transaction cannot be reversed")
+	ErrKYCNotVerified        = errors.New("account owner is not KYC verified")
+	ErrTransferLimitExceeded = errors.New("transfer limit exceeded")
+)
+
+// Resource names used in NotFoundError and DuplicateError
+const (
+	ResourceAccount     = "account"
+	ResourceCustomer    = "customer"
+	ResourceProduct     = "product"
+	ResourceTransaction = "transaction"
+	ResourceAPIKey      = "api_key"
+)
+
+// NotFoundError reports a missing row; it matches ErrNotFound
+type NotFoundError struct {
+	Resource string
+	ID       interface{}
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%s not found: %v", e.Resource, e.ID)
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// DuplicateError reports a unique key that is already taken; it matches ErrDuplicate
+type DuplicateError struct {
+	Resource string
+	Field    string
+	Value    interface{}
+}
+
+func (e *DuplicateError) Error() string {
+	return fmt.Sprintf("%s with %s %v already exists", e.Resource, e.Field, e.Value)
+}
+
+func (e *DuplicateError) Is(target error) bool {
+	return target == ErrDuplicate
+}
+
+// dbError keeps the original driver error while also matching one of the sentinels
+type dbError struct {
+	kind error
+	err  error
+}
+
+func (e *dbError) Error() string {
+	return e.err.Error()
+}
+
+func (e *dbError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pqUniqueViolation      = "23505"
+	pqSerializationFailure = "40001"
+	pqDeadlockDetected     = "40P01"
+	pqLockNotAvailable     = "55P03"
+	pqTooManyConnections   = "53300"
+	pqAdminShutdown        = "57P01"
+	pqCrashShutdown        = "57P02"
+	pqCannotConnectNow     = "57P03"
+	pqConnectionException  = "08"
+	pqNumericOutOfRange    = "22003"
+)
+
+// maxBalance is the largest value of the DECIMAL(20,8) balance column
+const maxBalance = "999999999999.99999999"
+
+// isNumericOverflow reports whether a value did not fit its numeric column
+func isNumericOverflow(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == pqNumericOutOfRange
+}
+
+// classifyDBError tags driver errors with the matching sentinel so the service
+// and handler layers can tell a retryable conflict or an outage from a bug
+func classifyDBError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pqUniqueViolation:
+			return &dbError{kind: ErrDuplicate, err: err}
+		case pqSerializationFailure, pqDeadlockDetected, pqLockNotAvailable:
+			return &dbError{kind: ErrConflict, err: err}
+		case pqTooManyConnections, pqAdminShutdown, pqCrashShutdown, pqCannotConnectNow:
+			return &dbError{kind: ErrUnavailable, err: err}
+		}
+		if pqErr.Code.Class() == pqConnectionException {
+			return &dbError{kind: ErrUnavailable, err: err}
+		}
+		return err
+	}
+
+	var netErr net.Error
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr) {
+		return &dbError{kind: ErrUnavailable, err: err}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &dbError{kind: ErrUnavailable, err: err}
+	}
+
+	return err
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/tenant"
+	"txn-service/models"
+
+	"github.com/google/uuid"
+)
+
+// InterestRepository works on the accounts and accruals of the tenant in the
+// context; ListTenants lists the tenants the interest job has to visit
+type InterestRepository interface {
+	ListTenants(ctx context.Context) ([]string, error)
+	ListInterestBearingAccounts(ctx context.Context, excludeAccountID int64) ([]*models.InterestBearingAccount, error)
+	GetEndOfDayBalance(ctx context.Context, accountID int64, day time.Time) (string, error)
+	CreateAccrual(ctx context.Context, accrual *models.InterestAccrual) (bool, error)
+	ListPendingPostings(ctx context.Context, before time.Time) ([]*models.PendingInterestPosting, error)
+	PostAccruals(ctx context.Context, accountID int64, month time.Time, expenseAccountID int64) (*models.Transaction, error)
+}
+
+type interestRepository struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+func NewInterestRepository(db *sql.DB, log *logger.Logger) InterestRepository {
+	return &interestRepository{
+		db:     db,
+		logger: log,
+	}
+}
+
+func (r *interestRepository) ListTenants(ctx context.Context) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT DISTINCT tenant_id FROM accounts ORDER BY tenant_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", classifyDBError(err))
+	}
+	defer rows.Close()
+
+	var tenants []string
+	for rows.Next() {
+		var tenantID string
+		if err := rows.Scan(&tenantID); err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", classifyDBError(err))
+		}
+		tenants = append(tenants, tenantID)
+	}
+
+	return tenants, rows.Err()
+}
+
+func (r *interestRepository) ListInterestBearingAccounts(ctx context.Context, excludeAccountID int64) ([]*models.InterestBearingAccount, error) {
+	query := `
+		SELECT a.account_id, p.annual_interest_rate, p.day_count_convention, a.created_at,
+			(SELECT MAX(ia.accrual_date) FROM interest_accruals ia WHERE ia.tenant_id = a.tenant_id AND ia.account_id = a.account_id)
+		FROM accounts a
+		JOIN account_products p ON p.tenant_id = a.tenant_id AND p.product_code = a.product_code
+		WHERE a.tenant_id = $1 AND p.annual_interest_rate > 0 AND a.account_id <> $2
+		ORDER BY a.account_id`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.FromContext(ctx), excludeAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interest bearing accounts: %w", classifyDBError(err))
+	}
+	defer rows.Close()
+
+	var accounts []*models.InterestBearingAccount
+	for rows.Next() {
+		account := &models.InterestBearingAccount{}
+		if err := rows.Scan(&account.AccountID, &account.AnnualInterestRate, &account.DayCountConvention, &account.CreatedAt, &account.LastAccrualDate); err != nil {
+			return nil, fmt.Errorf("failed to scan interest bearing account: %w", classifyDBError(err))
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+// GetEndOfDayBalance reconstructs the balance at the end of day (UTC) by
+// rolling back every transfer that moved money after the day ended. A reversed
+// transfer moved money too, and its reversal is rolled back as a transfer of
+// its own.
+func (r *interestRepository) GetEndOfDayBalance(ctx context.Context, accountID int64, day time.Time) (string, error) {
+	query := `
+		SELECT (a.balance
+			- COALESCE((SELECT SUM(t.amount) FROM transactions t
+				WHERE t.tenant_id = a.tenant_id AND t.destination_account_id = a.account_id AND t.status IN ($3, $4) AND t.created_at >= $5), 0)
+			+ COALESCE((SELECT SUM(t.amount) FROM transactions t
+				WHERE t.tenant_id = a.tenant_id AND t.source_account_id = a.account_id AND t.status IN ($3, $4) AND t.created_at >= $5), 0))::text
+		FROM accounts a
+		WHERE a.tenant_id = $1 AND a.account_id = $2`
+
+	endOfDay := day.AddDate(0, 0, 1)
+
+	var balance string
+	err := r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), accountID,
+		models.TransactionStatusCompleted, models.TransactionStatusReversed, endOfDay).Scan(&balance)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", &NotFoundError{Resource: ResourceAccount, ID: accountID}
+		}
+		return "", fmt.Errorf("failed to get end of day balance: %w", classifyDBError(err))
+	}
+
+	return balance, nil
+}
+
+// CreateAccrual records the accrual for a single (account, day) and reports
+// whether a new row was written; an existing accrual for that day is left untouched
+func (r *interestRepository) CreateAccrual(ctx context.Context, accrual *models.InterestAccrual) (bool, error) {
+	query := `
+		INSERT INTO interest_accruals (tenant_id, account_id, accrual_date, end_of_day_balance, annual_interest_rate, amount)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_id, account_id, accrual_date) DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query,
+		tenant.FromContext(ctx),
+		accrual.AccountID,
+		accrual.AccrualDate,
+		accrual.EndOfDayBalance,
+		accrual.AnnualInterestRate,
+		accrual.Amount,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create interest accrual: %w", classifyDBError(err))
+	}
+
+	created, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to create interest accrual: %w", classifyDBError(err))
+	}
+
+	return created > 0, nil
+}
+
+func (r *interestRepository) ListPendingPostings(ctx context.Context, before time.Time) ([]*models.PendingInterestPosting, error) {
+	query := `
+		SELECT account_id, date_trunc('month', accrual_date)::date AS month
+		FROM interest_accruals
+		WHERE tenant_id = $1 AND posted_at IS NULL AND accrual_date < $2
+		GROUP BY account_id, month
+		ORDER BY month, account_id`
+
+	rows, err := r.db.QueryContext(ctx, query, tenant.FromContext(ctx), before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending interest postings: %w", classifyDBError(err))
+	}
+	defer rows.Close()
+
+	var postings []*models.PendingInterestPosting
+	for rows.Next() {
+		posting := &models.PendingInterestPosting{}
+		if err := rows.Scan(&posting.AccountID, &posting.Month); err != nil {
+			return nil, fmt.Errorf("failed to scan pending interest posting: %w", classifyDBError(err))
+		}
+		postings = append(postings, posting)
+	}
+
+	return postings, rows.Err()
+}
+
+// PostAccruals marks every unposted accrual of the month as posted and moves the
+// total from the interest expense account in the same database transaction, so a
+// crash either leaves the accruals unposted or fully posted. Returns nil when
+// there was nothing left to post or the total was zero.
+func (r *interestRepository) PostAccruals(ctx context.Context, accountID int64, month time.Time, expenseAccountID int64) (*models.Transaction, error) {
+	transactionID := uuid.New()
+	tenantID := tenant.FromContext(ctx)
+	entry := r.logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id":      tenantID,
+		"transaction_id": transactionID,
+		"account_id":     accountID,
+		"month":          month.Format("2006-01"),
+	})
+
+	entry.Debug("Starting interest posting transaction")
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelReadCommitted})
+	if err != nil {
+		entry.Error("Failed to begin transaction: %v", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", classifyDBError(err))
+	}
+
+	defer tx.Rollback()
+
+	// the UPDATE takes row locks on the accruals, so a concurrent run blocks here
+	// and then finds nothing left with posted_at IS NULL
+	query := `
+		WITH posted AS (
+			UPDATE interest_accruals
+			SET posted_at = CURRENT_TIMESTAMP, posted_transaction_id = $5
+			WHERE tenant_id = $1 AND account_id = $2 AND accrual_date >= $3 AND accrual_date < $4 AND posted_at IS NULL
+			RETURNING amount
+		)
+		SELECT COUNT(*), COALESCE(SUM(amount), 0)::text, COALESCE(SUM(amount), 0) > 0 FROM posted`
+
+	var count int
+	var total string
+	var positive bool
+	err = tx.QueryRowContext(ctx, query, tenantID, accountID, month, month.AddDate(0, 1, 0), transactionID).Scan(&count, &total, &positive)
+	if err != nil {
+		entry.Error("Failed to mark accruals as posted: %v", err)
+		return nil, fmt.Errorf("failed to mark accruals as posted: %w", classifyDBError(err))
+	}
+
+	if count == 0 {
+		entry.Debug("No unposted accruals left")
+		return nil, nil
+	}
+
+	if !positive {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE interest_accruals SET posted_transaction_id = NULL
+			WHERE posted_transaction_id = $1`, transactionID); err != nil {
+			entry.Error("Failed to clear posted transaction: %v", err)
+			return nil, fmt.Errorf("failed to clear posted transaction: %w", classifyDBError(err))
+		}
+		entry.Debug("Accrued interest is zero, marking accruals as posted without a transfer")
+		return nil, classifyDBError(tx.Commit())
+	}
+
+	transaction := &models.Transaction{
+		TransactionID:        transactionID,
+		SourceAccountID:      expenseAccountID,
+		DestinationAccountID: accountID,
+		Amount:               total,
+		Status:               models.TransactionStatusCompleted,
+		Reference:            fmt.Sprintf("interest:%d:%s", accountID, month.Format("2006-01")),
+		Description:          fmt.Sprintf("Interest for %s", month.Format("January 2006")),
+	}
+
+	if err := insertTransaction(ctx, tx, transaction); err != nil {
+		entry.Error("Failed to create interest transaction: %v", err)
+		return nil, fmt.Errorf("failed to create interest transaction: %w", classifyDBError(err))
+	}
+
+	if err := transferFunds(ctx, tx, entry, expenseAccountID, accountID, total, transferPolicy{allowOverdraft: true, allowFrozen: true}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		entry.Error("Failed to commit interest posting: %v", err)
+		return nil, fmt.Errorf("failed to commit interest posting: %w", classifyDBError(err))
+	}
+
+	entry.Info("Interest posted successfully, amount: %s", total)
+	return transaction, nil
+}
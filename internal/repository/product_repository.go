@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/tenant"
+	"txn-service/models"
+)
+
+type ProductRepository interface {
+	Create(ctx context.Context, product *models.Product) error
+	GetByProductCode(ctx context.Context, productCode string) (*models.Product, error)
+}
+
+type productRepository struct {
+	db     *sql.DB
+	logger *logger.Logger
+}
+
+func NewProductRepository(db *sql.DB, log *logger.Logger) ProductRepository {
+	return &productRepository{
+		db:     db,
+		logger: log,
+	}
+}
+
+// Create adds the product to the tenant in ctx. Product codes are unique per
+// tenant, so tenants never compete for a code.
+func (r *productRepository) Create(ctx context.Context, product *models.Product) error {
+	query := `
+		INSERT INTO account_products (tenant_id, product_code, name, annual_interest_rate, day_count_convention)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (tenant_id, product_code) DO NOTHING
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		tenant.FromContext(ctx),
+		product.ProductCode,
+		product.Name,
+		product.AnnualInterestRate,
+		product.DayCountConvention,
+	).Scan(&product.ID, &product.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &DuplicateError{Resource: ResourceProduct, Field: "code", Value: product.ProductCode}
+		}
+		r.logger.FromContext(ctx).Error("Failed to insert product: %v", err)
+		return fmt.Errorf("failed to create product: %w", classifyDBError(err))
+	}
+
+	return nil
+}
+
+func (r *productRepository) GetByProductCode(ctx context.Context, productCode string) (*models.Product, error) {
+	query := `
+		SELECT id, product_code, name, annual_interest_rate, day_count_convention, created_at
+		FROM account_products
+		WHERE tenant_id = $1 AND product_code = $2`
+
+	product := &models.Product{}
+	err := r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), productCode).
+		Scan(&product.ID, &product.ProductCode, &product.Name, &product.AnnualInterestRate, &product.DayCountConvention, &product.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &NotFoundError{Resource: ResourceProduct, ID: productCode}
+		}
+		return nil, fmt.Errorf("failed to get product: %w", classifyDBError(err))
+	}
+
+	return product, nil
+}
@@ -3,10 +3,15 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
-	"strconv"
+	"math/big"
+	"strings"
+	"time"
 
 	"txn-service/internal/logger"
+	"txn-service/internal/metrics"
+	"txn-service/internal/tenant"
 	"txn-service/models"
 
 	"github.com/google/uuid"
@@ -14,53 +19,198 @@ import (
 
 type TransactionRepository interface {
 	Create(ctx context.Context, transaction *models.Transaction) error
-	Transfer(ctx context.Context, sourceAccountID int64, destinationAccountID int64, amount string, transactionId uuid.UUID) error
+	GetByTransactionID(ctx context.Context, transactionID uuid.UUID) (*models.Transaction, error)
+	Search(ctx context.Context, filter *models.TransactionFilter) ([]*models.Transaction, error)
+	Transfer(ctx context.Context, sourceAccountID int64, destinationAccountID int64, amount string, transactionId uuid.UUID, checks TransferChecks) error
+	Reverse(ctx context.Context, reversal *models.Transaction) error
+	ListStalePending(ctx context.Context, createdBefore time.Time) ([]*models.Transaction, error)
+	FailStalePending(ctx context.Context, createdBefore time.Time) ([]*models.Transaction, error)
 }
 
 type transactionRepository struct {
-	db     *sql.DB
-	logger *logger.Logger
+	db        *sql.DB
+	isolation sql.IsolationLevel
+	logger    *logger.Logger
 }
 
-func NewTransactionRepository(db *sql.DB) TransactionRepository {
+// NewTransactionRepository creates the repository. Transfers and reversals
+// run at the isolation level given.
+func NewTransactionRepository(db *sql.DB, isolation sql.IsolationLevel, log *logger.Logger) TransactionRepository {
 	return &transactionRepository{
-		db:     db,
-		logger: logger.NewFromEnv(),
+		db:        db,
+		isolation: isolation,
+		logger:    log,
 	}
 }
 
 func (r *transactionRepository) Create(ctx context.Context, transaction *models.Transaction) error {
+	return classifyDBError(insertTransaction(ctx, r.db, transaction))
+}
+
+const transactionColumns = `id, transaction_id, source_account_id, destination_account_id, amount, status,
+		COALESCE(reference, ''), COALESCE(description, ''), metadata, COALESCE(api_key_id, ''), reversal_of, created_at, updated_at`
+
+func (r *transactionRepository) GetByTransactionID(ctx context.Context, transactionID uuid.UUID) (*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE tenant_id = $1 AND transaction_id = $2`
+
+	transaction, err := scanTransaction(r.db.QueryRowContext(ctx, query, tenant.FromContext(ctx), transactionID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, &NotFoundError{Resource: ResourceTransaction, ID: transactionID}
+		}
+		return nil, fmt.Errorf("failed to get transaction: %w", classifyDBError(err))
+	}
+
+	return transaction, nil
+}
+
+// Search returns the most recent transactions of the tenant matching the
+// filter. The reference lookup uses idx_transactions_reference and the metadata
+// containment (@>) uses the GIN index idx_transactions_metadata.
+func (r *transactionRepository) Search(ctx context.Context, filter *models.TransactionFilter) ([]*models.Transaction, error) {
+	args := []interface{}{tenant.FromContext(ctx)}
+	conditions := []string{"tenant_id = $1"}
+
+	if filter.Reference != "" {
+		args = append(args, filter.Reference)
+		conditions = append(conditions, fmt.Sprintf("reference = $%d", len(args)))
+	}
+
+	if len(filter.Metadata) > 0 {
+		metadata, err := json.Marshal(filter.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode metadata filter: %w", err)
+		}
+		args = append(args, metadata)
+		conditions = append(conditions, fmt.Sprintf("metadata @> $%d", len(args)))
+	}
+
+	if filter.CustomerID != 0 {
+		args = append(args, filter.CustomerID)
+		conditions = append(conditions, fmt.Sprintf(`(source_account_id IN (SELECT account_id FROM accounts WHERE tenant_id = $1 AND customer_id = $%[1]d)
+			OR destination_account_id IN (SELECT account_id FROM accounts WHERE tenant_id = $1 AND customer_id = $%[1]d))`, len(args)))
+	}
+
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE ` + strings.Join(conditions, " AND ")
+
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(`
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d`, len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transactions: %w", classifyDBError(err))
+	}
+	defer rows.Close()
+
+	transactions := []*models.Transaction{}
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", classifyDBError(err))
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
+	transaction := &models.Transaction{}
+	var metadata []byte
+
+	err := row.Scan(
+		&transaction.ID,
+		&transaction.TransactionID,
+		&transaction.SourceAccountID,
+		&transaction.DestinationAccountID,
+		&transaction.Amount,
+		&transaction.Status,
+		&transaction.Reference,
+		&transaction.Description,
+		&metadata,
+		&transaction.APIKeyID,
+		&transaction.ReversalOf,
+		&transaction.CreatedAt,
+		&transaction.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(metadata, &transaction.Metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction metadata: %w", err)
+	}
+
+	return transaction, nil
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// insertTransaction writes a new transaction row of the tenant in ctx using
+// either the pool or an open transaction
+func insertTransaction(ctx context.Context, db queryRower, transaction *models.Transaction) error {
+	metadata := transaction.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	encodedMetadata, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode transaction metadata: %w", err)
+	}
+
 	query := `
-		INSERT INTO transactions (transaction_id, source_account_id, destination_account_id, amount, status)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO transactions (tenant_id, transaction_id, source_account_id, destination_account_id, amount, status, reference, description, metadata, api_key_id, reversal_of)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9, NULLIF($10, ''), $11)
 		RETURNING id, created_at, updated_at`
 
-	return r.db.QueryRowContext(ctx, query,
+	return db.QueryRowContext(ctx, query,
+		tenant.FromContext(ctx),
 		transaction.TransactionID,
 		transaction.SourceAccountID,
 		transaction.DestinationAccountID,
 		transaction.Amount,
 		transaction.Status,
+		transaction.Reference,
+		transaction.Description,
+		encodedMetadata,
+		transaction.APIKeyID,
+		transaction.ReversalOf,
 	).Scan(&transaction.ID, &transaction.CreatedAt, &transaction.UpdatedAt)
 }
 
-// getByAccountIDWithLock will get the account and lock it until next update
-func (r *transactionRepository) getByAccountIDWithLock(ctx context.Context, tx *sql.Tx, accountID int64) (*models.Account, error) {
+// getByAccountIDWithLock will get the account of the tenant in ctx and lock it
+// until next update
+func getByAccountIDWithLock(ctx context.Context, tx *sql.Tx, accountID int64) (*models.Account, error) {
 	query := `
-		SELECT id, account_id, balance, created_at, updated_at
+		SELECT id, account_id, balance, product_code, customer_id, frozen_at, created_at, updated_at
 		FROM accounts
-		WHERE account_id = $1
+		WHERE tenant_id = $1 AND account_id = $2
 		FOR UPDATE`
 
 	account := &models.Account{}
-	err := tx.QueryRowContext(ctx, query, accountID).
-		Scan(&account.ID, &account.AccountID, &account.Balance, &account.CreatedAt, &account.UpdatedAt)
+	err := tx.QueryRowContext(ctx, query, tenant.FromContext(ctx), accountID).
+		Scan(&account.ID, &account.AccountID, &account.Balance, &account.ProductCode, &account.CustomerID, &account.FrozenAt, &account.CreatedAt, &account.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("account not found: %d", accountID)
+			return nil, &NotFoundError{Resource: ResourceAccount, ID: accountID}
 		}
-		return nil, fmt.Errorf("failed to get account: %w", err)
+		return nil, fmt.Errorf("failed to get account: %w", classifyDBError(err))
 	}
 
 	return account, nil
@@ -68,30 +218,33 @@ func (r *transactionRepository) getByAccountIDWithLock(ctx context.Context, tx *
 
 func (r *transactionRepository) getByAccountID(ctx context.Context, tx *sql.Tx, accountID int64) (*models.Account, error) {
 	query := `
-		SELECT id, account_id, balance, created_at, updated_at
+		SELECT id, account_id, balance, product_code, customer_id, frozen_at, created_at, updated_at
 		FROM accounts
-		WHERE account_id = $1`
+		WHERE tenant_id = $1 AND account_id = $2`
 
 	account := &models.Account{}
-	err := tx.QueryRowContext(ctx, query, accountID).
-		Scan(&account.ID, &account.AccountID, &account.Balance, &account.CreatedAt, &account.UpdatedAt)
+	err := tx.QueryRowContext(ctx, query, tenant.FromContext(ctx), accountID).
+		Scan(&account.ID, &account.AccountID, &account.Balance, &account.ProductCode, &account.CustomerID, &account.FrozenAt, &account.CreatedAt, &account.UpdatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("account not found: %d", accountID)
+			return nil, &NotFoundError{Resource: ResourceAccount, ID: accountID}
 		}
-		return nil, fmt.Errorf("failed to get account: %w", err)
+		return nil, fmt.Errorf("failed to get account: %w", classifyDBError(err))
 	}
 
 	return account, nil
 }
 
 // Transfer would perform the main logic to process the transaction
-// Isolation mode READ COMMITED is used with ROW lock to prevent issues in concurrent transaction
+// Isolation mode READ COMMITED, the default, is used with ROW lock to prevent issues in concurrent transaction
 // this level can be bumped up to REPEATABLE READ or SERIALIZABLE isolation level if complexity of the
-// function increases but the throughput would decrease as the isolation level is increased
-func (r *transactionRepository) Transfer(ctx context.Context, sourceAccountID int64, destinationAccountID int64, amount string, transactionId uuid.UUID) error {
-	entry := r.logger.WithFields(map[string]interface{}{
+// function increases but the throughput would decrease as the isolation level is increased.
+// The checks run under the same locks, so a KYC status revoked or a transfer
+// made concurrently cannot slip past them
+func (r *transactionRepository) Transfer(ctx context.Context, sourceAccountID int64, destinationAccountID int64, amount string, transactionId uuid.UUID, checks TransferChecks) error {
+	entry := r.logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id":              tenant.FromContext(ctx),
 		"transaction_id":         transactionId,
 		"source_account_id":      sourceAccountID,
 		"destination_account_id": destinationAccountID,
@@ -101,97 +254,321 @@ func (r *transactionRepository) Transfer(ctx context.Context, sourceAccountID in
 	entry.Debug("Starting transfer transaction")
 
 	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{
-		Isolation: sql.LevelReadCommitted,
+		Isolation: r.isolation,
 		ReadOnly:  false,
 	})
 	if err != nil {
 		entry.Error("Failed to begin transaction: %v", err)
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", classifyDBError(err))
+	}
+
+	defer tx.Rollback()
+
+	if err := transferFunds(ctx, tx, entry, sourceAccountID, destinationAccountID, amount, transferPolicy{TransferChecks: checks}); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE transactions SET status = $1 WHERE tenant_id = $2 AND transaction_id = $3", models.TransactionStatusCompleted, tenant.FromContext(ctx), transactionId)
+	if err != nil {
+		entry.Error("Failed to update transaction status: %v", err)
+		return fmt.Errorf("failed to update transaction: %w", classifyDBError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		entry.Error("Failed to commit transfer: %v", err)
+		return fmt.Errorf("failed to commit transfer: %w", classifyDBError(err))
+	}
+
+	entry.Info("Transfer completed successfully")
+	return nil
+}
+
+// Reverse records reversal and moves its amount back, marking the transaction
+// in reversal.ReversalOf as reversed. Only a completed transaction can be
+// reversed, and only once. Frozen accounts take part, since reversals are
+// made by operators.
+func (r *transactionRepository) Reverse(ctx context.Context, reversal *models.Transaction) error {
+	entry := r.logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"tenant_id":      tenant.FromContext(ctx),
+		"transaction_id": reversal.TransactionID,
+		"reversal_of":    *reversal.ReversalOf,
+	})
+
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{Isolation: r.isolation})
+	if err != nil {
+		entry.Error("Failed to begin transaction: %v", err)
+		return fmt.Errorf("failed to begin transaction: %w", classifyDBError(err))
 	}
 
 	defer tx.Rollback()
 
+	// the lock on the original makes a concurrent second reversal wait and
+	// then see it reversed
+	var status string
+	err = tx.QueryRowContext(ctx, "SELECT status FROM transactions WHERE tenant_id = $1 AND transaction_id = $2 FOR UPDATE",
+		tenant.FromContext(ctx), *reversal.ReversalOf).Scan(&status)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &NotFoundError{Resource: ResourceTransaction, ID: *reversal.ReversalOf}
+		}
+		entry.Error("Failed to lock original transaction: %v", err)
+		return fmt.Errorf("failed to get transaction: %w", classifyDBError(err))
+	}
+	if status != models.TransactionStatusCompleted {
+		return fmt.Errorf("transaction %s is %s: %w", *reversal.ReversalOf, status, ErrNotReversible)
+	}
+
+	reversal.Status = models.TransactionStatusCompleted
+	if err := insertTransaction(ctx, tx, reversal); err != nil {
+		entry.Error("Failed to create reversal: %v", err)
+		return fmt.Errorf("failed to create reversal: %w", classifyDBError(err))
+	}
+
+	if err := transferFunds(ctx, tx, entry, reversal.SourceAccountID, reversal.DestinationAccountID, reversal.Amount, transferPolicy{allowFrozen: true}); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE transactions SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE tenant_id = $2 AND transaction_id = $3",
+		models.TransactionStatusReversed, tenant.FromContext(ctx), *reversal.ReversalOf)
+	if err != nil {
+		entry.Error("Failed to mark transaction reversed: %v", err)
+		return fmt.Errorf("failed to update transaction: %w", classifyDBError(err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		entry.Error("Failed to commit reversal: %v", err)
+		return fmt.Errorf("failed to commit reversal: %w", classifyDBError(err))
+	}
+
+	entry.Info("Transaction reversed")
+	return nil
+}
+
+// ListStalePending returns the transactions of the tenant still pending that
+// were created before createdBefore, oldest first
+func (r *transactionRepository) ListStalePending(ctx context.Context, createdBefore time.Time) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE tenant_id = $1 AND status = $2 AND created_at < $3
+		ORDER BY created_at, id`
+
+	return r.queryTransactions(ctx, query, tenant.FromContext(ctx), models.TransactionStatusPending, createdBefore)
+}
+
+// FailStalePending marks the transactions ListStalePending returns as failed
+// and returns them. A transfer commits its balances and its completed status
+// together, so a transaction left pending never moved any money.
+func (r *transactionRepository) FailStalePending(ctx context.Context, createdBefore time.Time) ([]*models.Transaction, error) {
+	query := `
+		UPDATE transactions
+		SET status = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE tenant_id = $1 AND status = $2 AND created_at < $3
+		RETURNING ` + transactionColumns
+
+	return r.queryTransactions(ctx, query, tenant.FromContext(ctx), models.TransactionStatusPending, createdBefore, models.TransactionStatusFailed)
+}
+
+func (r *transactionRepository) queryTransactions(ctx context.Context, query string, args ...interface{}) ([]*models.Transaction, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transactions: %w", classifyDBError(err))
+	}
+	defer rows.Close()
+
+	transactions := []*models.Transaction{}
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan transaction: %w", classifyDBError(err))
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
+// transferPolicy relaxes the checks of transferFunds for transfers the service
+// makes itself rather than on behalf of a client
+type transferPolicy struct {
+	// allowOverdraft skips the balance check on the source, which is only used
+	// for internal accounts such as interest expense
+	allowOverdraft bool
+	// allowFrozen lets frozen accounts take part, for interest postings and
+	// operator reversals
+	allowFrozen bool
+	TransferChecks
+}
+
+// TransferChecks are the checks a client transfer has to pass on top of the
+// balance and frozen account checks
+type TransferChecks struct {
+	// RequireVerifiedOwners rejects accounts whose owner has not passed KYC
+	RequireVerifiedOwners bool
+	// DailyLimit bounds the total the source account sends per UTC day; it is
+	// a decimal string, empty for no limit
+	DailyLimit string
+}
+
+// transferFunds locks both accounts and moves amount from source to destination
+// inside the caller's transaction. Both accounts are looked up in the tenant of
+// ctx, so an account of another tenant is not found and cannot take part.
+func transferFunds(ctx context.Context, tx *sql.Tx, entry *logger.Entry, sourceAccountID int64, destinationAccountID int64, amount string, policy transferPolicy) error {
 	var sourceAccount, destinationAccount *models.Account
+	var err error
+
+	// the time spent taking both row locks shows contention on hot accounts
+	lockStart := time.Now()
 
 	// we need to make sure to lock the lower id first to avoid dead locks
 	if sourceAccountID < destinationAccountID {
 		entry.Debug("Getting source account with lock (first)")
-		var err error
-		sourceAccount, err = r.getByAccountIDWithLock(ctx, tx, sourceAccountID)
+		sourceAccount, err = getByAccountIDWithLock(ctx, tx, sourceAccountID)
 		if err != nil {
 			entry.Error("Failed to get source account: %v", err)
 			return fmt.Errorf("failed to get source account: %w", err)
 		}
 
 		entry.Debug("Getting destination account with lock (second)")
-		destinationAccount, err = r.getByAccountIDWithLock(ctx, tx, destinationAccountID)
+		destinationAccount, err = getByAccountIDWithLock(ctx, tx, destinationAccountID)
 		if err != nil {
 			entry.Error("Failed to get destination account: %v", err)
 			return fmt.Errorf("failed to get destination account: %w", err)
 		}
 	} else {
 		entry.Debug("Getting destination account with lock (first)")
-		var err error
-		destinationAccount, err = r.getByAccountIDWithLock(ctx, tx, destinationAccountID)
+		destinationAccount, err = getByAccountIDWithLock(ctx, tx, destinationAccountID)
 		if err != nil {
 			entry.Error("Failed to get destination account: %v", err)
 			return fmt.Errorf("failed to get destination account: %w", err)
 		}
 
 		entry.Debug("Getting source account with lock (second)")
-		sourceAccount, err = r.getByAccountIDWithLock(ctx, tx, sourceAccountID)
+		sourceAccount, err = getByAccountIDWithLock(ctx, tx, sourceAccountID)
 		if err != nil {
 			entry.Error("Failed to get source account: %v", err)
 			return fmt.Errorf("failed to get source account: %w", err)
 		}
 	}
+	metrics.ObserveTransferLockWait(time.Since(lockStart))
+
+	if !policy.allowFrozen {
+		for _, account := range []*models.Account{sourceAccount, destinationAccount} {
+			if account.FrozenAt != nil {
+				entry.Warn("Account %d is frozen", account.AccountID)
+				return fmt.Errorf("account %d: %w", account.AccountID, ErrAccountFrozen)
+			}
+		}
+	}
 
-	txnAmount, err := strconv.ParseFloat(amount, 64)
-	if err != nil {
-		entry.Error("Failed to parse transaction amount: %v", err)
-		return fmt.Errorf("failed to parse transaction amount: %w", err)
+	if policy.RequireVerifiedOwners {
+		if err := checkOwnerVerified(ctx, tx, entry, sourceAccount); err != nil {
+			return fmt.Errorf("source account not allowed: %w", err)
+		}
+		if err := checkOwnerVerified(ctx, tx, entry, destinationAccount); err != nil {
+			return fmt.Errorf("destination account not allowed: %w", err)
+		}
 	}
 
-	sourceBalance, err := strconv.ParseFloat(sourceAccount.Balance, 64)
-	if err != nil {
-		entry.Error("Failed to parse source account balance: %v", err)
-		return fmt.Errorf("failed to parse source account balance: %w", err)
+	if policy.DailyLimit != "" {
+		if err := checkDailyLimit(ctx, tx, entry, sourceAccountID, amount, policy.DailyLimit); err != nil {
+			return err
+		}
 	}
 
-	if sourceBalance < txnAmount {
-		entry.Warn("Insufficient balance: source_balance=%f, requested_amount=%f", sourceBalance, txnAmount)
-		return fmt.Errorf("insufficient balance")
+	// balances are DECIMAL(20,8), more digits than a float64 holds, so they
+	// are compared exactly and updated by Postgres
+	txnAmount, ok := new(big.Rat).SetString(amount)
+	if !ok {
+		entry.Error("Failed to parse transaction amount: %s", amount)
+		return fmt.Errorf("failed to parse transaction amount %q", amount)
 	}
 
-	sourceBalance -= txnAmount
+	sourceBalance, ok := new(big.Rat).SetString(sourceAccount.Balance)
+	if !ok {
+		entry.Error("Failed to parse source account balance: %s", sourceAccount.Balance)
+		return fmt.Errorf("failed to parse source account balance %q", sourceAccount.Balance)
+	}
 
-	destinationBalance, err := strconv.ParseFloat(destinationAccount.Balance, 64)
-	if err != nil {
-		entry.Error("Failed to parse destination account balance: %v", err)
-		return fmt.Errorf("failed to parse destination account balance: %w", err)
+	if !policy.allowOverdraft && sourceBalance.Cmp(txnAmount) < 0 {
+		entry.Warn("Insufficient balance: source_balance=%s, requested_amount=%s", sourceAccount.Balance, amount)
+		return ErrInsufficientFunds
 	}
-	destinationBalance += txnAmount
 
-	entry.Debug("Updating balances: source_new_balance=%f, destination_new_balance=%f", sourceBalance, destinationBalance)
+	entry.Debug("Updating balances: source_balance=%s, destination_balance=%s, amount=%s", sourceAccount.Balance, destinationAccount.Balance, amount)
+
+	tenantID := tenant.FromContext(ctx)
 
-	_, err = tx.ExecContext(ctx, "UPDATE accounts SET balance = $1 WHERE account_id = $2", sourceBalance, sourceAccountID)
+	_, err = tx.ExecContext(ctx, "UPDATE accounts SET balance = balance - $1::numeric WHERE tenant_id = $2 AND account_id = $3", amount, tenantID, sourceAccountID)
 	if err != nil {
 		entry.Error("Failed to update source account: %v", err)
-		return fmt.Errorf("failed to update source account: %w", err)
+		return fmt.Errorf("failed to update source account: %w", classifyDBError(err))
 	}
 
-	_, err = tx.ExecContext(ctx, "UPDATE accounts SET balance = $1 WHERE account_id = $2", destinationBalance, destinationAccountID)
+	_, err = tx.ExecContext(ctx, "UPDATE accounts SET balance = balance + $1::numeric WHERE tenant_id = $2 AND account_id = $3", amount, tenantID, destinationAccountID)
 	if err != nil {
+		if isNumericOverflow(err) {
+			entry.Warn("Transfer rejected, destination balance would overflow - destination_balance: %s, amount: %s", destinationAccount.Balance, amount)
+			return fmt.Errorf("%w: account %d cannot hold a balance above %s", ErrTransferLimitExceeded, destinationAccountID, maxBalance)
+		}
 		entry.Error("Failed to update destination account: %v", err)
-		return fmt.Errorf("failed to update destination account: %w", err)
+		return fmt.Errorf("failed to update destination account: %w", classifyDBError(err))
+	}
+
+	return nil
+}
+
+// checkOwnerVerified rejects the locked account unless its owner passed KYC.
+// The owner row is share locked, so a concurrent status change waits for the
+// transfer to commit, or is seen by it when it committed first.
+func checkOwnerVerified(ctx context.Context, tx *sql.Tx, entry *logger.Entry, account *models.Account) error {
+	if account.CustomerID == nil {
+		return fmt.Errorf("%w: account %d has no owner", ErrKYCNotVerified, account.AccountID)
 	}
 
-	_, err = tx.ExecContext(ctx, "UPDATE transactions SET status = $1 WHERE transaction_id = $2", models.TransactionStatusCompleted, transactionId)
+	var kycStatus string
+	err := tx.QueryRowContext(ctx, "SELECT kyc_status FROM customers WHERE tenant_id = $1 AND id = $2 FOR SHARE",
+		tenant.FromContext(ctx), *account.CustomerID).Scan(&kycStatus)
 	if err != nil {
-		entry.Error("Failed to update transaction status: %v", err)
-		return fmt.Errorf("failed to update transaction: %w", err)
+		if err == sql.ErrNoRows {
+			return &NotFoundError{Resource: ResourceCustomer, ID: *account.CustomerID}
+		}
+		entry.Error("Failed to get account owner: %v", err)
+		return fmt.Errorf("failed to get customer: %w", classifyDBError(err))
 	}
 
-	entry.Info("Transfer completed successfully")
-	return tx.Commit()
+	if kycStatus != models.KYCStatusVerified {
+		entry.Warn("Transfer rejected, owner not KYC verified - account_id: %d, customer_id: %d, kyc_status: %s",
+			account.AccountID, *account.CustomerID, kycStatus)
+		return fmt.Errorf("%w: owner of account %d has kyc status %s", ErrKYCNotVerified, account.AccountID, kycStatus)
+	}
+
+	return nil
+}
+
+// checkDailyLimit rejects the transfer when it would take what the locked source
+// account sent today, in UTC, above limit. Reversals made by operators do not
+// count. Concurrent transfers from the account wait for its row lock, so they
+// cannot all fit under the limit.
+func checkDailyLimit(ctx context.Context, tx *sql.Tx, entry *logger.Entry, sourceAccountID int64, amount, limit string) error {
+	query := `
+		SELECT COALESCE(SUM(amount), 0), COALESCE(SUM(amount), 0) + $3::numeric > $4::numeric
+		FROM transactions
+		WHERE tenant_id = $1 AND source_account_id = $2 AND status = $5 AND reversal_of IS NULL
+			AND created_at >= date_trunc('day', CURRENT_TIMESTAMP AT TIME ZONE 'UTC') AT TIME ZONE 'UTC'`
+
+	var sentToday string
+	var exceeded bool
+	err := tx.QueryRowContext(ctx, query, tenant.FromContext(ctx), sourceAccountID, amount, limit, models.TransactionStatusCompleted).Scan(&sentToday, &exceeded)
+	if err != nil {
+		entry.Error("Failed to sum today's transfers: %v", err)
+		return fmt.Errorf("failed to check daily limit: %w", classifyDBError(err))
+	}
+
+	if exceeded {
+		entry.Warn("Transfer rejected, daily limit reached - sent_today: %s, daily_limit: %s", sentToday, limit)
+		return fmt.Errorf("%w: account %d already sent %s today, the daily limit is %s", ErrTransferLimitExceeded, sourceAccountID, sentToday, limit)
+	}
+
+	return nil
 }
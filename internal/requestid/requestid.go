@@ -0,0 +1,56 @@
+// Package requestid carries the ID that ties a request to its log lines and
+// error responses. Callers may send their own ID; otherwise one is generated.
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header, and in lowercase the gRPC metadata key, that
+// carries the request ID both ways
+const Header = "X-Request-ID"
+
+// maxLength bounds caller supplied IDs so they cannot bloat the logs
+const maxLength = 128
+
+// New generates a request ID
+func New() string {
+	return uuid.New().String()
+}
+
+// Valid reports whether a caller supplied id can be used as is: 1 to 128
+// printable ASCII characters without spaces
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// OrNew returns id when it is valid, or else a new request ID
+func OrNew(id string) string {
+	if Valid(id) {
+		return id
+	}
+	return New()
+}
+
+type requestIDKey struct{}
+
+// WithID returns a copy of ctx carrying request ID id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// FromContext returns the request ID of ctx, or "" when it carries none
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
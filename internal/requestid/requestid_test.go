@@ -0,0 +1,35 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("req-123"))
+	assert.True(t, Valid(New()))
+	assert.True(t, Valid(strings.Repeat("a", 128)))
+
+	assert.False(t, Valid(""))
+	assert.False(t, Valid(strings.Repeat("a", 129)))
+	assert.False(t, Valid("req 123"))
+	assert.False(t, Valid("req\n123"))
+	assert.False(t, Valid("réq"))
+}
+
+func TestOrNew(t *testing.T) {
+	assert.Equal(t, "req-123", OrNew("req-123"))
+
+	generated := OrNew("bad id")
+	assert.NotEqual(t, "bad id", generated)
+	assert.True(t, Valid(generated))
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, FromContext(ctx))
+	assert.Equal(t, "req-123", FromContext(WithID(ctx, "req-123")))
+}
@@ -3,38 +3,50 @@ package service
 import (
 	"context"
 	"fmt"
-	"strconv"
 
+	"txn-service/internal/auth"
 	"txn-service/internal/logger"
 	"txn-service/internal/repository"
+	"txn-service/internal/validation"
 	"txn-service/models"
 )
 
 type AccountService interface {
-	CreateAccount(ctx context.Context, req *models.CreateAccountRequest) error
+	CreateAccount(ctx context.Context, req *models.CreateAccountRequest) (*models.Account, error)
 	GetAccount(ctx context.Context, accountID int64) (*models.Account, error)
+	FreezeAccount(ctx context.Context, accountID int64) (*models.Account, error)
+	UnfreezeAccount(ctx context.Context, accountID int64) (*models.Account, error)
 }
 
 type accountService struct {
-	accountRepo repository.AccountRepository
-	logger      *logger.Logger
+	accountRepo  repository.AccountRepository
+	productRepo  repository.ProductRepository
+	customerRepo repository.CustomerRepository
+	logger       *logger.Logger
 }
 
-func NewAccountService(accountRepo repository.AccountRepository) AccountService {
+func NewAccountService(accountRepo repository.AccountRepository, productRepo repository.ProductRepository, customerRepo repository.CustomerRepository, log *logger.Logger) AccountService {
 	return &accountService{
-		accountRepo: accountRepo,
-		logger:      logger.NewFromEnv(),
+		accountRepo:  accountRepo,
+		productRepo:  productRepo,
+		customerRepo: customerRepo,
+		logger:       log,
 	}
 }
 
-func (s *accountService) CreateAccount(ctx context.Context, req *models.CreateAccountRequest) error {
-
-	if req.AccountID <= 0 {
-		return fmt.Errorf("invalid account ID: %d", req.AccountID)
+// CreateAccount creates the account with the requested ID, or lets the
+// repository allocate one from the sequence when the request has no ID
+func (s *accountService) CreateAccount(ctx context.Context, req *models.CreateAccountRequest) (*models.Account, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
 	}
 
-	if err := s.validateBalance(req.InitialBalance); err != nil {
-		return fmt.Errorf("invalid initial balance: %w", err)
+	var customerID *int64
+	if req.CustomerID != 0 {
+		customerID = &req.CustomerID
+	}
+	if err := auth.CheckCustomerAccess(ctx, customerID); err != nil {
+		return nil, fmt.Errorf("account owner not allowed: %w", err)
 	}
 
 	account := &models.Account{
@@ -42,11 +54,25 @@ func (s *accountService) CreateAccount(ctx context.Context, req *models.CreateAc
 		Balance:   req.InitialBalance,
 	}
 
+	if req.ProductCode != "" {
+		if _, err := s.productRepo.GetByProductCode(ctx, req.ProductCode); err != nil {
+			return nil, invalidReference("product_code", err)
+		}
+		account.ProductCode = &req.ProductCode
+	}
+
+	if req.CustomerID != 0 {
+		if _, err := s.customerRepo.GetByID(ctx, req.CustomerID); err != nil {
+			return nil, invalidReference("customer_id", err)
+		}
+		account.CustomerID = &req.CustomerID
+	}
+
 	if err := s.accountRepo.Create(ctx, account); err != nil {
-		return fmt.Errorf("failed to create account: %w", err)
+		return nil, fmt.Errorf("failed to create account: %w", err)
 	}
 
-	return nil
+	return account, nil
 }
 
 func (s *accountService) GetAccount(ctx context.Context, accountID int64) (*models.Account, error) {
@@ -55,18 +81,33 @@ func (s *accountService) GetAccount(ctx context.Context, accountID int64) (*mode
 		return nil, fmt.Errorf("failed to get account: %w", err)
 	}
 
+	if err := auth.CheckCustomerAccess(ctx, account.CustomerID); err != nil {
+		return nil, fmt.Errorf("account %d: %w", accountID, err)
+	}
+
 	return account, nil
 }
 
-func (s *accountService) validateBalance(balance string) error {
-	if balance == "" {
-		return fmt.Errorf("balance cannot be empty")
+// FreezeAccount stops the account from sending or receiving transfers until it
+// is unfrozen. Interest keeps being posted to it.
+func (s *accountService) FreezeAccount(ctx context.Context, accountID int64) (*models.Account, error) {
+	return s.setFrozen(ctx, accountID, true)
+}
+
+// UnfreezeAccount lets a frozen account take part in transfers again
+func (s *accountService) UnfreezeAccount(ctx context.Context, accountID int64) (*models.Account, error) {
+	return s.setFrozen(ctx, accountID, false)
+}
+
+func (s *accountService) setFrozen(ctx context.Context, accountID int64, frozen bool) (*models.Account, error) {
+	if _, err := s.GetAccount(ctx, accountID); err != nil {
+		return nil, err
 	}
 
-	_, err := strconv.ParseFloat(balance, 64)
+	account, err := s.accountRepo.SetFrozen(ctx, accountID, frozen)
 	if err != nil {
-		return fmt.Errorf("invalid balance format: %s", balance)
+		return nil, fmt.Errorf("failed to update account: %w", err)
 	}
 
-	return nil
+	return account, nil
 }
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/logger"
+	"txn-service/internal/repository"
+	"txn-service/internal/tenant"
+	"txn-service/models"
+)
+
+// APIKeyService manages API keys and authenticates requests made with them
+type APIKeyService interface {
+	auth.Authenticator
+	CreateAPIKey(ctx context.Context, name, tenantID string, scopes []string) (*models.APIKey, string, error)
+	ListAPIKeys(ctx context.Context) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID string) error
+}
+
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	logger     *logger.Logger
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, log *logger.Logger) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		logger:     log,
+	}
+}
+
+// CreateAPIKey stores a new key acting in tenantID, the default tenant when
+// empty, and returns it together with the token, which is not stored and
+// cannot be shown again
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, name, tenantID string, scopes []string) (*models.APIKey, string, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, "", invalidField("name", "is required")
+	}
+
+	if tenantID == "" {
+		tenantID = tenant.Default
+	}
+	if err := tenant.Validate(tenantID); err != nil {
+		return nil, "", invalidField("tenant", "%v", err)
+	}
+
+	if len(scopes) == 0 {
+		return nil, "", invalidField("scopes", "at least one scope is required")
+	}
+
+	for _, scope := range scopes {
+		if !auth.IsValidScope(scope) {
+			return nil, "", invalidField("scopes", "unknown scope %q, must be one of: %s", scope, strings.Join(auth.AllScopes, ", "))
+		}
+	}
+
+	keyID, token, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := &models.APIKey{
+		KeyID:    keyID,
+		Name:     name,
+		TenantID: tenantID,
+		KeyHash:  auth.HashAPIKey(token),
+		Scopes:   scopes,
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	s.logger.FromContext(ctx).Info("API key created - key_id: %s, name: %s, tenant: %s, scopes: %s", keyID, name, tenantID, strings.Join(scopes, ","))
+	return key, token, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) ([]*models.APIKey, error) {
+	keys, err := s.apiKeyRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, keyID string) error {
+	if err := s.apiKeyRepo.Revoke(ctx, keyID); err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	s.logger.FromContext(ctx).Info("API key revoked - key_id: %s", keyID)
+	return nil
+}
+
+// Authenticate resolves an API key to its principal. Unknown, revoked and
+// mismatching keys all fail with auth.ErrUnauthenticated so callers cannot
+// probe which key IDs exist.
+func (s *apiKeyService) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	keyID, err := auth.ParseAPIKeyID(token)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.apiKeyRepo.GetByKeyID(ctx, keyID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: unknown API key", auth.ErrUnauthenticated)
+		}
+		return nil, fmt.Errorf("failed to authenticate API key: %w", err)
+	}
+
+	if !auth.MatchAPIKey(token, key.KeyHash) {
+		s.logger.FromContext(ctx).Warn("API key rejected, secret does not match - key_id: %s", keyID)
+		return nil, fmt.Errorf("%w: unknown API key", auth.ErrUnauthenticated)
+	}
+
+	if key.RevokedAt != nil {
+		s.logger.FromContext(ctx).Warn("API key rejected, revoked - key_id: %s", keyID)
+		return nil, fmt.Errorf("%w: API key has been revoked", auth.ErrUnauthenticated)
+	}
+
+	return &auth.Principal{APIKeyID: key.KeyID, TenantID: key.TenantID, Scopes: key.Scopes}, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/logger"
+	"txn-service/internal/repository"
+	"txn-service/internal/validation"
+	"txn-service/models"
+)
+
+type CustomerService interface {
+	CreateCustomer(ctx context.Context, req *models.CreateCustomerRequest) (*models.Customer, error)
+	GetCustomer(ctx context.Context, customerID int64) (*models.Customer, error)
+	UpdateKYCStatus(ctx context.Context, customerID int64, kycStatus string) error
+	GetCustomerAccounts(ctx context.Context, customerID int64) (*models.CustomerAccountsResponse, error)
+}
+
+type customerService struct {
+	customerRepo repository.CustomerRepository
+	accountRepo  repository.AccountRepository
+	logger       *logger.Logger
+}
+
+func NewCustomerService(customerRepo repository.CustomerRepository, accountRepo repository.AccountRepository, log *logger.Logger) CustomerService {
+	return &customerService{
+		customerRepo: customerRepo,
+		accountRepo:  accountRepo,
+		logger:       log,
+	}
+}
+
+func (s *customerService) CreateCustomer(ctx context.Context, req *models.CreateCustomerRequest) (*models.Customer, error) {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	if err := auth.CheckUnrestricted(ctx); err != nil {
+		return nil, fmt.Errorf("failed to create customer: %w", err)
+	}
+
+	kycStatus := req.KYCStatus
+	if kycStatus == "" {
+		kycStatus = models.KYCStatusPending
+	}
+
+	metadata := req.Metadata
+	if metadata == nil {
+		metadata = map[string]string{}
+	}
+
+	customer := &models.Customer{
+		Name:              req.Name,
+		ExternalReference: req.ExternalReference,
+		KYCStatus:         kycStatus,
+		Metadata:          metadata,
+	}
+
+	if err := s.customerRepo.Create(ctx, customer); err != nil {
+		return nil, fmt.Errorf("failed to create customer: %w", err)
+	}
+
+	return customer, nil
+}
+
+func (s *customerService) GetCustomer(ctx context.Context, customerID int64) (*models.Customer, error) {
+	if err := auth.CheckCustomerAccess(ctx, &customerID); err != nil {
+		return nil, fmt.Errorf("customer %d: %w", customerID, err)
+	}
+
+	customer, err := s.customerRepo.GetByID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	return customer, nil
+}
+
+func (s *customerService) UpdateKYCStatus(ctx context.Context, customerID int64, kycStatus string) error {
+	if err := validation.Struct(&models.UpdateKYCStatusRequest{KYCStatus: kycStatus}); err != nil {
+		return err
+	}
+
+	if err := auth.CheckUnrestricted(ctx); err != nil {
+		return fmt.Errorf("failed to update kyc status: %w", err)
+	}
+
+	if err := s.customerRepo.UpdateKYCStatus(ctx, customerID, kycStatus); err != nil {
+		return fmt.Errorf("failed to update kyc status: %w", err)
+	}
+
+	s.logger.FromContext(ctx).Info("KYC status updated - customer_id: %d, kyc_status: %s", customerID, kycStatus)
+	return nil
+}
+
+// GetCustomerAccounts lists the customer's accounts together with the sum of
+// their balances, computed from the same rows that are returned
+func (s *customerService) GetCustomerAccounts(ctx context.Context, customerID int64) (*models.CustomerAccountsResponse, error) {
+	if err := auth.CheckCustomerAccess(ctx, &customerID); err != nil {
+		return nil, fmt.Errorf("customer %d: %w", customerID, err)
+	}
+
+	if _, err := s.customerRepo.GetByID(ctx, customerID); err != nil {
+		return nil, fmt.Errorf("failed to get customer: %w", err)
+	}
+
+	accounts, err := s.accountRepo.ListByCustomerID(ctx, customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customer accounts: %w", err)
+	}
+
+	total := new(big.Rat)
+	for _, account := range accounts {
+		balance, ok := new(big.Rat).SetString(account.Balance)
+		if !ok {
+			return nil, fmt.Errorf("invalid balance format for account %d: %s", account.AccountID, account.Balance)
+		}
+		total.Add(total, balance)
+	}
+
+	return &models.CustomerAccountsResponse{
+		CustomerID:   customerID,
+		Accounts:     accounts,
+		AccountCount: len(accounts),
+		TotalBalance: total.FloatString(8),
+	}, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"txn-service/models"
+)
+
+// dayCountFraction returns the year fraction that accrues for a single day
+// under the given day-count convention
+func dayCountFraction(convention string, day time.Time) (*big.Rat, error) {
+	switch convention {
+	case models.DayCountActual365:
+		return big.NewRat(1, 365), nil
+	case models.DayCount30360:
+		return big.NewRat(days30360(day, day.AddDate(0, 0, 1)), 360), nil
+	default:
+		return nil, fmt.Errorf("unsupported day count convention: %s", convention)
+	}
+}
+
+// days30360 counts days between start and end using the 30/360 bond basis,
+// so the daily values within any calendar month always sum up to 30
+func days30360(start, end time.Time) int64 {
+	y1, m1, d1 := start.Date()
+	y2, m2, d2 := end.Date()
+
+	if d1 == 31 {
+		d1 = 30
+	}
+	if d2 == 31 && d1 == 30 {
+		d2 = 30
+	}
+
+	return int64(360*(y2-y1) + 30*(int(m2)-int(m1)) + (d2 - d1))
+}
+
+// dailyInterest computes balance * rate * fraction rounded to the 8 decimal
+// places stored in the database. Non-positive balances accrue nothing.
+func dailyInterest(balance, annualRate string, fraction *big.Rat) (string, error) {
+	b, ok := new(big.Rat).SetString(balance)
+	if !ok {
+		return "", fmt.Errorf("invalid balance format: %s", balance)
+	}
+
+	rate, ok := new(big.Rat).SetString(annualRate)
+	if !ok {
+		return "", fmt.Errorf("invalid interest rate format: %s", annualRate)
+	}
+
+	if b.Sign() <= 0 {
+		return "0", nil
+	}
+
+	amount := new(big.Rat).Mul(b, rate)
+	amount.Mul(amount, fraction)
+
+	return amount.FloatString(8), nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"txn-service/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDayCount30360SumsToThirtyPerMonth(t *testing.T) {
+	for _, year := range []int{2023, 2024} {
+		for month := time.January; month <= time.December; month++ {
+			total := new(big.Rat)
+			for d := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC); d.Month() == month; d = d.AddDate(0, 0, 1) {
+				fraction, err := dayCountFraction(models.DayCount30360, d)
+				require.NoError(t, err)
+				total.Add(total, fraction)
+			}
+			assert.Equal(t, big.NewRat(30, 360).String(), total.String(), "%d-%02d", year, month)
+		}
+	}
+}
+
+func TestDailyInterest(t *testing.T) {
+	amount, err := dailyInterest("1000.00", "0.0365", big.NewRat(1, 365))
+	require.NoError(t, err)
+	assert.Equal(t, "0.10000000", amount)
+
+	amount, err = dailyInterest("-50.00", "0.0365", big.NewRat(1, 365))
+	require.NoError(t, err)
+	assert.Equal(t, "0", amount)
+
+	_, err = dayCountFraction("ACT/ACT", time.Now())
+	assert.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+
+	"txn-service/internal/auth"
+	"txn-service/internal/metrics"
+	"txn-service/internal/repository"
+	"txn-service/internal/validation"
+)
+
+// Errors returned by the services. The repository sentinels are re-exported so
+// callers only need to depend on this package to classify an error.
+var (
+	ErrNotFound              = repository.ErrNotFound
+	ErrDuplicate             = repository.ErrDuplicate
+	ErrInsufficientFunds     = repository.ErrInsufficientFunds
+	ErrConflict              = repository.ErrConflict
+	ErrUnavailable           = repository.ErrUnavailable
+	ErrAccountFrozen         = repository.ErrAccountFrozen
+	ErrNotReversible         = repository.ErrNotReversible
+	ErrKYCNotVerified        = repository.ErrKYCNotVerified
+	ErrTransferLimitExceeded = repository.ErrTransferLimitExceeded
+
+	ErrInvalidInput = validation.ErrInvalid
+	ErrForbidden    = auth.ErrForbidden
+)
+
+// ValidationError reports a single invalid field and ValidationErrors every
+// violation of a request; both match ErrInvalidInput
+type (
+	NotFoundError    = repository.NotFoundError
+	DuplicateError   = repository.DuplicateError
+	ValidationError  = validation.FieldError
+	ValidationErrors = validation.Errors
+)
+
+// transferOutcomes names the failure reasons of transfers in the metrics. It is
+// checked in order with errors.Is, the first match wins.
+var transferOutcomes = []struct {
+	target  error
+	outcome string
+}{
+	{ErrInvalidInput, "invalid"},
+	{ErrForbidden, "forbidden"},
+	{ErrNotFound, "not_found"},
+	{ErrInsufficientFunds, "insufficient_funds"},
+	{ErrAccountFrozen, "account_frozen"},
+	{ErrKYCNotVerified, "kyc_not_verified"},
+	{ErrTransferLimitExceeded, "limit_exceeded"},
+	{ErrConflict, "conflict"},
+	{ErrUnavailable, "unavailable"},
+}
+
+// transferOutcome is the metrics outcome of a transfer that returned err
+func transferOutcome(err error) string {
+	if err == nil {
+		return metrics.OutcomeCompleted
+	}
+	for _, mapping := range transferOutcomes {
+		if errors.Is(err, mapping.target) {
+			return mapping.outcome
+		}
+	}
+	return metrics.OutcomeError
+}
+
+func invalidField(field, format string, args ...interface{}) error {
+	return validation.Field(field, format, args...)
+}
+
+// invalidReference turns a missing referenced resource into a validation error on
+// field, leaving any other failure (e.g. the database being down) untouched
+func invalidReference(field string, err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return &ValidationError{Field: field, Message: err.Error()}
+	}
+	return err
+}
@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/repository"
+	"txn-service/internal/tenant"
+	"txn-service/models"
+)
+
+type InterestService interface {
+	AccrueThrough(ctx context.Context, day time.Time) error
+	PostBefore(ctx context.Context, month time.Time) error
+	Run(ctx context.Context, now time.Time) error
+}
+
+type interestService struct {
+	interestRepo     repository.InterestRepository
+	expenseAccountID int64
+	logger           *logger.Logger
+}
+
+// NewInterestService creates the service that accrues and posts interest.
+// Posted interest is paid out of expenseAccountID, which must already exist.
+func NewInterestService(interestRepo repository.InterestRepository, expenseAccountID int64, log *logger.Logger) InterestService {
+	return &interestService{
+		interestRepo:     interestRepo,
+		expenseAccountID: expenseAccountID,
+		logger:           log,
+	}
+}
+
+// Run accrues interest for every day up to and including yesterday and posts
+// all accruals of previous months, for every tenant. Each tenant pays interest
+// from its own expense account with the configured ID; a failing tenant does
+// not hold up the others. It is safe to call any number of times.
+func (s *interestService) Run(ctx context.Context, now time.Time) error {
+	today := truncateToDay(now)
+
+	tenants, err := s.interestRepo.ListTenants(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list tenants: %w", err)
+	}
+
+	var errs []error
+	for _, tenantID := range tenants {
+		tenantCtx := tenant.WithID(ctx, tenantID)
+		tenantCtx = logger.ContextWithField(tenantCtx, "tenant_id", tenantID)
+
+		err := s.AccrueThrough(tenantCtx, today.AddDate(0, 0, -1))
+		if err == nil {
+			err = s.PostBefore(tenantCtx, time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC))
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", tenantID, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// AccrueThrough accrues every interest bearing account of the tenant in ctx
+// for each day after its last accrual (or since it was opened) up to and
+// including day
+func (s *interestService) AccrueThrough(ctx context.Context, day time.Time) error {
+	day = truncateToDay(day)
+
+	accounts, err := s.interestRepo.ListInterestBearingAccounts(ctx, s.expenseAccountID)
+	if err != nil {
+		return fmt.Errorf("failed to list interest bearing accounts: %w", err)
+	}
+
+	for _, account := range accounts {
+		from := truncateToDay(account.CreatedAt)
+		if account.LastAccrualDate != nil {
+			from = truncateToDay(*account.LastAccrualDate).AddDate(0, 0, 1)
+		}
+
+		for d := from; !d.After(day); d = d.AddDate(0, 0, 1) {
+			if err := s.accrue(ctx, account, d); err != nil {
+				return fmt.Errorf("failed to accrue interest for account %d on %s: %w", account.AccountID, d.Format("2006-01-02"), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *interestService) accrue(ctx context.Context, account *models.InterestBearingAccount, day time.Time) error {
+	fraction, err := dayCountFraction(account.DayCountConvention, day)
+	if err != nil {
+		return err
+	}
+
+	balance, err := s.interestRepo.GetEndOfDayBalance(ctx, account.AccountID, day)
+	if err != nil {
+		return err
+	}
+
+	amount, err := dailyInterest(balance, account.AnnualInterestRate, fraction)
+	if err != nil {
+		return err
+	}
+
+	created, err := s.interestRepo.CreateAccrual(ctx, &models.InterestAccrual{
+		AccountID:          account.AccountID,
+		AccrualDate:        day,
+		EndOfDayBalance:    balance,
+		AnnualInterestRate: account.AnnualInterestRate,
+		Amount:             amount,
+	})
+	if err != nil {
+		return err
+	}
+
+	if created {
+		s.logger.FromContext(ctx).Debug("Interest accrued - account_id: %d, date: %s, balance: %s, amount: %s",
+			account.AccountID, day.Format("2006-01-02"), balance, amount)
+	}
+
+	return nil
+}
+
+// PostBefore posts every unposted accrual of the tenant in ctx dated before
+// month, one transfer per account and calendar month
+func (s *interestService) PostBefore(ctx context.Context, month time.Time) error {
+	postings, err := s.interestRepo.ListPendingPostings(ctx, month)
+	if err != nil {
+		return fmt.Errorf("failed to list pending interest postings: %w", err)
+	}
+
+	for _, posting := range postings {
+		transaction, err := s.interestRepo.PostAccruals(ctx, posting.AccountID, posting.Month, s.expenseAccountID)
+		if err != nil {
+			return fmt.Errorf("failed to post interest for account %d for %s: %w", posting.AccountID, posting.Month.Format("2006-01"), err)
+		}
+
+		if transaction != nil {
+			s.logger.FromContext(ctx).Info("Interest posted - account_id: %d, month: %s, amount: %s, transaction_id: %s",
+				posting.AccountID, posting.Month.Format("2006-01"), transaction.Amount, transaction.TransactionID)
+		}
+	}
+
+	return nil
+}
+
+func truncateToDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/repository"
+	"txn-service/internal/validation"
+	"txn-service/models"
+)
+
+type ProductService interface {
+	CreateProduct(ctx context.Context, req *models.CreateProductRequest) error
+	GetProduct(ctx context.Context, productCode string) (*models.Product, error)
+}
+
+type productService struct {
+	productRepo repository.ProductRepository
+	logger      *logger.Logger
+}
+
+func NewProductService(productRepo repository.ProductRepository, log *logger.Logger) ProductService {
+	return &productService{
+		productRepo: productRepo,
+		logger:      log,
+	}
+}
+
+func (s *productService) CreateProduct(ctx context.Context, req *models.CreateProductRequest) error {
+	if err := validation.Struct(req); err != nil {
+		return err
+	}
+
+	product := &models.Product{
+		ProductCode:        req.ProductCode,
+		Name:               req.Name,
+		AnnualInterestRate: req.AnnualInterestRate,
+		DayCountConvention: req.DayCountConvention,
+	}
+
+	if err := s.productRepo.Create(ctx, product); err != nil {
+		return fmt.Errorf("failed to create product: %w", err)
+	}
+
+	return nil
+}
+
+func (s *productService) GetProduct(ctx context.Context, productCode string) (*models.Product, error) {
+	product, err := s.productRepo.GetByProductCode(ctx, productCode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	return product, nil
+}
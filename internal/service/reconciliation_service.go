@@ -0,0 +1,78 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/repository"
+	"txn-service/internal/tenant"
+	"txn-service/models"
+)
+
+// ReconciliationService finds the inconsistencies that the normal request
+// flow can leave behind: transactions left pending by a transfer that failed,
+// and accounts overdrawn below zero.
+type ReconciliationService interface {
+	Reconcile(ctx context.Context, pendingOlderThan time.Duration, fix bool) (*models.ReconciliationReport, error)
+}
+
+type reconciliationService struct {
+	transactionRepo  repository.TransactionRepository
+	accountRepo      repository.AccountRepository
+	expenseAccountID int64
+	logger           *logger.Logger
+}
+
+// NewReconciliationService creates the service. The interest expense account
+// is allowed to go below zero and is not reported.
+func NewReconciliationService(transactionRepo repository.TransactionRepository, accountRepo repository.AccountRepository, expenseAccountID int64, log *logger.Logger) ReconciliationService {
+	return &reconciliationService{
+		transactionRepo:  transactionRepo,
+		accountRepo:      accountRepo,
+		expenseAccountID: expenseAccountID,
+		logger:           log,
+	}
+}
+
+// Reconcile reports the tenant's transactions pending for longer than
+// pendingOlderThan and its negative balances. With fix, the stale pending
+// transactions are marked failed; balances are only ever reported.
+func (s *reconciliationService) Reconcile(ctx context.Context, pendingOlderThan time.Duration, fix bool) (*models.ReconciliationReport, error) {
+	if pendingOlderThan <= 0 {
+		return nil, invalidField("pending_older_than", "must be positive")
+	}
+
+	report := &models.ReconciliationReport{TenantID: tenant.FromContext(ctx), Fixed: fix}
+	createdBefore := time.Now().Add(-pendingOlderThan)
+
+	var err error
+	if fix {
+		report.StalePendingTransactions, err = s.transactionRepo.FailStalePending(ctx, createdBefore)
+	} else {
+		report.StalePendingTransactions, err = s.transactionRepo.ListStalePending(ctx, createdBefore)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find stale pending transactions: %w", err)
+	}
+
+	accounts, err := s.accountRepo.ListNegativeBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find negative balances: %w", err)
+	}
+	report.NegativeBalanceAccounts = []*models.Account{}
+	for _, account := range accounts {
+		if account.AccountID != s.expenseAccountID {
+			report.NegativeBalanceAccounts = append(report.NegativeBalanceAccounts, account)
+		}
+	}
+
+	s.logger.FromContext(ctx).WithFields(map[string]interface{}{
+		"stale_pending":    len(report.StalePendingTransactions),
+		"negative_balance": len(report.NegativeBalanceAccounts),
+		"fixed":            fix,
+	}).Info("Reconciliation completed")
+
+	return report, nil
+}
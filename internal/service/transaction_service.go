@@ -3,10 +3,15 @@ package service
 import (
 	"context"
 	"fmt"
-	"strconv"
 
+	"txn-service/internal/accountnumber"
+	"txn-service/internal/auth"
 	"txn-service/internal/logger"
+	"txn-service/internal/metrics"
 	"txn-service/internal/repository"
+	"txn-service/internal/settings"
+	"txn-service/internal/tracing"
+	"txn-service/internal/validation"
 	"txn-service/models"
 
 	"github.com/google/uuid"
@@ -14,39 +19,101 @@ import (
 
 type TransactionService interface {
 	ProcessTransaction(ctx context.Context, req *models.CreateTransactionRequest) (*models.CreateTransactionSuccessResponse, error)
+	GetTransaction(ctx context.Context, transactionID uuid.UUID) (*models.Transaction, error)
+	SearchTransactions(ctx context.Context, filter *models.TransactionFilter) ([]*models.Transaction, error)
+	ReverseTransaction(ctx context.Context, transactionID uuid.UUID, reason string) (*models.Transaction, error)
 }
 
 type transactionService struct {
 	transactionRepo repository.TransactionRepository
 	accountRepo     repository.AccountRepository
+	settings        *settings.Store
 	logger          *logger.Logger
 }
 
-func NewTransactionService(transactionRepo repository.TransactionRepository, accountRepo repository.AccountRepository) TransactionService {
+// NewTransactionService creates the transfer service. Each transfer reads the
+// KYC requirement and the transfer limits from runtime. With RequireVerifiedKYC
+// both accounts must be owned by a customer whose KYC status is verified; it
+// and the daily limit are checked inside the transfer, under the account locks.
+func NewTransactionService(transactionRepo repository.TransactionRepository, accountRepo repository.AccountRepository, runtime *settings.Store, log *logger.Logger) TransactionService {
 	return &transactionService{
 		transactionRepo: transactionRepo,
 		accountRepo:     accountRepo,
-		logger:          logger.NewFromEnv(),
+		settings:        runtime,
+		logger:          log,
 	}
 }
 
-func (s *transactionService) ProcessTransaction(ctx context.Context, req *models.CreateTransactionRequest) (*models.CreateTransactionSuccessResponse, error) {
-	if err := s.validateTransactionRequest(req); err != nil {
+func (s *transactionService) ProcessTransaction(ctx context.Context, req *models.CreateTransactionRequest) (_ *models.CreateTransactionSuccessResponse, err error) {
+	ctx, span := tracing.Start(ctx, "ProcessTransaction", tracing.Amount(req.Amount)...)
+	defer func() {
+		metrics.ObserveTransfer(transferOutcome(err), req.Amount)
+		tracing.End(span, err)
+	}()
+
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	// every setting of this transfer comes from the same snapshot
+	runtime := s.settings.Current()
+	if limit := runtime.TransferMaxAmount; limit != "" && aboveLimit(req.Amount, limit) {
+		return nil, fmt.Errorf("%w: amount %s is above the limit of %s per transfer", ErrTransferLimitExceeded, req.Amount, limit)
+	}
+
+	if err := resolveAccountReferences(req); err != nil {
 		return nil, fmt.Errorf("invalid transaction request: %w", err)
 	}
+	span.SetAttributes(
+		tracing.SourceAccountIDKey.Int64(req.SourceAccountID),
+		tracing.DestinationAccountIDKey.Int64(req.DestinationAccountID),
+	)
+
+	if req.SourceAccountID == req.DestinationAccountID {
+		return nil, invalidField("destination_account_id", "source and destination accounts cannot be the same")
+	}
 
-	transactionID := uuid.New()
-	if err := s.transactionRepo.Create(ctx, &models.Transaction{
-		TransactionID:        transactionID,
+	source, err := s.accountRepo.GetByAccountID(ctx, req.SourceAccountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get source account: %w", err)
+	}
+
+	if _, err := s.accountRepo.GetByAccountID(ctx, req.DestinationAccountID); err != nil {
+		return nil, fmt.Errorf("failed to get destination account: %w", err)
+	}
+
+	// the caller may pay into any account, but only out of its own
+	if err := auth.CheckCustomerAccess(ctx, source.CustomerID); err != nil {
+		return nil, fmt.Errorf("source account %d: %w", source.AccountID, err)
+	}
+
+	transaction := &models.Transaction{
+		TransactionID:        uuid.New(),
 		SourceAccountID:      req.SourceAccountID,
 		DestinationAccountID: req.DestinationAccountID,
 		Amount:               req.Amount,
 		Status:               models.TransactionStatusPending,
-	}); err != nil {
+		Reference:            req.Reference,
+		Description:          req.Description,
+		Metadata:             req.Metadata,
+	}
+	if principal := auth.FromContext(ctx); principal != nil {
+		transaction.APIKeyID = principal.APIKeyID
+	}
+
+	// everything logged from here on names the transaction
+	transactionID := transaction.TransactionID
+	ctx = logger.ContextWithField(ctx, "transaction_id", transactionID)
+	span.SetAttributes(tracing.TransactionIDKey.String(transactionID.String()))
+
+	if err := s.transactionRepo.Create(ctx, transaction); err != nil {
 		return nil, fmt.Errorf("failed to create transaction: %w", err)
 	}
 
-	if err := s.transactionRepo.Transfer(ctx, req.SourceAccountID, req.DestinationAccountID, req.Amount, transactionID); err != nil {
+	if err := s.transactionRepo.Transfer(ctx, req.SourceAccountID, req.DestinationAccountID, req.Amount, transactionID, repository.TransferChecks{
+		RequireVerifiedOwners: runtime.RequireVerifiedKYC,
+		DailyLimit:            runtime.TransferDailyLimit,
+	}); err != nil {
 		return nil, fmt.Errorf("failed to transfer funds: %w", err)
 	}
 
@@ -55,31 +122,144 @@ func (s *transactionService) ProcessTransaction(ctx context.Context, req *models
 	}, nil
 }
 
-func (s *transactionService) validateTransactionRequest(req *models.CreateTransactionRequest) error {
-	if req.SourceAccountID <= 0 {
-		return fmt.Errorf("invalid source account ID: %d", req.SourceAccountID)
+func (s *transactionService) GetTransaction(ctx context.Context, transactionID uuid.UUID) (*models.Transaction, error) {
+	transaction, err := s.transactionRepo.GetByTransactionID(ctx, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
 	}
 
-	if req.DestinationAccountID <= 0 {
-		return fmt.Errorf("invalid destination account ID: %d", req.DestinationAccountID)
+	if err := s.checkTransactionAccess(ctx, transaction); err != nil {
+		return nil, fmt.Errorf("transaction %s: %w", transactionID, err)
 	}
 
-	if req.SourceAccountID == req.DestinationAccountID {
-		return fmt.Errorf("source and destination accounts cannot be the same")
+	return transaction, nil
+}
+
+func (s *transactionService) SearchTransactions(ctx context.Context, filter *models.TransactionFilter) ([]*models.Transaction, error) {
+	if filter.Reference == "" && len(filter.Metadata) == 0 {
+		return nil, invalidField("", "at least one of reference or metadata filters is required")
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = models.DefaultTransactionPageLen
+	}
+
+	if filter.Limit > models.MaxTransactionPageLen {
+		filter.Limit = models.MaxTransactionPageLen
+	}
+
+	// restricted callers only see transfers touching their own accounts
+	if principal := auth.FromContext(ctx); principal != nil && principal.Restricted {
+		if principal.CustomerID == 0 {
+			return nil, fmt.Errorf("failed to search transactions: %w", auth.CheckCustomerAccess(ctx, nil))
+		}
+		filter.CustomerID = principal.CustomerID
+	}
+
+	transactions, err := s.transactionRepo.Search(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search transactions: %w", err)
+	}
+
+	return transactions, nil
+}
+
+// ReverseTransaction undoes a completed transaction with a new transaction
+// moving the same amount back, and marks the original reversed. The reason
+// becomes the description of the reversal.
+func (s *transactionService) ReverseTransaction(ctx context.Context, transactionID uuid.UUID, reason string) (*models.Transaction, error) {
+	original, err := s.GetTransaction(ctx, transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	description := reason
+	if description == "" {
+		description = fmt.Sprintf("Reversal of %s", transactionID)
+	}
+
+	reversal := &models.Transaction{
+		TransactionID:        uuid.New(),
+		SourceAccountID:      original.DestinationAccountID,
+		DestinationAccountID: original.SourceAccountID,
+		Amount:               original.Amount,
+		Reference:            original.Reference,
+		Description:          description,
+		Metadata:             original.Metadata,
+		ReversalOf:           &original.TransactionID,
+	}
+	if principal := auth.FromContext(ctx); principal != nil {
+		reversal.APIKeyID = principal.APIKeyID
+	}
+
+	ctx = logger.ContextWithField(ctx, "transaction_id", reversal.TransactionID)
+	if err := s.transactionRepo.Reverse(ctx, reversal); err != nil {
+		return nil, fmt.Errorf("failed to reverse transaction: %w", err)
+	}
+
+	return reversal, nil
+}
+
+// checkTransactionAccess allows restricted callers to read a transfer when they
+// own either of its accounts
+func (s *transactionService) checkTransactionAccess(ctx context.Context, transaction *models.Transaction) error {
+	principal := auth.FromContext(ctx)
+	if principal == nil || !principal.Restricted {
+		return nil
 	}
 
-	if req.Amount == "" {
-		return fmt.Errorf("amount cannot be empty")
+	for _, accountID := range []int64{transaction.SourceAccountID, transaction.DestinationAccountID} {
+		account, err := s.accountRepo.GetByAccountID(ctx, accountID)
+		if err != nil {
+			return fmt.Errorf("failed to get account: %w", err)
+		}
+		if principal.CanAccessCustomer(account.CustomerID) {
+			return nil
+		}
 	}
 
-	amount, err := strconv.ParseFloat(req.Amount, 64)
+	return auth.CheckCustomerAccess(ctx, nil)
+}
+
+// resolveAccountReferences fills in the account IDs of accounts given by account
+// number, rejecting numbers that fail the check digits or disagree with the ID
+func resolveAccountReferences(req *models.CreateTransactionRequest) error {
+	sourceAccountID, err := resolveAccountReference(req.SourceAccountID, req.SourceAccountNumber)
 	if err != nil {
-		return fmt.Errorf("invalid amount format: %s", req.Amount)
+		return invalidField("source_account_number", "invalid source account: %v", err)
 	}
 
-	if amount <= 0 {
-		return fmt.Errorf("amount must be greater than zero")
+	destinationAccountID, err := resolveAccountReference(req.DestinationAccountID, req.DestinationAccountNumber)
+	if err != nil {
+		return invalidField("destination_account_number", "invalid destination account: %v", err)
 	}
 
+	req.SourceAccountID = sourceAccountID
+	req.DestinationAccountID = destinationAccountID
 	return nil
 }
+
+func resolveAccountReference(accountID int64, accountNumber string) (int64, error) {
+	if accountNumber == "" {
+		return accountID, nil
+	}
+
+	parsed, err := accountnumber.Parse(accountNumber)
+	if err != nil {
+		return 0, err
+	}
+
+	if accountID != 0 && accountID != parsed {
+		return 0, fmt.Errorf("account number %s does not match account ID %d", accountNumber, accountID)
+	}
+
+	return parsed, nil
+}
+
+// aboveLimit reports whether amount is greater than limit; both have been
+// validated as decimals
+func aboveLimit(amount, limit string) bool {
+	value, _ := validation.ParseDecimal(amount)
+	max, _ := validation.ParseDecimal(limit)
+	return value.Cmp(max) > 0
+}
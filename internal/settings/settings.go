@@ -0,0 +1,50 @@
+// Package settings holds the settings a running service can change without a
+// restart. They are read from one immutable snapshot, which a configuration
+// reload replaces with a single store: a reader sees all of the old settings
+// or all of the new ones, never a mix of both.
+package settings
+
+import (
+	"sync/atomic"
+	"time"
+
+	"txn-service/internal/logger"
+	"txn-service/internal/ratelimit"
+)
+
+// Settings is a snapshot of the runtime settings. It must not be modified once
+// it is in a Store; a change builds a new Settings.
+type Settings struct {
+	LogLevel             logger.Level
+	DebugMode            bool
+	SlowRequestThreshold time.Duration
+	RateLimits           ratelimit.Limits
+	RequireVerifiedKYC   bool
+	// TransferMaxAmount bounds a single transfer and TransferDailyLimit the
+	// total an account sends per UTC day; both are decimal strings, and an
+	// empty one is no limit
+	TransferMaxAmount  string
+	TransferDailyLimit string
+}
+
+// Store holds the settings in force
+type Store struct {
+	current atomic.Pointer[Settings]
+}
+
+func NewStore(initial *Settings) *Store {
+	store := &Store{}
+	store.Replace(initial)
+	return store
+}
+
+// Current returns the settings in force. Code that reads more than one setting
+// for a request should read them from a single Current.
+func (s *Store) Current() *Settings {
+	return s.current.Load()
+}
+
+// Replace puts next in force for every reader from now on
+func (s *Store) Replace(next *Settings) {
+	s.current.Store(next)
+}
@@ -0,0 +1,40 @@
+// Package tenant carries the tenant a request acts in. Accounts, customers and
+// transactions belong to a single tenant and the repositories only ever see
+// the rows of the tenant in the request context.
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+)
+
+// Default is the tenant of requests that do not name one: unauthenticated
+// requests, background jobs and credentials created before multi-tenancy
+const Default = "default"
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Validate checks that id is a tenant ID: 1 to 64 lowercase letters, digits,
+// underscores or dashes, starting with a letter or digit
+func Validate(id string) error {
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("invalid tenant ID %q: use 1 to 64 lowercase letters, digits, '_' or '-'", id)
+	}
+	return nil
+}
+
+type tenantKey struct{}
+
+// WithID returns a copy of ctx acting in tenant id
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant of ctx, or Default when it names none
+func FromContext(ctx context.Context) string {
+	if id, _ := ctx.Value(tenantKey{}).(string); id != "" {
+		return id
+	}
+	return Default
+}
@@ -0,0 +1,23 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, Default, FromContext(context.Background()))
+	assert.Equal(t, Default, FromContext(WithID(context.Background(), "")))
+	assert.Equal(t, "acme", FromContext(WithID(context.Background(), "acme")))
+}
+
+func TestValidate(t *testing.T) {
+	for _, id := range []string{"default", "acme", "acme-eu_1", "9lives"} {
+		assert.NoError(t, Validate(id), id)
+	}
+	for _, id := range []string{"", "Acme", "-acme", "acme corp", "acme/eu", string(make([]byte, 65))} {
+		assert.Error(t, Validate(id), id)
+	}
+}
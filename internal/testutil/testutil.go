@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -13,23 +14,52 @@ import (
 	"testing"
 	"time"
 
+	"txn-service/internal/app"
+	"txn-service/internal/auth"
+	"txn-service/internal/config"
 	"txn-service/internal/database"
 	"txn-service/internal/handlers"
-	"txn-service/internal/repository"
+	"txn-service/internal/logger"
 	"txn-service/internal/service"
+	"txn-service/internal/tenant"
 
 	"github.com/stretchr/testify/require"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
 )
 
 type TestServer struct {
-	Server  *httptest.Server
-	DB      *sql.DB
-	Cleanup func()
-	client  *http.Client
+	Server          *httptest.Server
+	GRPCConn        *grpc.ClientConn
+	DB              *sql.DB
+	InterestService service.InterestService
+	APIKeyService   service.APIKeyService
+	Health          *handlers.HealthHandler
+	Cleanup         func()
+	client          *http.Client
 }
 
+// bearerTransport adds a bearer token to requests that carry no Authorization
+// header, so the helpers keep working when AUTH_ENABLED is set
+type bearerTransport struct {
+	token string
+}
+
+func (b *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Authorization") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// InterestExpenseAccountID is the account the test server pays interest from.
+// Tests that exercise interest posting need to create it first.
+const InterestExpenseAccountID int64 = 999999
+
 func SetupTestServer(t *testing.T) *TestServer {
 	t.Helper()
 
@@ -63,97 +93,91 @@ func SetupTestServer(t *testing.T) *TestServer {
 
 	time.Sleep(2 * time.Second)
 
-	db, err := database.NewConnection(databaseURL)
+	// the test server pays interest from InterestExpenseAccountID, also after
+	// a configuration reload, and only authenticates for tests that set
+	// AUTH_ENABLED
+	load := func() (*config.Config, error) {
+		cfg, err := config.Load("", nil)
+		if err != nil {
+			return nil, err
+		}
+		cfg.InterestExpenseAccountID = InterestExpenseAccountID
+		if _, ok := os.LookupEnv("AUTH_ENABLED"); !ok {
+			cfg.AuthEnabled = false
+		}
+		return cfg, nil
+	}
+
+	cfg, err := load()
 	require.NoError(t, err)
 
-	err = runMigrations(db)
+	db, err := database.NewConnection(databaseURL, cfg.DatabaseOptions())
 	require.NoError(t, err)
 
 	err = db.Ping()
 	require.NoError(t, err)
 
-	accountRepo := repository.NewAccountRepository(db)
-	transactionRepo := repository.NewTransactionRepository(db)
+	log := logger.NewFromConfig(cfg.LoggerConfig())
 
-	accountService := service.NewAccountService(accountRepo)
-	transactionService := service.NewTransactionService(transactionRepo, accountRepo)
+	application, err := app.New(cfg, load, db, log)
+	require.NoError(t, err)
 
-	accountHandler := handlers.NewAccountHandler(accountService)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	server := httptest.NewServer(application.Router)
 
-	router := handlers.SetupRoutes(accountHandler, transactionHandler)
+	grpcListener := bufconn.Listen(1 << 20)
+	grpcServer := application.GRPCServer
+	go grpcServer.Serve(grpcListener)
 
-	server := httptest.NewServer(router)
+	grpcConn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return grpcListener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
 
 	cleanup := func() {
+		grpcConn.Close()
+		grpcServer.Stop()
 		server.Close()
 		db.Close()
+		log.Close()
 		postgres.Terminate(ctx)
 	}
 
 	ts := &TestServer{
-		Server:  server,
-		DB:      db,
-		Cleanup: cleanup,
+		Server:          server,
+		GRPCConn:        grpcConn,
+		DB:              db,
+		InterestService: application.InterestService,
+		APIKeyService:   application.APIKeyService,
+		Health:          application.Health,
+		Cleanup:         cleanup,
 	}
 
 	ts.client = &http.Client{
 		Timeout: 10 * time.Second,
 	}
 
+	if cfg.AuthEnabled {
+		ts.client.Transport = &bearerTransport{token: ts.CreateTestAPIKey(t, auth.AllScopes...)}
+	}
+
 	return ts
 }
 
-func runMigrations(db *sql.DB) error {
-
-	accountsTable := `
-		CREATE TABLE IF NOT EXISTS accounts (
-			id SERIAL PRIMARY KEY,
-			account_id BIGINT UNIQUE NOT NULL,
-			balance DECIMAL(15,2) NOT NULL DEFAULT 0,
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
-		);
-	`
-
-	transactionsTable := `
-		CREATE TABLE IF NOT EXISTS transactions (
-			id SERIAL PRIMARY KEY,
-			transaction_id UUID UNIQUE NOT NULL,
-			source_account_id BIGINT NOT NULL,
-			destination_account_id BIGINT NOT NULL,
-			amount DECIMAL(15,2) NOT NULL,
-			status VARCHAR(20) NOT NULL DEFAULT 'pending',
-			created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
-			FOREIGN KEY (source_account_id) REFERENCES accounts(account_id),
-			FOREIGN KEY (destination_account_id) REFERENCES accounts(account_id)
-		);
-	`
-
-	indexes := `
-		CREATE INDEX IF NOT EXISTS idx_accounts_account_id ON accounts(account_id);
-		CREATE INDEX IF NOT EXISTS idx_transactions_transaction_id ON transactions(transaction_id);
-		CREATE INDEX IF NOT EXISTS idx_transactions_source_account_id ON transactions(source_account_id);
-		CREATE INDEX IF NOT EXISTS idx_transactions_destination_account_id ON transactions(destination_account_id);
-	`
-
-	_, err := db.Exec(accountsTable)
-	if err != nil {
-		return fmt.Errorf("failed to create accounts table: %w", err)
-	}
+// CreateTestAPIKey creates an API key with the given scopes and returns its token
+func (ts *TestServer) CreateTestAPIKey(t *testing.T, scopes ...string) string {
+	t.Helper()
+	return ts.CreateTestTenantAPIKey(t, tenant.Default, scopes...)
+}
 
-	_, err = db.Exec(transactionsTable)
-	if err != nil {
-		return fmt.Errorf("failed to create transactions table: %w", err)
-	}
+// CreateTestTenantAPIKey creates an API key acting in tenantID and returns its token
+func (ts *TestServer) CreateTestTenantAPIKey(t *testing.T, tenantID string, scopes ...string) string {
+	t.Helper()
 
-	_, err = db.Exec(indexes)
-	if err != nil {
-		return fmt.Errorf("failed to create indexes: %w", err)
-	}
+	_, token, err := ts.APIKeyService.CreateAPIKey(context.Background(), t.Name(), tenantID, scopes)
+	require.NoError(t, err)
 
-	return nil
+	return token
 }
 
 func (ts *TestServer) CreateTestAccount(t *testing.T, accountID int64, balance string) {
@@ -172,6 +196,104 @@ func (ts *TestServer) CreateTestAccount(t *testing.T, accountID int64, balance s
 	resp.Body.Close()
 }
 
+func (ts *TestServer) CreateTestProduct(t *testing.T, productCode, annualInterestRate, dayCountConvention string) {
+	t.Helper()
+
+	url := fmt.Sprintf("%s/products", ts.Server.URL)
+	payload := fmt.Sprintf(`{"product_code": "%s", "name": "%s", "annual_interest_rate": "%s", "day_count_convention": "%s"}`,
+		productCode, productCode, annualInterestRate, dayCountConvention)
+
+	req, err := http.NewRequest("POST", url, strings.NewReader(payload))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ts.client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func (ts *TestServer) CreateTestAccountWithProduct(t *testing.T, accountID int64, balance, productCode string) {
+	t.Helper()
+
+	url := fmt.Sprintf("%s/accounts", ts.Server.URL)
+	payload := fmt.Sprintf(`{"account_id": %d, "initial_balance": "%s", "product_code": "%s"}`, accountID, balance, productCode)
+
+	req, err := http.NewRequest("POST", url, strings.NewReader(payload))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ts.client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+}
+
+func (ts *TestServer) CreateTestCustomer(t *testing.T, externalReference, kycStatus string) int64 {
+	t.Helper()
+
+	url := fmt.Sprintf("%s/customers", ts.Server.URL)
+	payload := fmt.Sprintf(`{"name": "Customer %s", "external_reference": "%s", "kyc_status": "%s"}`, externalReference, externalReference, kycStatus)
+
+	req, err := http.NewRequest("POST", url, strings.NewReader(payload))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ts.client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	defer resp.Body.Close()
+
+	var customer struct {
+		CustomerID int64 `json:"customer_id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&customer))
+
+	return customer.CustomerID
+}
+
+func (ts *TestServer) CreateTestAccountForCustomer(t *testing.T, accountID int64, balance string, customerID int64) {
+	t.Helper()
+
+	url := fmt.Sprintf("%s/accounts", ts.Server.URL)
+	payload := fmt.Sprintf(`{"account_id": %d, "initial_balance": "%s", "customer_id": %d}`, accountID, balance, customerID)
+
+	req, err := http.NewRequest("POST", url, strings.NewReader(payload))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ts.client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	resp.Body.Close()
+}
+
+// CreateTestAccountWithServerID creates an account without an account_id and
+// returns the ID and account number allocated by the server
+func (ts *TestServer) CreateTestAccountWithServerID(t *testing.T, balance string) (int64, string) {
+	t.Helper()
+
+	url := fmt.Sprintf("%s/accounts", ts.Server.URL)
+	payload := fmt.Sprintf(`{"initial_balance": "%s"}`, balance)
+
+	req, err := http.NewRequest("POST", url, strings.NewReader(payload))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := ts.client.Do(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	defer resp.Body.Close()
+
+	var account struct {
+		AccountID     int64  `json:"account_id"`
+		AccountNumber string `json:"account_number"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&account))
+
+	return account.AccountID, account.AccountNumber
+}
+
 func (ts *TestServer) GetAccountBalance(t *testing.T, accountID int64) string {
 	t.Helper()
 
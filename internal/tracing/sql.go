@@ -0,0 +1,192 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WrapConnector traces every statement run through connections of connector.
+// Spans carry the statement text with its placeholders, never the arguments,
+// so no values reach the traces.
+func WrapConnector(connector driver.Connector) driver.Connector {
+	return &tracedConnector{Connector: connector}
+}
+
+type tracedConnector struct {
+	driver.Connector
+}
+
+func (c *tracedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: conn}, nil
+}
+
+// tracedConn implements the optional driver interfaces database/sql looks for
+// and delegates them to the wrapped connection, so a wrapped lib/pq connection
+// still reports itself bad through ResetSession and IsValid and converts its
+// own arguments through CheckNamedValue. The ones the wrapped connection
+// lacks fall back to what database/sql does without them.
+type tracedConn struct {
+	driver.Conn
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := startStatement(ctx, query)
+	rows, err := queryer.QueryContext(ctx, query, args)
+	endStatement(span, err)
+	return rows, err
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	ctx, span := startStatement(ctx, query)
+	result, err := execer.ExecContext(ctx, query, args)
+	endStatement(span, err)
+	return result, err
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+// BeginTx begins through Begin when the wrapped connection lacks BeginTx.
+// database/sql does not fall back on ErrSkip here, so the fallback and its
+// checks of opts are the ones database/sql applies to such a connection.
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	spanCtx, span := startStatement(ctx, "BEGIN")
+	tx, err := c.beginTx(spanCtx, opts)
+	endStatement(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	// commit and rollback get no context, so their spans are children of the
+	// span the transaction was begun in
+	return &tracedTx{Tx: tx, ctx: ctx}, nil
+}
+
+func (c *tracedConn) beginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, errors.New("sql: driver does not support non-default isolation level")
+	}
+	if opts.ReadOnly {
+		return nil, errors.New("sql: driver does not support read-only transactions")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Conn.Begin()
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue lets the wrapped connection convert arguments itself;
+// ErrSkip makes database/sql use its default conversion
+func (c *tracedConn) CheckNamedValue(value *driver.NamedValue) error {
+	if checker, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(value)
+	}
+	return driver.ErrSkip
+}
+
+type tracedTx struct {
+	driver.Tx
+	ctx context.Context
+}
+
+func (t *tracedTx) Commit() error {
+	_, span := startStatement(t.ctx, "COMMIT")
+	err := t.Tx.Commit()
+	endStatement(span, err)
+	return err
+}
+
+func (t *tracedTx) Rollback() error {
+	_, span := startStatement(t.ctx, "ROLLBACK")
+	err := t.Tx.Rollback()
+	endStatement(span, err)
+	return err
+}
+
+// startStatement starts a client span for a SQL statement, named after its
+// operation, like "SELECT FOR UPDATE" for a row lock
+func startStatement(ctx context.Context, query string) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, statementName(query),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBStatement(strings.TrimSpace(query)),
+			attribute.Bool("db.row_lock", isRowLock(query)),
+		),
+	)
+}
+
+func endStatement(span trace.Span, err error) {
+	// ErrSkip is the fallback protocol of database/sql, not a failure
+	if err == driver.ErrSkip {
+		err = nil
+	}
+	End(span, err)
+}
+
+func statementName(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+
+	operation := strings.ToUpper(fields[0])
+	if operation == "SELECT" && isRowLock(query) {
+		return "SELECT FOR UPDATE"
+	}
+	return operation
+}
+
+func isRowLock(query string) bool {
+	return strings.Contains(strings.ToUpper(query), "FOR UPDATE")
+}
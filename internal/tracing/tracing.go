@@ -0,0 +1,140 @@
+// Package tracing sets up OpenTelemetry tracing. Spans are started for HTTP
+// requests and gRPC calls, for transfers and for every SQL statement, and the
+// W3C trace context of incoming requests is continued.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName names the tracer of the service's own spans
+const instrumentationName = "txn-service"
+
+// Config selects where spans are exported
+type Config struct {
+	// Exporter is "none", "otlp" or "stdout"
+	Exporter string
+	// OTLPEndpoint is the host:port of the OTLP gRPC collector; empty uses the
+	// standard OTEL_EXPORTER_OTLP_ENDPOINT or localhost:4317
+	OTLPEndpoint string
+	OTLPInsecure bool
+	// SampleRatio is the fraction of new traces that are recorded; traces
+	// continued from a caller follow the caller's decision
+	SampleRatio float64
+	// RedactAmounts keeps transfer amounts out of span attributes
+	RedactAmounts bool
+	// ServiceVersion is reported as service.version
+	ServiceVersion string
+}
+
+// redactAmounts is set by Setup; see Amount
+var redactAmounts = true
+
+// Setup installs the W3C trace context propagator and, unless the exporter is
+// "none", a tracer provider exporting spans. The returned function flushes and
+// stops the exporter.
+func Setup(ctx context.Context, config Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	redactAmounts = config.RedactAmounts
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch config.Exporter {
+	case "", "none":
+		return func(context.Context) error { return nil }, nil
+	case "otlp":
+		options := []otlptracegrpc.Option{}
+		if config.OTLPEndpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(config.OTLPEndpoint))
+		}
+		if config.OTLPInsecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, options...)
+	case "stdout":
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q, must be none, otlp or stdout", config.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", config.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(instrumentationName),
+		semconv.ServiceVersion(config.ServiceVersion),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// Tracer is the tracer of the service's spans. It follows the global provider,
+// so spans started before Setup are not recorded.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// Start starts a span named name as a child of the span in ctx
+func Start(ctx context.Context, name string, attributes ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, trace.WithAttributes(attributes...))
+}
+
+// End records err on span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Attribute keys of the service's spans
+const (
+	SourceAccountIDKey      = attribute.Key("txn.source_account_id")
+	DestinationAccountIDKey = attribute.Key("txn.destination_account_id")
+	TransactionIDKey        = attribute.Key("txn.transaction_id")
+	AmountKey               = attribute.Key("txn.amount")
+	TenantIDKey             = attribute.Key("txn.tenant_id")
+	RequestIDKey            = attribute.Key("txn.request_id")
+)
+
+// Amount is the amount attribute of a transfer, or nothing when amounts are
+// redacted
+func Amount(amount string) []attribute.KeyValue {
+	if redactAmounts {
+		return nil
+	}
+	return []attribute.KeyValue{AmountKey.String(amount)}
+}
+
+// TraceID is the ID of the trace ctx is part of, or "" when it is in none
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+	return spanContext.TraceID().String()
+}
@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatementName(t *testing.T) {
+	tests := map[string]string{
+		"SELECT id FROM accounts WHERE account_id = $1":                       "SELECT",
+		"\n\t\tSELECT balance FROM accounts WHERE account_id = $1 FOR UPDATE": "SELECT FOR UPDATE",
+		"update accounts SET balance = $1":                                    "UPDATE",
+		"INSERT INTO transactions (transaction_id) VALUES ($1)":               "INSERT",
+		"   ": "SQL",
+	}
+
+	for query, want := range tests {
+		assert.Equal(t, want, statementName(query), query)
+	}
+}
+
+func TestAmountRedaction(t *testing.T) {
+	defer func() { redactAmounts = true }()
+
+	_, err := Setup(context.Background(), Config{Exporter: "none", RedactAmounts: true})
+	require.NoError(t, err)
+	assert.Empty(t, Amount("100.00"))
+
+	_, err = Setup(context.Background(), Config{Exporter: "none", RedactAmounts: false})
+	require.NoError(t, err)
+	attributes := Amount("100.00")
+	require.Len(t, attributes, 1)
+	assert.Equal(t, "100.00", attributes[0].Value.AsString())
+}
+
+func TestSetupUnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), Config{Exporter: "jaeger"})
+	assert.Error(t, err)
+}
+
+// stubConn is a driver connection with only the methods driver.Conn requires
+type stubConn struct {
+	begun int
+}
+
+func (c *stubConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *stubConn) Close() error { return nil }
+
+func (c *stubConn) Begin() (driver.Tx, error) {
+	c.begun++
+	return stubTx{}, nil
+}
+
+type stubTx struct{}
+
+func (stubTx) Commit() error   { return nil }
+func (stubTx) Rollback() error { return nil }
+
+// checkedConn also implements the optional interfaces lib/pq implements
+type checkedConn struct {
+	stubConn
+	valid   bool
+	checked int
+}
+
+func (c *checkedConn) ResetSession(ctx context.Context) error {
+	if !c.valid {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *checkedConn) IsValid() bool { return c.valid }
+
+func (c *checkedConn) CheckNamedValue(value *driver.NamedValue) error {
+	c.checked++
+	return driver.ErrSkip
+}
+
+func TestTracedConnForwardsOptionalInterfaces(t *testing.T) {
+	conn := &tracedConn{Conn: &checkedConn{valid: false}}
+	assert.False(t, conn.IsValid())
+	assert.Equal(t, driver.ErrBadConn, conn.ResetSession(context.Background()))
+	assert.Equal(t, driver.ErrSkip, conn.CheckNamedValue(&driver.NamedValue{Value: 1}))
+	assert.Equal(t, 1, conn.Conn.(*checkedConn).checked)
+
+	plain := &tracedConn{Conn: &stubConn{}}
+	assert.True(t, plain.IsValid())
+	assert.NoError(t, plain.ResetSession(context.Background()))
+	assert.Equal(t, driver.ErrSkip, plain.CheckNamedValue(&driver.NamedValue{Value: 1}))
+}
+
+func TestTracedConnBeginFallback(t *testing.T) {
+	wrapped := &stubConn{}
+	conn := &tracedConn{Conn: wrapped}
+
+	tx, err := conn.BeginTx(context.Background(), driver.TxOptions{})
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, 1, wrapped.begun, "a connection without BeginTx is begun through Begin")
+
+	_, err = conn.BeginTx(context.Background(), driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSerializable)})
+	assert.Error(t, err)
+	_, err = conn.BeginTx(context.Background(), driver.TxOptions{ReadOnly: true})
+	assert.Error(t, err)
+	assert.Equal(t, 1, wrapped.begun)
+}
@@ -0,0 +1,75 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// MaxBodySize is the largest request body DecodeJSON accepts
+const MaxBodySize = 64 << 10
+
+// ErrBodyTooLarge is returned by DecodeJSON for bodies over MaxBodySize
+var ErrBodyTooLarge = fmt.Errorf("request body cannot be larger than %d bytes", MaxBodySize)
+
+// DecodeJSON decodes a single JSON object from the request body into dst.
+// Unknown fields are reported as a FieldError on that field; malformed JSON,
+// trailing data and oversized bodies are returned as plain errors.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodySize)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		return decodeError(err)
+	}
+
+	if err := decoder.Decode(&struct{}{}); err != io.EOF {
+		if err != nil {
+			return decodeError(err)
+		}
+		return errors.New("request body must contain a single JSON object")
+	}
+
+	return nil
+}
+
+func decodeError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return ErrBodyTooLarge
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return &FieldError{Field: typeErr.Field, Message: fmt.Sprintf("must be of type %s", jsonType(typeErr.Type.Kind().String()))}
+	}
+
+	// encoding/json has no typed error for unknown fields
+	if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		return &FieldError{Field: strings.Trim(field, `"`), Message: "is not a known field"}
+	}
+
+	if errors.Is(err, io.EOF) {
+		return errors.New("request body cannot be empty")
+	}
+
+	return fmt.Errorf("request body is not valid JSON: %w", err)
+}
+
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "map" || kind == "struct":
+		return "object"
+	case kind == "slice":
+		return "array"
+	default:
+		return kind
+	}
+}
@@ -0,0 +1,109 @@
+package validation
+
+import (
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"txn-service/internal/accountnumber"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// decimalPattern matches plain decimal strings; exponents, hex, NaN and Inf are
+// rejected because amounts are stored as DECIMAL and compared exactly
+var decimalPattern = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+func registerCustomRules(v *validator.Validate) {
+	v.RegisterValidation("decimal", isDecimal)
+	v.RegisterValidation("positive", isPositive)
+	v.RegisterValidation("nonnegative", isNonNegative)
+	v.RegisterValidation("maxprecision", hasMaxPrecision)
+	v.RegisterValidation("maxamount", hasMaxAmount)
+	v.RegisterValidation("accountnumber", isAccountNumber)
+	v.RegisterValidation("metadatasize", hasMaxMetadataSize)
+}
+
+// ParseDecimal parses a plain decimal string such as "100.25", the format of
+// the decimal rule
+func ParseDecimal(value string) (*big.Rat, bool) {
+	if !decimalPattern.MatchString(value) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(value)
+}
+
+func isDecimal(fl validator.FieldLevel) bool {
+	_, ok := ParseDecimal(fl.Field().String())
+	return ok
+}
+
+func isPositive(fl validator.FieldLevel) bool {
+	amount, ok := ParseDecimal(fl.Field().String())
+	return ok && amount.Sign() > 0
+}
+
+func isNonNegative(fl validator.FieldLevel) bool {
+	amount, ok := ParseDecimal(fl.Field().String())
+	return ok && amount.Sign() >= 0
+}
+
+// hasMaxPrecision passes values that are not decimals so that only the decimal
+// rule reports them
+func hasMaxPrecision(fl validator.FieldLevel) bool {
+	maxDigits, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		panic("validation: maxprecision needs an integer parameter")
+	}
+
+	value := fl.Field().String()
+	if !decimalPattern.MatchString(value) {
+		return true
+	}
+
+	i := strings.IndexByte(value, '.')
+	return i < 0 || len(value)-i-1 <= maxDigits
+}
+
+func hasMaxAmount(fl validator.FieldLevel) bool {
+	limit, ok := new(big.Rat).SetString(fl.Param())
+	if !ok {
+		panic("validation: maxamount needs a decimal parameter")
+	}
+
+	amount, ok := ParseDecimal(fl.Field().String())
+	if !ok {
+		return true
+	}
+
+	return new(big.Rat).Abs(amount).Cmp(limit) <= 0
+}
+
+func isAccountNumber(fl validator.FieldLevel) bool {
+	_, err := accountnumber.Parse(fl.Field().String())
+	return err == nil
+}
+
+// hasMaxMetadataSize bounds the combined length of all keys and values of a
+// map[string]string
+func hasMaxMetadataSize(fl validator.FieldLevel) bool {
+	maxSize, err := strconv.Atoi(fl.Param())
+	if err != nil {
+		panic("validation: metadatasize needs an integer parameter")
+	}
+
+	field := fl.Field()
+	if field.Kind() != reflect.Map {
+		return false
+	}
+
+	size := 0
+	iter := field.MapRange()
+	for iter.Next() {
+		size += len(iter.Key().String()) + len(iter.Value().String())
+	}
+
+	return size <= maxSize
+}
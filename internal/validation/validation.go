@@ -0,0 +1,181 @@
+// Package validation enforces the `validate` struct tags on request models.
+// On top of the standard go-playground/validator rules it registers:
+//
+//	decimal         a plain decimal string such as "100.25" (no exponent)
+//	positive        a decimal string greater than zero
+//	nonnegative     a decimal string greater than or equal to zero
+//	maxprecision=N  at most N digits after the decimal point
+//	maxamount=X     absolute value at most X
+//	accountnumber   an account number with valid check digits
+//	metadatasize=N  total size of a string map's keys and values at most N bytes
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrInvalid is matched by every validation error
+var ErrInvalid = errors.New("invalid input")
+
+// FieldError describes one invalid field using its JSON name
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+func (e *FieldError) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// Errors holds every violation found in a request
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e Errors) Is(target error) bool {
+	return target == ErrInvalid
+}
+
+// Field returns a validation error for a single field
+func Field(field, format string, args ...interface{}) error {
+	return &FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+var (
+	validate     *validator.Validate
+	validateOnce sync.Once
+)
+
+func instance() *validator.Validate {
+	validateOnce.Do(func() {
+		validate = validator.New()
+
+		// report fields by their JSON name so errors match the request body
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+
+		registerCustomRules(validate)
+	})
+
+	return validate
+}
+
+// Struct validates s against its `validate` tags and returns all violations
+// as Errors, or nil when s is valid
+func Struct(s interface{}) error {
+	err := instance().Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fieldErrs := make(Errors, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fieldErrs = append(fieldErrs, &FieldError{
+			Field:   fieldPath(fieldErr),
+			Message: message(fieldErr),
+		})
+	}
+
+	return fieldErrs
+}
+
+// fieldPath drops the struct name from the namespace, e.g.
+// CreateTransactionRequest.metadata[key] becomes metadata[key]
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func message(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return fmt.Sprintf("is required when %s is not given", jsonName(param))
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", param)
+	case "gte":
+		return fmt.Sprintf("must be greater than or equal to %s", param)
+	case "min":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", param)
+		}
+		return fmt.Sprintf("must have at least %s entries", param)
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return fmt.Sprintf("cannot be longer than %s characters", param)
+		}
+		return fmt.Sprintf("cannot have more than %s entries", param)
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(param), ", "))
+	case "decimal":
+		return "must be a decimal number"
+	case "positive":
+		return "must be a decimal number greater than zero"
+	case "nonnegative":
+		return "must be a decimal number greater than or equal to zero"
+	case "maxprecision":
+		return fmt.Sprintf("cannot have more than %s decimal places", param)
+	case "maxamount":
+		return fmt.Sprintf("cannot exceed %s", param)
+	case "accountnumber":
+		return "must be a valid account number"
+	case "metadatasize":
+		return fmt.Sprintf("cannot be larger than %s bytes", param)
+	default:
+		return fmt.Sprintf("failed the %s rule", fieldErr.Tag())
+	}
+}
+
+// jsonName converts the Go field name used in cross-field rules to the snake
+// case JSON name, e.g. SourceAccountNumber becomes source_account_number
+func jsonName(field string) string {
+	var b strings.Builder
+	for i, r := range field {
+		if r >= 'A' && r <= 'Z' {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+package validation
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"txn-service/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fieldMessages(t *testing.T, err error) map[string]string {
+	t.Helper()
+
+	var fieldErrs Errors
+	require.True(t, errors.As(err, &fieldErrs), "expected validation errors, got %v", err)
+
+	messages := map[string]string{}
+	for _, fieldErr := range fieldErrs {
+		messages[fieldErr.Field] = fieldErr.Message
+	}
+	return messages
+}
+
+func TestStructValid(t *testing.T) {
+	req := &models.CreateTransactionRequest{
+		SourceAccountID:          1,
+		DestinationAccountNumber: "TX06000000000123",
+		Amount:                   "100.12345678",
+		Metadata:                 map[string]string{"order": "42"},
+	}
+
+	assert.NoError(t, Struct(req))
+}
+
+func TestStructReportsAllViolations(t *testing.T) {
+	req := &models.CreateTransactionRequest{
+		DestinationAccountID: -1,
+		Amount:               "1.123456789",
+		Reference:            strings.Repeat("x", 256),
+		Metadata:             map[string]string{"": "value"},
+	}
+
+	err := Struct(req)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrInvalid))
+
+	messages := fieldMessages(t, err)
+	assert.Equal(t, "is required when source_account_number is not given", messages["source_account_id"])
+	assert.Equal(t, "must be greater than or equal to 0", messages["destination_account_id"])
+	assert.Equal(t, "cannot have more than 8 decimal places", messages["amount"])
+	assert.Equal(t, "cannot be longer than 255 characters", messages["reference"])
+	assert.Contains(t, messages, "metadata[]")
+}
+
+func TestAmountRules(t *testing.T) {
+	tests := []struct {
+		amount  string
+		message string
+	}{
+		{"10", ""},
+		{"0.00000001", ""},
+		{"999999999999", ""},
+		{"", "is required"},
+		{"abc", "must be a decimal number"},
+		{"1e5", "must be a decimal number"},
+		{"NaN", "must be a decimal number"},
+		{".5", "must be a decimal number"},
+		{"0", "must be a decimal number greater than zero"},
+		{"-5.00", "must be a decimal number greater than zero"},
+		{"1000000000000", "cannot exceed 999999999999"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			err := Struct(&models.CreateTransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: tt.amount})
+			if tt.message == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Equal(t, tt.message, fieldMessages(t, err)["amount"])
+		})
+	}
+}
+
+func TestMetadataSize(t *testing.T) {
+	metadata := map[string]string{}
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i"} {
+		metadata[key] = strings.Repeat("v", 500)
+	}
+
+	err := Struct(&models.CreateTransactionRequest{SourceAccountID: 1, DestinationAccountID: 2, Amount: "1", Metadata: metadata})
+	assert.Equal(t, "cannot be larger than 4096 bytes", fieldMessages(t, err)["metadata"])
+}
+
+func TestDecodeJSON(t *testing.T) {
+	decode := func(body string) error {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		var dst models.CreateAccountRequest
+		return DecodeJSON(httptest.NewRecorder(), req, &dst)
+	}
+
+	assert.NoError(t, decode(`{"initial_balance": "1.00"}`))
+
+	var fieldErr *FieldError
+	require.True(t, errors.As(decode(`{"initial_balance": "1.00", "balance": "2"}`), &fieldErr))
+	assert.Equal(t, "balance", fieldErr.Field)
+
+	require.True(t, errors.As(decode(`{"initial_balance": 1}`), &fieldErr))
+	assert.Equal(t, "initial_balance", fieldErr.Field)
+
+	assert.Error(t, decode(`{"initial_balance": "1.00"} {}`))
+	assert.Error(t, decode(``))
+	assert.ErrorIs(t, decode(`{"initial_balance": "`+strings.Repeat("1", MaxBodySize)+`"}`), ErrBodyTooLarge)
+}
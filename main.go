@@ -1,78 +1,48 @@
 package main
 
 import (
-	"context"
-	"net/http"
+	"fmt"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
-
-	"txn-service/internal/config"
-	"txn-service/internal/database"
-	"txn-service/internal/handlers"
-	"txn-service/internal/logger"
-	"txn-service/internal/repository"
-	"txn-service/internal/service"
 )
 
-func main() {
-	// Initialize the logger from ENV vars - supports Log level and file logging
-	logger := logger.NewFromEnv()
-	logger.Info("Starting transaction service")
-
-	// Load configuration
-	cfg := config.Load()
-	logger.Info("Configuration loaded - server_address: %s", cfg.ServerAddress)
-
-	db, err := database.NewConnection(cfg.DatabaseURL)
-	if err != nil {
-		logger.Error("Failed to connect to database: %v", err)
-		os.Exit(1)
-	}
-	defer db.Close()
-	logger.Info("Database connection established")
-
-	accountRepo := repository.NewAccountRepository(db)
-	transactionRepo := repository.NewTransactionRepository(db)
-
-	accountService := service.NewAccountService(accountRepo)
-	transactionService := service.NewTransactionService(transactionRepo, accountRepo)
-
-	accountHandler := handlers.NewAccountHandler(accountService)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
-
-	router := handlers.SetupRoutes(accountHandler, transactionHandler)
+const usage = `usage: txn-service [command]
+
+commands:
+  serve          run the HTTP and gRPC servers (the default)
+  migrate        apply, revert or list the schema migrations
+  accounts       create, show, freeze and unfreeze accounts
+  transactions   show and reverse transactions
+  reconcile      find and fail transactions stuck pending, list negative balances
+  apikeys        create, revoke and list API keys
+  config         print the effective configuration
+
+Run "txn-service <command> -h" for its usage. Every command reads its
+configuration like the server: from the YAML file given by --config or
+CONFIG_FILE, then the environment, then --set <key>=<value> flags.
+`
+
+// commands run with the arguments after their name and return the process
+// exit code: 0 on success, 1 on failure and 2 on a usage error
+var commands = map[string]func(args []string) int{
+	"serve":        runServe,
+	"migrate":      runMigrateCommand,
+	"accounts":     runAccountsCommand,
+	"transactions": runTransactionsCommand,
+	"reconcile":    runReconcileCommand,
+	"apikeys":      runAPIKeysCommand,
+	"config":       runConfigCommand,
+}
 
-	server := &http.Server{
-		Addr:         cfg.ServerAddress,
-		Handler:      router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+func main() {
+	// without a command the binary serves, as it always has
+	if len(os.Args) < 2 {
+		os.Exit(runServe(nil))
 	}
 
-	go func() {
-		logger.Info("Starting HTTP server - address: %s", cfg.ServerAddress)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			logger.Error("Failed to start server: %v", err)
-			os.Exit(1)
-		}
-	}()
-
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-
-	logger.Info("Received shutdown signal, shutting down server...")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Error("Server forced to shutdown: %v", err)
-		os.Exit(1)
+	command, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
 	}
-
-	logger.Info("Server shutdown completed")
+	os.Exit(command(os.Args[2:]))
 }
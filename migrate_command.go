@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"txn-service/internal/database"
+)
+
+const migrateUsage = `usage: txn-service migrate <command>
+
+commands:
+  up                     apply every pending migration
+  down [--steps <n>]     revert the last n applied migrations, 1 by default
+  status [-o json]       list the migrations and when they were applied
+`
+
+// migrationStatusOutput is a row of migrate status
+type migrationStatusOutput struct {
+	Version   int64      `json:"version"`
+	Name      string     `json:"name"`
+	AppliedAt *time.Time `json:"applied_at"`
+}
+
+// runMigrateCommand changes or reports the schema of the configured database
+// and returns the process exit code
+func runMigrateCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("migrate "+args[0], flag.ContinueOnError)
+	source := configFlags(flags)
+	var steps *int
+	var output *string
+	switch args[0] {
+	case "up":
+	case "down":
+		steps = flags.Int("steps", 1, "number of migrations to revert")
+	case "status":
+		output = outputFlag(flags)
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+	if positional, err := parseFlags(flags, args[1:]); err != nil || len(positional) > 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+	if output != nil {
+		if err := checkOutput(*output); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	if steps != nil && *steps < 1 {
+		fmt.Fprintln(os.Stderr, "--steps must be at least 1")
+		return 2
+	}
+
+	env, err := openAdminEnv(source, false)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer env.Close()
+
+	migrator, err := database.NewMigrator(env.db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load migrations: %v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to migrate: %v\n", err)
+			return 1
+		}
+		fmt.Printf("applied %d migrations, schema is at version %d\n", applied, migrator.LatestVersion())
+
+	case "down":
+		reverted, err := migrator.Down(ctx, *steps)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to revert migrations: %v\n", err)
+			return 1
+		}
+		fmt.Printf("reverted %d migrations\n", reverted)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to read migrations: %v\n", err)
+			return 1
+		}
+
+		rows := make([]migrationStatusOutput, 0, len(statuses))
+		for _, status := range statuses {
+			rows = append(rows, migrationStatusOutput{Version: status.Version, Name: status.Name, AppliedAt: status.AppliedAt})
+		}
+		err = printOutput(*output, rows, func(w io.Writer) {
+			fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED")
+			for _, row := range rows {
+				fmt.Fprintf(w, "%d\t%s\t%s\n", row.Version, row.Name, formatTime(row.AppliedAt))
+			}
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to print migrations: %v\n", err)
+			return 1
+		}
+	}
+
+	return 0
+}
@@ -7,41 +7,189 @@ import (
 )
 
 type Account struct {
-	ID        int64     `json:"-" db:"id"`
-	AccountID int64     `json:"account_id" db:"account_id"`
-	Balance   string    `json:"balance" db:"balance"`
-	CreatedAt time.Time `json:"-" db:"created_at"`
-	UpdatedAt time.Time `json:"-" db:"updated_at"`
+	ID            int64      `json:"-" db:"id"`
+	AccountID     int64      `json:"account_id" db:"account_id"`
+	AccountNumber string     `json:"account_number" db:"-"`
+	Balance       string     `json:"balance" db:"balance"`
+	ProductCode   *string    `json:"product_code,omitempty" db:"product_code"`
+	CustomerID    *int64     `json:"customer_id,omitempty" db:"customer_id"`
+	FrozenAt      *time.Time `json:"frozen_at,omitempty" db:"frozen_at"`
+	CreatedAt     time.Time  `json:"-" db:"created_at"`
+	UpdatedAt     time.Time  `json:"-" db:"updated_at"`
 }
 
 type Transaction struct {
-	ID                   int64     `json:"-" db:"id"`
-	TransactionID        uuid.UUID `json:"transaction_id" db:"transaction_id"`
-	SourceAccountID      int64     `json:"source_account_id" db:"source_account_id"`
-	DestinationAccountID int64     `json:"destination_account_id" db:"destination_account_id"`
-	Amount               string    `json:"amount" db:"amount"`
-	Status               string    `json:"status" db:"status"`
-	CreatedAt            time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
+	ID                   int64             `json:"-" db:"id"`
+	TransactionID        uuid.UUID         `json:"transaction_id" db:"transaction_id"`
+	SourceAccountID      int64             `json:"source_account_id" db:"source_account_id"`
+	DestinationAccountID int64             `json:"destination_account_id" db:"destination_account_id"`
+	Amount               string            `json:"amount" db:"amount"`
+	Status               string            `json:"status" db:"status"`
+	Reference            string            `json:"reference,omitempty" db:"reference"`
+	Description          string            `json:"description,omitempty" db:"description"`
+	Metadata             map[string]string `json:"metadata" db:"metadata"`
+	APIKeyID             string            `json:"api_key_id,omitempty" db:"api_key_id"`
+	ReversalOf           *uuid.UUID        `json:"reversal_of,omitempty" db:"reversal_of"`
+	CreatedAt            time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// TransactionFilter holds the search criteria for listing transactions. Every
+// key/value in Metadata has to match.
+type TransactionFilter struct {
+	Reference string
+	Metadata  map[string]string
+	// CustomerID limits the results to transfers from or to accounts the
+	// customer owns, when non-zero
+	CustomerID int64
+	Limit      int
+}
+
+type TransactionListResponse struct {
+	Transactions []*Transaction `json:"transactions"`
 }
 
 type CreateTransactionSuccessResponse struct {
 	TransactionID uuid.UUID `json:"transaction_id"`
 }
 
+// CreateAccountRequest creates an account with the caller's AccountID, or with an
+// ID allocated by the server when AccountID is omitted
 type CreateAccountRequest struct {
-	AccountID      int64  `json:"account_id" validate:"required,gt=0"`
-	InitialBalance string `json:"initial_balance" validate:"required"`
+	AccountID      int64  `json:"account_id" validate:"gte=0"`
+	InitialBalance string `json:"initial_balance" validate:"required,decimal,nonnegative,maxprecision=8,maxamount=999999999999"`
+	ProductCode    string `json:"product_code,omitempty" validate:"max=50"`
+	CustomerID     int64  `json:"customer_id,omitempty" validate:"gte=0"`
 }
 
+// CreateTransactionRequest identifies each account either by its ID or by its
+// account number; when both are given they must refer to the same account
 type CreateTransactionRequest struct {
-	SourceAccountID      int64  `json:"source_account_id" validate:"required,gt=0"`
-	DestinationAccountID int64  `json:"destination_account_id" validate:"required,gt=0"`
-	Amount               string `json:"amount" validate:"required"`
+	SourceAccountID          int64             `json:"source_account_id" validate:"required_without=SourceAccountNumber,gte=0"`
+	SourceAccountNumber      string            `json:"source_account_number,omitempty" validate:"omitempty,accountnumber"`
+	DestinationAccountID     int64             `json:"destination_account_id" validate:"required_without=DestinationAccountNumber,gte=0"`
+	DestinationAccountNumber string            `json:"destination_account_number,omitempty" validate:"omitempty,accountnumber"`
+	Amount                   string            `json:"amount" validate:"required,decimal,positive,maxprecision=8,maxamount=999999999999"`
+	Reference                string            `json:"reference,omitempty" validate:"max=255"`
+	Description              string            `json:"description,omitempty" validate:"max=1024"`
+	Metadata                 map[string]string `json:"metadata,omitempty" validate:"max=50,metadatasize=4096,dive,keys,required,max=64,endkeys,max=512"`
 }
 
 const (
 	TransactionStatusPending   = "pending"
 	TransactionStatusCompleted = "completed"
 	TransactionStatusFailed    = "failed"
+	// TransactionStatusReversed marks a completed transaction undone by a reversal
+	TransactionStatusReversed = "reversed"
 )
+
+type Customer struct {
+	CustomerID        int64             `json:"customer_id" db:"id"`
+	Name              string            `json:"name" db:"name"`
+	ExternalReference string            `json:"external_reference" db:"external_reference"`
+	KYCStatus         string            `json:"kyc_status" db:"kyc_status"`
+	Metadata          map[string]string `json:"metadata" db:"metadata"`
+	CreatedAt         time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+type CreateCustomerRequest struct {
+	Name              string            `json:"name" validate:"required,max=255"`
+	ExternalReference string            `json:"external_reference" validate:"required,max=255"`
+	KYCStatus         string            `json:"kyc_status" validate:"omitempty,oneof=pending verified rejected"`
+	Metadata          map[string]string `json:"metadata" validate:"max=50,metadatasize=4096,dive,keys,required,max=64,endkeys,max=512"`
+}
+
+type UpdateKYCStatusRequest struct {
+	KYCStatus string `json:"kyc_status" validate:"required,oneof=pending verified rejected"`
+}
+
+type CustomerAccountsResponse struct {
+	CustomerID   int64      `json:"customer_id"`
+	Accounts     []*Account `json:"accounts"`
+	AccountCount int        `json:"account_count"`
+	TotalBalance string     `json:"total_balance"`
+}
+
+type Product struct {
+	ID                 int64     `json:"-" db:"id"`
+	ProductCode        string    `json:"product_code" db:"product_code"`
+	Name               string    `json:"name" db:"name"`
+	AnnualInterestRate string    `json:"annual_interest_rate" db:"annual_interest_rate"`
+	DayCountConvention string    `json:"day_count_convention" db:"day_count_convention"`
+	CreatedAt          time.Time `json:"-" db:"created_at"`
+}
+
+type CreateProductRequest struct {
+	ProductCode        string `json:"product_code" validate:"required,max=50"`
+	Name               string `json:"name" validate:"required,max=255"`
+	AnnualInterestRate string `json:"annual_interest_rate" validate:"required,decimal,nonnegative,maxprecision=6,maxamount=999"`
+	DayCountConvention string `json:"day_count_convention" validate:"required,oneof=ACT/365 30/360"`
+}
+
+// InterestBearingAccount is an account attached to a product together with
+// the state the accrual job needs to decide which days still have to be accrued.
+type InterestBearingAccount struct {
+	AccountID          int64
+	AnnualInterestRate string
+	DayCountConvention string
+	CreatedAt          time.Time
+	LastAccrualDate    *time.Time
+}
+
+type InterestAccrual struct {
+	AccountID           int64      `json:"account_id" db:"account_id"`
+	AccrualDate         time.Time  `json:"accrual_date" db:"accrual_date"`
+	EndOfDayBalance     string     `json:"end_of_day_balance" db:"end_of_day_balance"`
+	AnnualInterestRate  string     `json:"annual_interest_rate" db:"annual_interest_rate"`
+	Amount              string     `json:"amount" db:"amount"`
+	PostedTransactionID *uuid.UUID `json:"posted_transaction_id,omitempty" db:"posted_transaction_id"`
+	PostedAt            *time.Time `json:"posted_at,omitempty" db:"posted_at"`
+}
+
+// PendingInterestPosting identifies a month of accruals for one account
+// that has not been posted yet.
+type PendingInterestPosting struct {
+	AccountID int64
+	Month     time.Time
+}
+
+// Page sizes for transaction search. Limits on request fields live in the
+// validate tags of the request models.
+const (
+	DefaultTransactionPageLen = 50
+	MaxTransactionPageLen     = 500
+)
+
+const (
+	KYCStatusPending  = "pending"
+	KYCStatusVerified = "verified"
+	KYCStatusRejected = "rejected"
+)
+
+// APIKey is a credential for the HTTP and gRPC APIs. Only a hash of the key
+// is stored; the key itself is shown once, when it is created.
+type APIKey struct {
+	KeyID     string     `json:"key_id" db:"key_id"`
+	Name      string     `json:"name" db:"name"`
+	TenantID  string     `json:"tenant_id" db:"tenant_id"`
+	KeyHash   string     `json:"-" db:"key_hash"`
+	Scopes    []string   `json:"scopes" db:"scopes"`
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+const (
+	DayCountActual365 = "ACT/365"
+	DayCount30360     = "30/360"
+)
+
+// ReconciliationReport lists what a reconciliation run found in a tenant.
+// StalePendingTransactions never moved money; when the run fixed them they
+// are returned with their new failed status.
+type ReconciliationReport struct {
+	TenantID                 string         `json:"tenant_id"`
+	StalePendingTransactions []*Transaction `json:"stale_pending_transactions"`
+	NegativeBalanceAccounts  []*Account     `json:"negative_balance_accounts"`
+	Fixed                    bool           `json:"fixed"`
+}
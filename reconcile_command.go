@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"txn-service/internal/repository"
+	"txn-service/internal/service"
+)
+
+const reconcileUsage = `usage: txn-service reconcile [--pending-older-than <duration>] [--fix] [--tenant <tenant>] [-o table|json]
+
+Lists the transactions pending for longer than --pending-older-than, 1h by
+default, which a transfer that failed left behind, and the accounts below
+zero. With --fix the pending transactions are marked failed; balances are
+only reported.
+`
+
+// runReconcileCommand reports and optionally fixes the inconsistencies of a
+// tenant and returns the process exit code. It exits 1 when anything is left
+// to look at, so it can run from cron or CI.
+func runReconcileCommand(args []string) int {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	source := configFlags(flags)
+	pendingOlderThan := flags.Duration("pending-older-than", time.Hour, "age after which a pending transaction is stale")
+	fix := flags.Bool("fix", false, "mark the stale pending transactions failed")
+	tenantID := tenantFlag(flags)
+	output := outputFlag(flags)
+	positional, err := parseFlags(flags, args)
+	if err != nil || len(positional) > 0 {
+		fmt.Fprint(os.Stderr, reconcileUsage)
+		return 2
+	}
+	if err := checkOutput(*output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	ctx, err := tenantContext(*tenantID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	env, err := openAdminEnv(source, true)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer env.Close()
+
+	reconciliationService := service.NewReconciliationService(
+		repository.NewTransactionRepository(env.db, env.cfg.TransferIsolationLevel(), env.log),
+		repository.NewAccountRepository(env.db, env.log),
+		env.cfg.InterestExpenseAccountID,
+		env.log,
+	)
+
+	report, err := reconciliationService.Reconcile(ctx, *pendingOlderThan, *fix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to reconcile: %v\n", err)
+		return 1
+	}
+
+	err = printOutput(*output, report, func(w io.Writer) {
+		heading := "Stale pending transactions"
+		if report.Fixed {
+			heading += ", now failed"
+		}
+		fmt.Fprintf(w, "%s: %d\n", heading, len(report.StalePendingTransactions))
+		if len(report.StalePendingTransactions) > 0 {
+			printTransactions(w, report.StalePendingTransactions...)
+		}
+		fmt.Fprintf(w, "\nNegative balances: %d\n", len(report.NegativeBalanceAccounts))
+		if len(report.NegativeBalanceAccounts) > 0 {
+			printAccounts(w, report.NegativeBalanceAccounts...)
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print report: %v\n", err)
+		return 1
+	}
+
+	if len(report.NegativeBalanceAccounts) > 0 || (!report.Fixed && len(report.StalePendingTransactions) > 0) {
+		return 1
+	}
+	return 0
+}
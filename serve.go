@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"txn-service/internal/app"
+	"txn-service/internal/database"
+	"txn-service/internal/logger"
+	"txn-service/internal/tracing"
+)
+
+// runServe runs the HTTP and gRPC servers and the background jobs until the
+// process is told to stop, and returns the process exit code
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	source := configFlags(flags)
+	if positional, err := parseFlags(flags, args); err != nil || len(positional) > 0 {
+		fmt.Fprintln(os.Stderr, "usage: txn-service serve [--config <file>] [--set <key>=<value> ...]")
+		return 2
+	}
+
+	// Load configuration; every invalid setting is reported before exiting
+	cfg, err := source.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	// Initialize the logger from the config - supports Log level and file logging.
+	// This one logger is passed to every component, and is the default that
+	// logger.FromContext logs to.
+	log := logger.NewFromConfig(cfg.LoggerConfig())
+	defer log.Close()
+	logger.SetDefault(log)
+	log.Info("Starting transaction service")
+	log.Info("Configuration loaded - server_address: %s", cfg.ServerAddress)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.TracingConfig())
+	if err != nil {
+		log.Error("Failed to set up tracing: %v", err)
+		return 1
+	}
+	log.Info("Tracing configured - exporter: %s", cfg.TracingExporter)
+
+	db, err := database.NewConnection(cfg.DatabaseURL, cfg.DatabaseOptions())
+	if err != nil {
+		log.Error("Failed to connect to database: %v", err)
+		return 1
+	}
+	defer db.Close()
+	log.Info("Database connection established")
+
+	application, err := app.New(cfg, source.load, db, log)
+	if err != nil {
+		log.Error("Failed to set up the service: %v", err)
+		return 1
+	}
+
+	// open both listeners before anything starts, so a taken address fails
+	// the start without leaving jobs or a server running
+	httpListener, err := net.Listen("tcp", cfg.ServerAddress)
+	if err != nil {
+		log.Error("Failed to listen for HTTP: %v", err)
+		return 1
+	}
+	grpcListener, err := net.Listen("tcp", cfg.GRPCAddress)
+	if err != nil {
+		httpListener.Close()
+		log.Error("Failed to listen for gRPC: %v", err)
+		return 1
+	}
+
+	application.Jobs.Start(context.Background())
+
+	server := &http.Server{
+		Addr:         cfg.ServerAddress,
+		Handler:      application.Router,
+		ReadTimeout:  cfg.HTTPReadTimeout,
+		WriteTimeout: cfg.HTTPWriteTimeout,
+		IdleTimeout:  cfg.HTTPIdleTimeout,
+	}
+
+	go func() {
+		log.Info("Starting HTTP server - address: %s", cfg.ServerAddress)
+		if err := server.Serve(httpListener); err != nil && err != http.ErrServerClosed {
+			log.Error("Failed to start server: %v", err)
+			os.Exit(1)
+		}
+	}()
+
+	grpcServer := application.GRPCServer
+	go func() {
+		log.Info("Starting gRPC server - address: %s", cfg.GRPCAddress)
+		if err := grpcServer.Serve(grpcListener); err != nil {
+			log.Error("Failed to start gRPC server: %v", err)
+			os.Exit(1)
+		}
+	}()
+
+	// SIGHUP reopens the log file after an external tool like logrotate moved
+	// it, and reloads the configuration
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func() {
+		for range hangup {
+			if err := log.Reopen(); err != nil {
+				log.Error("Failed to reopen log file: %v", err)
+			} else {
+				log.Info("Log file reopened")
+			}
+			// a rejected configuration is logged by the reloader
+			application.Reloader.Reload()
+		}
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+
+	log.Info("Received shutdown signal, shutting down server...")
+
+	// fail readiness first and keep serving while load balancers notice, so
+	// they stop routing new requests here before the listeners close
+	application.Health.ShutDown()
+	if cfg.ShutdownDrainDelay > 0 {
+		log.Info("Readiness failed, draining for %s", cfg.ShutdownDrainDelay)
+		time.Sleep(cfg.ShutdownDrainDelay)
+	}
+
+	application.Jobs.Stop()
+	log.Info("Background jobs stopped")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	// GracefulStop waits for in-flight calls; fall back to Stop at the shutdown deadline
+	grpcStopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(grpcStopped)
+	}()
+
+	if err := server.Shutdown(ctx); err != nil {
+		log.Error("Server forced to shutdown: %v", err)
+		return 1
+	}
+
+	select {
+	case <-grpcStopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
+
+	// flush the spans of the last requests
+	if err := shutdownTracing(ctx); err != nil {
+		log.Error("Failed to flush traces: %v", err)
+	}
+
+	log.Info("Server shutdown completed")
+	return 0
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"txn-service/internal/repository"
+	"txn-service/internal/service"
+	"txn-service/internal/settings"
+	"txn-service/models"
+
+	"github.com/google/uuid"
+)
+
+const transactionsUsage = `usage: txn-service transactions <command>
+
+commands:
+  show <transaction id>                         show a transaction
+  reverse <transaction id> [--reason <text>]    move the amount of a completed
+                                                transaction back and mark it reversed
+
+Every command takes --tenant <tenant> and -o/--output table|json.
+`
+
+// runTransactionsCommand inspects and reverses transactions through the
+// transaction service and returns the process exit code
+func runTransactionsCommand(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, transactionsUsage)
+		return 2
+	}
+
+	flags := flag.NewFlagSet("transactions "+args[0], flag.ContinueOnError)
+	source := configFlags(flags)
+	tenantID := tenantFlag(flags)
+	output := outputFlag(flags)
+	var reason *string
+	switch args[0] {
+	case "show":
+	case "reverse":
+		reason = flags.String("reason", "", "description of the reversal transaction")
+	default:
+		fmt.Fprint(os.Stderr, transactionsUsage)
+		return 2
+	}
+	positional, err := parseFlags(flags, args[1:])
+	if err != nil || len(positional) != 1 {
+		fmt.Fprint(os.Stderr, transactionsUsage)
+		return 2
+	}
+	if err := checkOutput(*output); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	transactionID, err := uuid.Parse(positional[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid transaction ID %q: %v\n", positional[0], err)
+		return 2
+	}
+
+	ctx, err := tenantContext(*tenantID)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	env, err := openAdminEnv(source, true)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer env.Close()
+
+	transactionService := service.NewTransactionService(
+		repository.NewTransactionRepository(env.db, env.cfg.TransferIsolationLevel(), env.log),
+		repository.NewAccountRepository(env.db, env.log),
+		settings.NewStore(env.cfg.Settings()),
+		env.log,
+	)
+
+	var transaction *models.Transaction
+	switch args[0] {
+	case "show":
+		transaction, err = transactionService.GetTransaction(ctx, transactionID)
+	case "reverse":
+		transaction, err = transactionService.ReverseTransaction(ctx, transactionID, *reason)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to %s transaction: %v\n", args[0], err)
+		return 1
+	}
+
+	if err := printOutput(*output, transaction, func(w io.Writer) { printTransactions(w, transaction) }); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to print transaction: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// printTransactions writes transactions as table rows
+func printTransactions(w io.Writer, transactions ...*models.Transaction) {
+	fmt.Fprintln(w, "TRANSACTION ID\tSOURCE\tDESTINATION\tAMOUNT\tSTATUS\tREVERSAL OF\tCREATED")
+	for _, transaction := range transactions {
+		reversalOf := "-"
+		if transaction.ReversalOf != nil {
+			reversalOf = transaction.ReversalOf.String()
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\t%s\n", transaction.TransactionID, transaction.SourceAccountID, transaction.DestinationAccountID,
+			transaction.Amount, transaction.Status, reversalOf, formatTime(&transaction.CreatedAt))
+	}
+}